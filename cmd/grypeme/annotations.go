@@ -0,0 +1,78 @@
+// Package main provides GitHub workflow annotations for scan results. Each
+// finding is printed as an ::error::, ::warning::, or ::notice:: workflow
+// command so it shows up in the Checks and Files changed views; see
+// https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions.
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxAnnotations caps the annotations emitted per run; GitHub shows at most
+// 50 annotations per job and silently drops the rest.
+const maxAnnotations = 50
+
+// annotationLevel maps a match to its workflow command: findings at or above
+// cutoff are errors (critical, high) or warnings, findings below it notices.
+func annotationLevel(severity, cutoff string) string {
+	if !isAtOrAboveCutoff(severity, cutoff) {
+		return "notice"
+	}
+	if sarifLevel(severity) == "error" {
+		return "error"
+	}
+	return "warning"
+}
+
+// escapeAnnotationData escapes the message of a workflow command.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a property value (file, title) of a
+// workflow command, which additionally must not contain ':' or ','.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// formatAnnotation renders one match as a workflow command, e.g.
+// "::error file=go.mod,title=CVE-2024-1 (High)::openssl 3.0.0 is affected
+// by CVE-2024-1; fixed in 3.0.1". The file property is the first path grype
+// reported for the package, relative to the scan root, and is omitted when
+// grype reported none.
+func formatAnnotation(m GrypeMatch, cutoff string) string {
+	fix := "no fix available"
+	if versions := m.Vulnerability.Fix.Versions; len(versions) > 0 {
+		fix = "fixed in " + strings.Join(versions, ", ")
+	}
+
+	properties := []string{}
+	if len(m.Artifact.Locations) > 0 && m.Artifact.Locations[0].Path != "" {
+		properties = append(properties, "file="+escapeAnnotationProperty(strings.TrimPrefix(m.Artifact.Locations[0].Path, "/")))
+	}
+	properties = append(properties, "title="+escapeAnnotationProperty(fmt.Sprintf("%s (%s)", m.Vulnerability.ID, m.Vulnerability.Severity)))
+
+	message := fmt.Sprintf("%s %s is affected by %s; %s", m.Artifact.Name, m.Artifact.Version, m.Vulnerability.ID, fix)
+	return fmt.Sprintf("::%s %s::%s", annotationLevel(m.Vulnerability.Severity, cutoff), strings.Join(properties, ","), escapeAnnotationData(message))
+}
+
+// printAnnotations prints a workflow annotation for each match, most severe
+// first (see sortMatches). cutoff is the severity-cutoff input deciding
+// between error/warning and notice. At most maxAnnotations are printed; a
+// log message reports how many were left out. The annotations are workflow
+// commands for the runner and stay plain text in JSON log format. Called from processResults
+// when the annotations input is set.
+func printAnnotations(matches []GrypeMatch, cutoff string) {
+	sorted := sortMatches(matches)
+	shown := sorted
+	if len(shown) > maxAnnotations {
+		shown = shown[:maxAnnotations]
+	}
+	for _, m := range shown {
+		fmt.Println(formatAnnotation(m, cutoff))
+	}
+	if omitted := len(sorted) - len(shown); omitted > 0 {
+		logInfof("Annotated the %d most severe of %d vulnerabilities; %d more omitted (GitHub shows at most %d annotations per job)", len(shown), len(sorted), omitted, maxAnnotations)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestPrintAnnotations verifies that findings show up inline in the Checks
+// and Files changed views, graded by the severity-cutoff the build gates on.
+//
+// This test covers printAnnotations, formatAnnotation, annotationLevel, and
+// the escaping helpers in annotations.go, called from processResults when the
+// annotations input is set.
+//
+// It captures stdout at cutoff "medium" and asserts ::error:: for a critical
+// finding with its file and fix versions, ::warning:: for a medium one,
+// ::notice:: below the cutoff, escaped newlines and property separators, and
+// a truncation note once more than maxAnnotations findings are printed.
+func TestPrintAnnotations(t *testing.T) {
+	critical := makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", []string{"3.0.1", "3.1.0"}, "", "")
+	critical.Artifact.Locations = append(critical.Artifact.Locations, struct {
+		Path string `json:"path"`
+	}{Path: "/go.mod"})
+	matches := []GrypeMatch{
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-MED", "Medium", "multi\nline", "1.0", nil, "", ""),
+		critical,
+	}
+
+	got := strings.Split(strings.TrimSpace(captureStdout(t, func() { printAnnotations(matches, "medium") })), "\n")
+	want := []string{
+		"::error file=go.mod,title=CVE-CRIT (Critical)::openssl 3.0.0 is affected by CVE-CRIT; fixed in 3.0.1, 3.1.0",
+		"::warning title=CVE-MED (Medium)::multi%0Aline 1.0 is affected by CVE-MED; no fix available",
+		"::notice title=CVE-LOW (Low)::zlib 1.2.11 is affected by CVE-LOW; no fix available",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("printAnnotations() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got := escapeAnnotationProperty("a:b,c%"); got != "a%3Ab%2Cc%25" {
+		t.Errorf("escapeAnnotationProperty() = %q, want a%%3Ab%%2Cc%%25", got)
+	}
+
+	t.Run("caps annotations and notes truncation", func(t *testing.T) {
+		many := make([]GrypeMatch, maxAnnotations+5)
+		for i := range many {
+			many[i] = makeMatch(fmt.Sprintf("CVE-%03d", i), "High", "pkg", "1.0", nil, "", "")
+		}
+		out := captureStdout(t, func() { printAnnotations(many, "high") })
+		if n := strings.Count(out, "::error "); n != maxAnnotations {
+			t.Errorf("printed %d annotations, want %d", n, maxAnnotations)
+		}
+		if !strings.Contains(out, "5 more omitted") {
+			t.Errorf("output = %q, want truncation note", out)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+// Package main provides the release-to-release CVE changelog.
+// The most recent releases are scanned one by one and consecutive scans are
+// diffed, producing a Markdown section suitable for release notes.
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultChangelogReleases is the number of releases scanned when
+// cve-changelog-releases is not set.
+const defaultChangelogReleases = 3
+
+// releaseScan is the parsed scan result of one release tag.
+type releaseScan struct {
+	Tag    string
+	Output *GrypeOutput
+}
+
+// changelogEntry lists the CVE changes between two consecutive releases.
+type changelogEntry struct {
+	From       string       // Older release tag
+	To         string       // Newer release tag
+	Introduced []GrypeMatch // Findings present in To but not in From
+	Resolved   []GrypeMatch // Findings present in From but not in To
+}
+
+// parseChangelogReleases parses the cve-changelog-releases input.
+// An empty value selects defaultChangelogReleases; at least two releases are
+// needed to form a pair.
+func parseChangelogReleases(value string) (int, error) {
+	if value == "" {
+		return defaultChangelogReleases, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 2 {
+		return 0, fmt.Errorf("invalid cve-changelog-releases %q: must be a whole number of at least 2", value)
+	}
+	return n, nil
+}
+
+// recentReleaseTags returns the n most recent stable semver tags from
+// tagNames in chronological (ascending version) order. Pre-release and
+// non-semver tags are ignored.
+func recentReleaseTags(tagNames []string, n int) []string {
+	var releases []string
+	for _, tag := range tagNames {
+		if _, ok := parseTagVersion(tag); ok && !isPreReleaseTag(tag) {
+			releases = append(releases, tag)
+		}
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return compareTagsDesc(releases[i], releases[j]) > 0
+	})
+
+	if len(releases) > n {
+		releases = releases[len(releases)-n:]
+	}
+	return releases
+}
+
+// diffScans diffs each consecutive pair of release scans (see diffMatches).
+// scans must be in chronological order; the result has len(scans)-1 entries.
+func diffScans(scans []releaseScan) []changelogEntry {
+	var entries []changelogEntry
+	for i := 1; i < len(scans); i++ {
+		added, removed := diffMatches(scans[i].Output, scans[i-1].Output)
+		entries = append(entries, changelogEntry{
+			From:       scans[i-1].Tag,
+			To:         scans[i].Tag,
+			Introduced: sortMatches(added),
+			Resolved:   sortMatches(removed),
+		})
+	}
+	return entries
+}
+
+// renderCVEChangelog formats changelog entries as Markdown, one section per
+// release in chronological order with "Introduced" and "Resolved" lists.
+func renderCVEChangelog(entries []changelogEntry) string {
+	var b strings.Builder
+
+	b.WriteString("# CVE Changelog\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\n## %s (since %s)\n\n", entry.To, entry.From)
+		if len(entry.Introduced) == 0 && len(entry.Resolved) == 0 {
+			b.WriteString("No vulnerability changes.\n")
+			continue
+		}
+		writeChangelogList(&b, "Introduced", entry.Introduced)
+		writeChangelogList(&b, "Resolved", entry.Resolved)
+	}
+
+	return b.String()
+}
+
+// writeChangelogList writes one titled bullet list of findings; empty lists are omitted.
+func writeChangelogList(b *strings.Builder, title string, matches []GrypeMatch) {
+	if len(matches) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "**%s:**\n\n", title)
+	for _, m := range matches {
+		fmt.Fprintf(b, "- %s (%s) in %s %s\n", m.Vulnerability.ID, m.Vulnerability.Severity, m.Artifact.Name, m.Artifact.Version)
+	}
+	b.WriteString("\n")
+}
+
+// writeCVEChangelog scans the most recent releases of the local repository
+// and writes the release-to-release CVE changelog to config.CVEChangelogFile.
+//
+// config.CVEChangelogReleases selects how many releases are scanned (default
+// defaultChangelogReleases); every release is checked out to a temporary
+// worktree and scanned with the same grype options as the main scan.
+//
+// Returns an error if fewer than two releases exist, a release cannot be
+// scanned, or the file cannot be written. Called from run() when the
+// cve-changelog-file input is set.
+func writeCVEChangelog(config Config) error {
+	n, err := parseChangelogReleases(config.CVEChangelogReleases)
+	if err != nil {
+		return err
+	}
+
+	tagNames, err := listRepoTags()
+	if err != nil {
+		return err
+	}
+	tags := recentReleaseTags(tagNames, n)
+	if len(tags) < 2 {
+		return fmt.Errorf("cve-changelog-file needs at least two stable release tags, found %d", len(tags))
+	}
+
+	scans := make([]releaseScan, 0, len(tags))
+	for _, tag := range tags {
+		logInfof("Scanning release %s for CVE changelog", tag)
+		output, _, err := scanRef(config, tag)
+		if err != nil {
+			return fmt.Errorf("failed to scan release %s: %w", tag, err)
+		}
+		scans = append(scans, releaseScan{Tag: tag, Output: output})
+	}
+
+	changelog := renderCVEChangelog(diffScans(scans))
+	if _, err := writeWorkspaceFile(config.CVEChangelogFile, []byte(changelog)); err != nil {
+		return err
+	}
+	return nil
+}
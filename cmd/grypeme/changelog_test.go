@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCVEChangelogThreeReleases verifies that release notes can state which
+// CVEs each release introduced and resolved compared to its predecessor.
+//
+// This test covers diffScans and renderCVEChangelog in changelog.go, used by
+// writeCVEChangelog when cve-changelog-file is set.
+//
+// It diffs scans of v1.0.0, v1.1.0, and v1.2.0 and asserts two entries in
+// chronological order with the expected introduced and resolved CVEs, and
+// that the Markdown lists them under the matching release headings.
+func TestCVEChangelogThreeReleases(t *testing.T) {
+	openssl := makeMatch("CVE-A", "High", "openssl", "3.0.0", nil, "", "")
+	zlib := makeMatch("CVE-B", "Low", "zlib", "1.2.11", nil, "", "")
+	curl := makeMatch("CVE-C", "Critical", "curl", "8.0.0", nil, "", "")
+
+	scans := []releaseScan{
+		{Tag: "v1.0.0", Output: &GrypeOutput{Matches: []GrypeMatch{openssl}}},
+		{Tag: "v1.1.0", Output: &GrypeOutput{Matches: []GrypeMatch{openssl, zlib}}},
+		{Tag: "v1.2.0", Output: &GrypeOutput{Matches: []GrypeMatch{zlib, curl}}},
+	}
+
+	entries := diffScans(scans)
+	if len(entries) != 2 {
+		t.Fatalf("diffScans() returned %d entries, want 2", len(entries))
+	}
+
+	ids := func(matches []GrypeMatch) string {
+		var parts []string
+		for _, m := range matches {
+			parts = append(parts, m.Vulnerability.ID)
+		}
+		return strings.Join(parts, ",")
+	}
+	tests := []struct {
+		from, to, introduced, resolved string
+	}{
+		{"v1.0.0", "v1.1.0", "CVE-B", ""},
+		{"v1.1.0", "v1.2.0", "CVE-C", "CVE-A"},
+	}
+	for i, want := range tests {
+		got := entries[i]
+		if got.From != want.from || got.To != want.to || ids(got.Introduced) != want.introduced || ids(got.Resolved) != want.resolved {
+			t.Errorf("entry %d = %s→%s introduced [%s] resolved [%s], want %s→%s introduced [%s] resolved [%s]",
+				i, got.From, got.To, ids(got.Introduced), ids(got.Resolved), want.from, want.to, want.introduced, want.resolved)
+		}
+	}
+
+	markdown := renderCVEChangelog(entries)
+	first := strings.Index(markdown, "## v1.1.0 (since v1.0.0)")
+	second := strings.Index(markdown, "## v1.2.0 (since v1.1.0)")
+	if first < 0 || second < first {
+		t.Fatalf("changelog sections missing or out of order:\n%s", markdown)
+	}
+	for _, want := range []string{
+		"- CVE-B (Low) in zlib 1.2.11",
+		"**Introduced:**\n\n- CVE-C (Critical) in curl 8.0.0",
+		"**Resolved:**\n\n- CVE-A (High) in openssl 3.0.0",
+	} {
+		if !strings.Contains(markdown[first:], want) {
+			t.Errorf("changelog missing %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown[first:second], "Resolved") {
+		t.Errorf("v1.1.0 section should not list resolved CVEs:\n%s", markdown[first:second])
+	}
+}
+
+// TestRecentReleaseTags verifies that the changelog covers the latest
+// releases in release order, regardless of how tags are listed.
+//
+// This test covers recentReleaseTags and parseChangelogReleases in
+// changelog.go.
+//
+// It asserts that pre-release and non-semver tags are skipped, the newest N
+// stable tags are returned oldest first, and that release counts below two
+// or non-numeric values are rejected.
+func TestRecentReleaseTags(t *testing.T) {
+	tags := []string{"v1.10.0", "v1.2.0", "latest", "v1.9.0", "v2.0.0-rc.1", "v1.0.0"}
+
+	got := strings.Join(recentReleaseTags(tags, 3), ",")
+	if got != "v1.2.0,v1.9.0,v1.10.0" {
+		t.Errorf("recentReleaseTags() = %s, want v1.2.0,v1.9.0,v1.10.0", got)
+	}
+
+	if n, err := parseChangelogReleases(""); err != nil || n != defaultChangelogReleases {
+		t.Errorf("parseChangelogReleases(\"\") = %d, %v; want default", n, err)
+	}
+	for _, invalid := range []string{"1", "abc", "-3"} {
+		if _, err := parseChangelogReleases(invalid); err == nil {
+			t.Errorf("parseChangelogReleases(%q) should fail", invalid)
+		}
+	}
+}
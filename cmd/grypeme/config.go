@@ -2,33 +2,179 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 )
 
+// configFileEnv names the environment variable pointing to an optional
+// defaults file for local runs outside GitHub Actions (see applyConfigFile).
+const configFileEnv = "GRYPE_ME_CONFIG"
+
 // loadConfig reads all action inputs from environment variables and returns a Config struct.
 // GitHub Actions passes inputs as environment variables with the INPUT_ prefix.
 // For example, the "scan" input becomes "INPUT_SCAN".
-func loadConfig() Config {
+//
+// When GRYPE_ME_CONFIG names a file, its values seed the inputs first (see
+// applyConfigFile). Precedence is: INPUT_ environment variable, then the
+// file, then the built-in default. Returns an error if the file cannot be
+// read or has a malformed line.
+func loadConfig() (Config, error) {
+	if path := os.Getenv(configFileEnv); path != "" {
+		if err := applyConfigFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
 	return Config{
-		Scan:           getEnv("INPUT_SCAN", ""),
-		Image:          getEnv("INPUT_IMAGE", ""),
-		ImageSource:    strings.ToLower(getEnv("INPUT_IMAGE-SOURCE", "auto")),
-		Path:           getEnv("INPUT_PATH", ""),
-		SBOM:           getEnv("INPUT_SBOM", ""),
-		FailBuild:      parseBoolEnv("INPUT_FAIL-BUILD", false),
-		SeverityCutoff: strings.ToLower(getEnv("INPUT_SEVERITY-CUTOFF", "medium")),
-		OutputFile:     getEnv("INPUT_OUTPUT-FILE", ""),
-		OnlyFixed:      parseBoolEnv("INPUT_ONLY-FIXED", false),
-		DBUpdate:       parseBoolEnv("INPUT_DB-UPDATE", false),
-		Debug:          parseBoolEnv("INPUT_DEBUG", false),
-		Description:    getEnv("INPUT_DESCRIPTION", ""),
-		GistToken:      getEnv("INPUT_GIST-TOKEN", ""),
-		GistID:         getEnv("INPUT_GIST-ID", ""),
-		GistFilename:   getEnv("INPUT_GIST-FILENAME", ""),
+		Mode:              strings.ToLower(strings.TrimSpace(getEnv("INPUT_MODE", ""))),
+		Scan:              getEnv("INPUT_SCAN", ""),
+		RepoURL:           strings.TrimSpace(getEnv("INPUT_REPO-URL", "")),
+		ScanSubdir:        strings.TrimSpace(getEnv("INPUT_SCAN-SUBDIR", "")),
+		Image:             getEnv("INPUT_IMAGE", ""),
+		ImageSource:       strings.ToLower(getEnv("INPUT_IMAGE-SOURCE", "auto")),
+		Platform:          strings.TrimSpace(getEnv("INPUT_PLATFORM", "")),
+		ScanScope:         strings.ToLower(strings.TrimSpace(getEnv("INPUT_SCAN-SCOPE", ""))),
+		RegistryServer:    strings.TrimSpace(getEnv("INPUT_REGISTRY-SERVER", "")),
+		RegistryUsername:  getEnv("INPUT_REGISTRY-USERNAME", ""),
+		RegistryPassword:  getEnv("INPUT_REGISTRY-PASSWORD", ""),
+		ImageArchive:      getEnv("INPUT_IMAGE-ARCHIVE", ""),
+		Path:              getEnv("INPUT_PATH", ""),
+		SBOM:              getEnv("INPUT_SBOM", ""),
+		SBOMStdin:         parseBoolEnv("INPUT_SBOM-STDIN", false),
+		SBOMContent:       getEnv("INPUT_SBOM-CONTENT", ""),
+		FailBuild:         parseBoolEnv("INPUT_FAIL-BUILD", false),
+		SeverityCutoff:    strings.ToLower(getEnv("INPUT_SEVERITY-CUTOFF", "medium")),
+		FailOnTypes:       parseListEnv("INPUT_FAIL-ON-TYPES"),
+		MaxAllowed:        strings.TrimSpace(getEnv("INPUT_MAX-ALLOWED", "")),
+		SeverityBudget:    getEnv("INPUT_SEVERITY-BUDGET", ""),
+		OutputFiles:       parseListOrLinesEnv("INPUT_OUTPUT-FILE"),
+		MaxOutputSize:     strings.TrimSpace(getEnv("INPUT_MAX-OUTPUT-SIZE", "")),
+		SBOMOutput:        getEnv("INPUT_SBOM-OUTPUT", ""),
+		TemplateFile:      getEnv("INPUT_TEMPLATE-FILE", ""),
+		BaselineFile:      getEnv("INPUT_BASELINE-FILE", ""),
+		OnlyFixed:         parseBoolEnv("INPUT_ONLY-FIXED", false),
+		AddCPEs:           parseBoolEnv("INPUT_ADD-CPES", false),
+		ByCVE:             parseBoolEnv("INPUT_BY-CVE", false),
+		MaxSeverity:       strings.ToLower(strings.TrimSpace(getEnv("INPUT_MAX-SEVERITY", ""))),
+		UnknownSeverityAs: strings.ToLower(strings.TrimSpace(getEnv("INPUT_UNKNOWN-SEVERITY-AS", ""))),
+		DedupeCVEs:        parseBoolEnv("INPUT_DEDUPE-CVES", false),
+		IgnorePackages:    parseListEnv("INPUT_IGNORE-PACKAGES"),
+		IgnoreFile:        strings.TrimSpace(getEnv("INPUT_IGNORE-FILE", "")),
+		DBUpdate:          parseBoolEnv("INPUT_DB-UPDATE", false),
+		DBCacheDir:        getEnv("INPUT_DB-CACHE-DIR", ""),
+		DBMaxAge:          strings.TrimSpace(getEnv("INPUT_DB-MAX-AGE", "")),
+		InputJSON:         getEnv("INPUT_INPUT-JSON", ""),
+		GrypeConfig:       getEnv("INPUT_GRYPE-CONFIG", ""),
+		Exclude:           parseLinesEnv("INPUT_EXCLUDE"),
+		IncludeGitDir:     parseBoolEnv("INPUT_INCLUDE-GIT-DIR", false),
+		AllowPartial:      parseBoolEnv("INPUT_ALLOW-PARTIAL", false),
+		ScanTimeout:       strings.TrimSpace(getEnv("INPUT_SCAN-TIMEOUT", "")),
+		Debug:             parseBoolEnv("INPUT_DEBUG", false),
+		Quiet:             parseBoolEnv("INPUT_QUIET", false),
+		LogFormat:         strings.ToLower(strings.TrimSpace(getEnv("INPUT_LOG-FORMAT", logFormatText))),
+		StrictOutputs:     parseBoolEnv("INPUT_STRICT-OUTPUTS", false),
+		Description:       getEnv("INPUT_DESCRIPTION", ""),
+		CollapseVersions:  parseBoolEnv("INPUT_COLLAPSE-VERSIONS", false),
+		ReportMaxRows:     strings.TrimSpace(getEnv("INPUT_REPORT-MAX-ROWS", "")),
+		ReportSort:        strings.ToLower(strings.TrimSpace(getEnv("INPUT_REPORT-SORT", "severity"))),
+		MatchesJSONLimit:  strings.TrimSpace(getEnv("INPUT_MATCHES-JSON-LIMIT", "")),
+		ReportMinSeverity: strings.ToLower(strings.TrimSpace(getEnv("INPUT_REPORT-MIN-SEVERITY", ""))),
+		CVELinkTemplate:   strings.TrimSpace(getEnv("INPUT_CVE-LINK-TEMPLATE", "")),
+		DBAgeBadge:        parseBoolEnv("INPUT_DB-AGE-BADGE", false),
+		DBStaleAge:        strings.TrimSpace(getEnv("INPUT_DB-STALE-AGE", "")),
+		FailOnStaleDB:     parseBoolEnv("INPUT_FAIL-ON-STALE-DB", false),
+		BadgeHost:         strings.TrimSuffix(strings.TrimSpace(getEnv("INPUT_BADGE-HOST", defaultBadgeHost)), "/"),
+		BadgeStyle:        strings.ToLower(strings.TrimSpace(getEnv("INPUT_BADGE-STYLE", ""))),
+		BadgeColor:        strings.TrimPrefix(strings.TrimSpace(getEnv("INPUT_BADGE-COLOR", "")), "#"),
+		BadgeLabel:        strings.TrimSpace(getEnv("INPUT_BADGE-LABEL", "")),
+
+		CSVFile:            getEnv("INPUT_CSV-FILE", ""),
+		SummaryFile:        getEnv("INPUT_SUMMARY-FILE", ""),
+		OSVFile:            getEnv("INPUT_OSV-FILE", ""),
+		JUnitFile:          getEnv("INPUT_JUNIT-FILE", ""),
+		HTMLFile:           getEnv("INPUT_HTML-FILE", ""),
+		LicenseReport:      getEnv("INPUT_LICENSE-REPORT", ""),
+		Annotations:        parseBoolEnv("INPUT_ANNOTATIONS", false),
+		IncludeFindingHash: parseBoolEnv("INPUT_INCLUDE-FINDING-HASH", false),
+
+		CVEChangelogFile:     getEnv("INPUT_CVE-CHANGELOG-FILE", ""),
+		CVEChangelogReleases: strings.TrimSpace(getEnv("INPUT_CVE-CHANGELOG-RELEASES", "")),
+
+		ScanTagsGlob:               strings.TrimSpace(getEnv("INPUT_SCAN-TAGS-GLOB", "")),
+		ScanTagsIncludePrereleases: parseBoolEnv("INPUT_SCAN-TAGS-INCLUDE-PRERELEASES", false),
+		ScanTagsDir:                getEnv("INPUT_SCAN-TAGS-DIR", defaultScanTagsDir),
+		Parallelism:                strings.TrimSpace(getEnv("INPUT_PARALLELISM", "")),
+		AggregateMode:              strings.ToLower(strings.TrimSpace(getEnv("INPUT_AGGREGATE-MODE", aggregateModeMax))),
+
+		ExpectedGrypeRange: strings.TrimSpace(getEnv("INPUT_EXPECTED-GRYPE-RANGE", "")),
+		StrictGrypeRange:   parseBoolEnv("INPUT_STRICT-GRYPE-RANGE", false),
+
+		GraphQLURL:      getEnv("INPUT_GRAPHQL-URL", ""),
+		GraphQLMutation: getEnv("INPUT_GRAPHQL-MUTATION", ""),
+		GraphQLToken:    getEnv("INPUT_GRAPHQL-TOKEN", ""),
+
+		WebhookURL:            getEnv("INPUT_WEBHOOK-URL", ""),
+		WebhookAuth:           getEnv("INPUT_WEBHOOK-AUTH", ""),
+		WebhookIncludeMatches: parseBoolEnv("INPUT_WEBHOOK-INCLUDE-MATCHES", false),
+		WebhookRequired:       parseBoolEnv("INPUT_WEBHOOK-REQUIRED", false),
+
+		PRComment:   parseBoolEnv("INPUT_PR-COMMENT", false),
+		GitHubToken: getEnv("INPUT_GITHUB-TOKEN", ""),
+
+		GistToken:    getEnv("INPUT_GIST-TOKEN", ""),
+		GistID:       normalizeGistID(getEnv("INPUT_GIST-ID", "")),
+		GistFilename: strings.TrimSpace(getEnv("INPUT_GIST-FILENAME", "")),
+		GistProxy:    strings.TrimSpace(getEnv("INPUT_GIST-PROXY", "")),
+	}, nil
+}
+
+// applyConfigFile reads a simple key=value defaults file and sets the
+// matching INPUT_ environment variables that are not already set, so the
+// environment always wins over the file.
+//
+// Keys are action input names as in action.yml (e.g. "fail-build=true");
+// they are upper-cased and prefixed with INPUT_ like GitHub does. Blank lines
+// and lines starting with "#" are skipped, and whitespace around keys and
+// values is trimmed. Values are single-line; list inputs such as exclude take
+// one entry per file.
+//
+// Returns an error naming the file and line for lines without "=" or with an
+// empty key.
+func applyConfigFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s file: %w", configFileEnv, err)
 	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid %s file %s line %d: want <input>=<value>", configFileEnv, path, lineNo)
+		}
+		envKey := "INPUT_" + strings.ToUpper(key)
+		if os.Getenv(envKey) != "" {
+			continue
+		}
+		if err := os.Setenv(envKey, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s file: %w", configFileEnv, err)
+	}
+	return nil
 }
 
 // getEnv retrieves an environment variable value, returning defaultValue if not set or empty.
@@ -49,6 +195,209 @@ func parseBoolEnv(key string, defaultValue bool) bool {
 	return strings.EqualFold(value, "true")
 }
 
+// parseListEnv parses a comma-separated environment variable into its
+// trimmed, non-empty entries. Returns nil if the variable is not set or empty.
+func parseListEnv(key string) []string {
+	var values []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseLinesEnv parses a newline-separated environment variable (a YAML block
+// scalar in the workflow) into its trimmed, non-empty lines.
+func parseLinesEnv(key string) []string {
+	var values []string
+	for _, line := range strings.Split(os.Getenv(key), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+// parseListOrLinesEnv parses an environment variable separated by commas,
+// newlines, or both into its trimmed, non-empty entries.
+func parseListOrLinesEnv(key string) []string {
+	var values []string
+	for _, part := range strings.FieldsFunc(os.Getenv(key), func(r rune) bool { return r == ',' || r == '\n' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// badgeColorPattern matches shields.io color names and hex values without "#".
+var badgeColorPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// gistIDPattern matches gist IDs, which are hex strings for current gists and
+// short numbers for very old ones.
+var gistIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// validateConfig checks inputs that must be well-formed before any work starts.
+// Most inputs are validated where they are used; this covers the ones whose
+// mistakes would otherwise only surface as broken URLs in the outputs.
+func validateConfig(config Config) error {
+	if err := validateMode(config.Mode); err != nil {
+		return err
+	}
+	if err := validateLogFormat(config.LogFormat); err != nil {
+		return err
+	}
+	if err := validateSeverityCutoff(config.SeverityCutoff); err != nil {
+		return err
+	}
+	if err := validateScanScope(config.ScanScope); err != nil {
+		return err
+	}
+	if err := validateBadgeHost(config.BadgeHost); err != nil {
+		return err
+	}
+	if err := validateBadgeAppearance(config.BadgeStyle, config.BadgeColor); err != nil {
+		return err
+	}
+	if (config.RegistryUsername == "") != (config.RegistryPassword == "") {
+		return fmt.Errorf("registry-username and registry-password must be set together")
+	}
+	if _, err := parseMaxAllowed(config.MaxAllowed); err != nil {
+		return err
+	}
+	if _, err := parseSeverityBudget(config.SeverityBudget); err != nil {
+		return err
+	}
+	if _, err := parseReportMaxRows(config.ReportMaxRows); err != nil {
+		return err
+	}
+	if err := validateCVELinkTemplate(config.CVELinkTemplate); err != nil {
+		return err
+	}
+	if config.ReportSort != "" && !containsString(reportSortKeys, config.ReportSort) {
+		return fmt.Errorf("invalid report-sort %q (allowed: %s)", config.ReportSort, strings.Join(reportSortKeys, ", "))
+	}
+	if _, err := parseMatchesJSONLimit(config.MatchesJSONLimit); err != nil {
+		return err
+	}
+	if _, err := parseMaxOutputSize(config.MaxOutputSize); err != nil {
+		return err
+	}
+	if _, err := parseDBStaleAge(config.DBStaleAge); err != nil {
+		return err
+	}
+	if config.UnknownSeverityAs != "" {
+		if err := validateSeverityName(config.UnknownSeverityAs); err != nil {
+			return fmt.Errorf("invalid unknown-severity-as: %w", err)
+		}
+	}
+	if config.ReportMinSeverity != "" {
+		if err := validateSeverityName(config.ReportMinSeverity); err != nil {
+			return fmt.Errorf("invalid report-min-severity: %w", err)
+		}
+	}
+	if config.MaxSeverity != "" {
+		if err := validateSeverityName(config.MaxSeverity); err != nil {
+			return fmt.Errorf("invalid max-severity: %w", err)
+		}
+	}
+	if err := validateScanTagsGlob(config.ScanTagsGlob); err != nil {
+		return err
+	}
+	if _, err := parseParallelism(config.Parallelism); err != nil {
+		return err
+	}
+	if err := validateAggregateMode(config.AggregateMode); err != nil {
+		return err
+	}
+	if err := validateTemplateFile(config); err != nil {
+		return err
+	}
+	if _, err := parseGistProxy(config.GistProxy); err != nil {
+		return err
+	}
+	if config.GistFilename != "" && sanitizeGistFilename(config.GistFilename) == "" {
+		return fmt.Errorf("invalid gist-filename %q: use letters, digits, '.', '-', or '_' (e.g. my-project)", config.GistFilename)
+	}
+	return validateGistConfig(config.GistToken, config.GistID)
+}
+
+// validateSeverityCutoff rejects cutoffs shouldFail does not know, which it
+// would otherwise silently treat as medium.
+func validateSeverityCutoff(cutoff string) error {
+	if containsString(severityCutoffs, cutoff) || containsString(cutoffSynonyms, cutoff) {
+		return nil
+	}
+	return fmt.Errorf("invalid severity-cutoff %q (allowed: %s, %s)", cutoff, strings.Join(severityCutoffs, ", "), strings.Join(cutoffSynonyms, ", "))
+}
+
+// validateBadgeHost ensures badge-host is an absolute https URL without query
+// or fragment, since badge paths are appended to it verbatim.
+func validateBadgeHost(badgeHost string) error {
+	parsed, err := url.Parse(badgeHost)
+	if err != nil {
+		return fmt.Errorf("invalid badge-host %q: %w", badgeHost, err)
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("invalid badge-host %q: must be an https URL such as %s", badgeHost, defaultBadgeHost)
+	}
+	return nil
+}
+
+// validateBadgeAppearance rejects badge styles shields.io does not know and
+// colors that are neither a color name nor a hex value.
+func validateBadgeAppearance(style, color string) error {
+	if style != "" && !containsString(badgeStyles, style) {
+		return fmt.Errorf("invalid badge-style %q (allowed: %s)", style, strings.Join(badgeStyles, ", "))
+	}
+	if color != "" && !badgeColorPattern.MatchString(color) {
+		return fmt.Errorf("invalid badge-color %q: use a shields.io color name (e.g., blue) or a hex value (e.g., 4c1)", color)
+	}
+	return nil
+}
+
+// validateGistConfig warns when only one of gist-token and gist-id is set,
+// since publishGist then silently skips the badge update, and rejects gist IDs
+// that are not a bare ID after normalizeGistID.
+func validateGistConfig(token, id string) error {
+	switch {
+	case token != "" && id == "":
+		logWarnf("gist-token is set but gist-id is empty; both are required, so the gist badge and report will NOT be updated")
+	case token == "" && id != "":
+		logWarnf("gist-id is set but gist-token is empty; both are required, so the gist badge and report will NOT be updated")
+	case id != "" && !gistIDPattern.MatchString(id):
+		return fmt.Errorf("invalid gist-id %q: use the ID from the gist URL (https://gist.github.com/<user>/<id>)", id)
+	}
+	return nil
+}
+
+// normalizeGistID reduces a pasted gist URL such as
+// "https://gist.github.com/user/abc123" to its ID; other values are only trimmed.
+func normalizeGistID(id string) string {
+	id = strings.TrimSpace(id)
+	for _, prefix := range []string{"https://gist.github.com/", "http://gist.github.com/", "gist.github.com/"} {
+		if rest, ok := strings.CutPrefix(id, prefix); ok {
+			rest = strings.TrimSuffix(rest, "/")
+			if i := strings.LastIndex(rest, "/"); i >= 0 {
+				rest = rest[i+1:]
+			}
+			return rest
+		}
+	}
+	return id
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // isDebugEnabled checks if debug mode is enabled via the INPUT_DEBUG environment variable.
 // This is a convenience function that can be called without loading the full config.
 func isDebugEnabled() bool {
@@ -58,7 +407,7 @@ func isDebugEnabled() bool {
 // printDebugEnv prints all relevant environment variables for debugging purposes.
 // Only variables with INPUT_ or GITHUB_ prefixes are printed (sorted alphabetically).
 func printDebugEnv() {
-	fmt.Println("=== Environment Variables (sorted) ===")
+	logResultf("=== Environment Variables (sorted) ===")
 
 	var relevantVars []string
 	for _, env := range os.Environ() {
@@ -69,10 +418,10 @@ func printDebugEnv() {
 
 	sort.Strings(relevantVars)
 	for _, envVar := range relevantVars {
-		fmt.Println(redactEnvVar(envVar))
+		logResultf("%s", redactEnvVar(envVar))
 	}
 
-	fmt.Println("======================================")
+	logResultf("======================================")
 }
 
 // redactEnvVar masks sensitive environment variable values in debug output.
@@ -93,7 +442,8 @@ func redactEnvVar(envVar string) string {
 func isSensitiveEnvKey(key string) bool {
 	upper := strings.ToUpper(key)
 
-	if upper == "INPUT_GIST-TOKEN" {
+	switch upper {
+	case "INPUT_GIST-TOKEN", "INPUT_REGISTRY-USERNAME", "INPUT_GIST-PROXY", "INPUT_WEBHOOK-AUTH", "INPUT_WEBHOOK-URL", "INPUT_GRAPHQL-URL":
 		return true
 	}
 
@@ -111,12 +461,14 @@ func isSensitiveEnvKey(key string) bool {
 // It determines the mode based on which config options are set.
 func determineScanMode(config Config) string {
 	switch {
-	case config.Image != "":
+	case config.Image != "", config.ImageArchive != "":
 		return "image"
 	case config.Path != "":
 		return "path"
-	case config.SBOM != "":
+	case config.SBOM != "", config.SBOMStdin, config.SBOMContent != "":
 		return "sbom"
+	case config.ScanTagsGlob != "":
+		return "tags"
 	default:
 		// Repository scan mode
 		scan := config.Scan
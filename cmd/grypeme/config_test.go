@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -78,6 +79,35 @@ func TestParseBoolEnv(t *testing.T) {
 	}
 }
 
+// TestParseListEnv verifies that list inputs such as fail-on-types tolerate
+// the spacing users naturally write in workflow YAML.
+//
+// This test covers parseListEnv in config.go.
+//
+// It asserts that entries are split on commas and trimmed, empty entries
+// are dropped, and an unset variable yields nil.
+func TestParseListEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"unset yields nil", "", nil},
+		{"splits and trims entries", " go-module , npm,deb ", []string{"go-module", "npm", "deb"}},
+		{"drops empty entries", "go-module,, ,npm,", []string{"go-module", "npm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_LIST", tt.value)
+			got := parseListEnv("TEST_LIST")
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || (got == nil) != (tt.want == nil) {
+				t.Errorf("parseListEnv() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsDebugEnabled(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -119,8 +149,14 @@ func TestLoadConfig(t *testing.T) {
 	t.Setenv("INPUT_GIST-TOKEN", "ghp_test123")
 	t.Setenv("INPUT_GIST-ID", "abc123def")
 	t.Setenv("INPUT_GIST-FILENAME", "my-scan")
+	t.Setenv("INPUT_EXPECTED-GRYPE-RANGE", " >=0.100.0 <1.0.0 ")
+	t.Setenv("INPUT_STRICT-GRYPE-RANGE", "true")
+	t.Setenv("INPUT_EXCLUDE", "./vendor/**\n\n  **/testdata/**  \n")
 
-	config := loadConfig()
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
 
 	if config.Scan != "latest_release" {
 		t.Errorf("config.Scan = %v, want latest_release", config.Scan)
@@ -149,6 +185,55 @@ func TestLoadConfig(t *testing.T) {
 	if config.GistFilename != "my-scan" {
 		t.Errorf("config.GistFilename = %v, want my-scan", config.GistFilename)
 	}
+	if config.ExpectedGrypeRange != ">=0.100.0 <1.0.0" {
+		t.Errorf("config.ExpectedGrypeRange = %q, want trimmed range", config.ExpectedGrypeRange)
+	}
+	if !config.StrictGrypeRange {
+		t.Error("config.StrictGrypeRange should be true")
+	}
+	if strings.Join(config.Exclude, ",") != "./vendor/**,**/testdata/**" {
+		t.Errorf("config.Exclude = %q, want trimmed non-blank lines", config.Exclude)
+	}
+	if config.BadgeHost != defaultBadgeHost {
+		t.Errorf("config.BadgeHost = %q, want default %q", config.BadgeHost, defaultBadgeHost)
+	}
+}
+
+// TestValidateBadgeHost verifies that a mistyped badge-host fails the action
+// up front instead of publishing broken badge URLs.
+//
+// This test covers validateBadgeHost and validateConfig in config.go, run at
+// the start of run().
+//
+// It asserts that the default and custom https hosts (with and without a
+// path prefix) are accepted, and that http, relative, and query-carrying
+// values are rejected with an error naming the input.
+func TestValidateBadgeHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		wantErr bool
+	}{
+		{"accepts default host", defaultBadgeHost, false},
+		{"accepts self-hosted https server", "https://badges.internal.example", false},
+		{"accepts https server under a path prefix", "https://tools.example/shields", false},
+		{"rejects plain http", "http://badges.internal.example", true},
+		{"rejects host without scheme", "badges.internal.example", true},
+		{"rejects empty value", "", true},
+		{"rejects query string", "https://badges.internal.example?x=1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(Config{SeverityCutoff: "medium", BadgeHost: tt.host})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "badge-host") {
+				t.Errorf("error %q should name the badge-host input", err)
+			}
+		})
+	}
 }
 
 func TestDetermineScanMode(t *testing.T) {
@@ -160,10 +245,12 @@ func TestDetermineScanMode(t *testing.T) {
 		{"image scan", Config{Image: "alpine:latest"}, "image"},
 		{"path scan", Config{Path: "./src"}, "path"},
 		{"sbom scan", Config{SBOM: "sbom.json"}, "sbom"},
+		{"inline sbom scan", Config{SBOMContent: "{}"}, "sbom"},
 		{"latest_release scan (explicit)", Config{Scan: "latest_release"}, "release"},
 		{"latest_release scan (default)", Config{Scan: ""}, "release"},
 		{"head scan", Config{Scan: "head"}, "head"},
 		{"specific ref scan", Config{Scan: "v1.2.3"}, "ref"},
+		{"tag glob scan", Config{ScanTagsGlob: "v1.*"}, "tags"},
 	}
 
 	for _, tt := range tests {
@@ -184,6 +271,12 @@ func TestRedactEnvVar(t *testing.T) {
 	}{
 		{"gist token", "INPUT_GIST-TOKEN=abc123", "INPUT_GIST-TOKEN=***REDACTED***"},
 		{"generic token", "GITHUB_TOKEN=abc123", "GITHUB_TOKEN=***REDACTED***"},
+		{"registry username", "INPUT_REGISTRY-USERNAME=robot", "INPUT_REGISTRY-USERNAME=***REDACTED***"},
+		{"registry password", "INPUT_REGISTRY-PASSWORD=s3cret", "INPUT_REGISTRY-PASSWORD=***REDACTED***"},
+		{"gist proxy with credentials", "INPUT_GIST-PROXY=http://user:pw@proxy:3128", "INPUT_GIST-PROXY=***REDACTED***"},
+		{"webhook auth", "INPUT_WEBHOOK-AUTH=hook-token", "INPUT_WEBHOOK-AUTH=***REDACTED***"},
+		{"webhook url with credentials", "INPUT_WEBHOOK-URL=https://user:pw@hooks.example.com/?sig=abc", "INPUT_WEBHOOK-URL=***REDACTED***"},
+		{"graphql url with credentials", "INPUT_GRAPHQL-URL=https://user:pw@dash.example.com/graphql", "INPUT_GRAPHQL-URL=***REDACTED***"},
 		{"normal var", "INPUT_SCAN=latest_release", "INPUT_SCAN=latest_release"},
 	}
 
@@ -235,3 +328,225 @@ func captureStdout(t *testing.T, fn func()) string {
 
 	return buf.String()
 }
+
+// TestLoadConfigFile verifies that local runs outside GitHub Actions can seed
+// inputs from a key=value file while INPUT_ variables still take precedence.
+//
+// This test covers applyConfigFile and the GRYPE_ME_CONFIG handling of
+// loadConfig in config.go.
+//
+// It loads a file setting scan, fail-build, and severity-cutoff with
+// INPUT_SEVERITY-CUTOFF also set, and expects the file values except the
+// cutoff from the environment, comments and blank lines to be skipped, the
+// unset output-file to keep its default, and a malformed line or missing
+// file to fail with an error naming GRYPE_ME_CONFIG.
+func TestLoadConfigFile(t *testing.T) {
+	for _, key := range []string{"INPUT_SCAN", "INPUT_FAIL-BUILD", "INPUT_OUTPUT-FILE"} {
+		t.Setenv(key, "") // restored after the test, since the file sets them
+	}
+	t.Setenv("INPUT_SEVERITY-CUTOFF", "critical")
+
+	path := filepath.Join(t.TempDir(), "grype-me.conf")
+	content := "# local defaults\n\nscan = head\nFail-Build=true\nseverity-cutoff=low\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configFileEnv, path)
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if config.Scan != "head" || !config.FailBuild {
+		t.Errorf("config = {Scan: %q, FailBuild: %v}, want file values head and true", config.Scan, config.FailBuild)
+	}
+	if config.SeverityCutoff != "critical" {
+		t.Errorf("config.SeverityCutoff = %q, want critical from the environment", config.SeverityCutoff)
+	}
+	if len(config.OutputFiles) != 0 {
+		t.Errorf("config.OutputFiles = %q, want default", config.OutputFiles)
+	}
+
+	t.Run("rejects malformed line", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.conf")
+		if err := os.WriteFile(bad, []byte("scan=head\nfail-build\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(configFileEnv, bad)
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), configFileEnv) {
+			t.Errorf("loadConfig() error = %v, want GRYPE_ME_CONFIG line 2 error", err)
+		}
+	})
+
+	t.Run("rejects missing file", func(t *testing.T) {
+		t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "missing.conf"))
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), configFileEnv) {
+			t.Errorf("loadConfig() error = %v, want GRYPE_ME_CONFIG read error", err)
+		}
+	})
+}
+
+// TestValidateSeverityCutoff verifies that a typo in severity-cutoff fails the
+// action with the allowed values instead of silently gating at medium.
+//
+// This test covers validateSeverityCutoff and validateConfig in config.go.
+//
+// It asserts that every documented cutoff, including the all/any/none
+// synonyms, is accepted and that an unknown value such as "higj" is rejected
+// with an error listing the valid values.
+func TestValidateSeverityCutoff(t *testing.T) {
+	for _, cutoff := range append(append([]string{}, severityCutoffs...), cutoffSynonyms...) {
+		t.Run("accepts "+cutoff, func(t *testing.T) {
+			if err := validateConfig(Config{SeverityCutoff: cutoff, BadgeHost: defaultBadgeHost}); err != nil {
+				t.Errorf("validateConfig() error = %v, want nil", err)
+			}
+		})
+	}
+
+	t.Run("rejects unknown cutoff with allowed values", func(t *testing.T) {
+		err := validateConfig(Config{SeverityCutoff: "higj", BadgeHost: defaultBadgeHost})
+		if err == nil {
+			t.Fatal("validateConfig() error = nil, want invalid severity-cutoff error")
+		}
+		for _, want := range []string{"severity-cutoff", `"higj"`, "critical, high, medium, low, negligible, all, any, none"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q should contain %q", err, want)
+			}
+		}
+	})
+}
+
+// TestValidateConfigSeverityNames verifies that a typo in an input naming a
+// single severity fails the action before grype runs, not after the scan.
+//
+// This test covers validateConfig in config.go with validateSeverityName in
+// output.go, for max-severity, report-min-severity, and
+// unknown-severity-as.
+//
+// It asserts that each input accepts a valid severity and rejects a typo
+// with an error naming the input.
+func TestValidateConfigSeverityNames(t *testing.T) {
+	tests := []struct {
+		input string
+		set   func(*Config, string)
+	}{
+		{"max-severity", func(c *Config, v string) { c.MaxSeverity = v }},
+		{"report-min-severity", func(c *Config, v string) { c.ReportMinSeverity = v }},
+		{"unknown-severity-as", func(c *Config, v string) { c.UnknownSeverityAs = v }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			config := Config{SeverityCutoff: "medium", BadgeHost: defaultBadgeHost}
+			tt.set(&config, "High")
+			if err := validateConfig(config); err != nil {
+				t.Errorf("validateConfig() error = %v, want nil", err)
+			}
+			tt.set(&config, "hihg")
+			if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "invalid "+tt.input) {
+				t.Errorf("validateConfig() error = %v, want invalid %s error", err, tt.input)
+			}
+		})
+	}
+}
+
+// TestValidateBadgeAppearance verifies that typos in badge-style or
+// badge-color fail the action instead of publishing a broken badge.
+//
+// This test covers validateBadgeAppearance and validateConfig in config.go.
+//
+// It asserts that every shields.io style, color names, and hex values are
+// accepted, and that unknown styles and colors with URL syntax are rejected
+// with an error naming the input.
+func TestValidateBadgeAppearance(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		color   string
+		wantErr string
+	}{
+		{"accepts unset style and color", "", "", ""},
+		{"accepts for-the-badge style", "for-the-badge", "", ""},
+		{"accepts color name", "flat", "blue", ""},
+		{"accepts hex color", "", "4c1", ""},
+		{"rejects unknown style", "rounded", "", "badge-style"},
+		{"rejects color with url syntax", "", "blue?x=1", "badge-color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(Config{SeverityCutoff: "medium", BadgeHost: defaultBadgeHost, BadgeStyle: tt.style, BadgeColor: tt.color})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateConfig() error = %v, want %s error", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateGistConfig verifies that a half-configured gist integration is
+// called out instead of leaving users wondering why their badge never
+// updates, and that a pasted gist URL still works.
+//
+// This test covers normalizeGistID, validateGistConfig, and validateConfig in
+// config.go.
+//
+// It asserts that gist URLs are reduced to their ID, that exactly one of
+// gist-token and gist-id records a warning naming the missing input, and that
+// a gist-id that is still not a bare ID yields an error naming the input.
+func TestValidateGistConfig(t *testing.T) {
+	t.Run("strips pasted gist URLs", func(t *testing.T) {
+		tests := map[string]string{
+			"abc123":                                 "abc123",
+			" abc123 ":                               "abc123",
+			"https://gist.github.com/octocat/abc123": "abc123",
+			"https://gist.github.com/octocat/abc123/": "abc123",
+			"gist.github.com/abc123":                  "abc123",
+		}
+		for in, want := range tests {
+			if got := normalizeGistID(in); got != want {
+				t.Errorf("normalizeGistID(%q) = %q, want %q", in, got, want)
+			}
+		}
+	})
+
+	tests := []struct {
+		name     string
+		token    string
+		id       string
+		wantWarn string
+		wantErr  bool
+	}{
+		{"accepts both unset", "", "", "", false},
+		{"accepts token with hex id", "ghp_x", "0123456789abcdef", "", false},
+		{"warns when gist-id is missing", "ghp_x", "", "gist-id is empty", false},
+		{"warns when gist-token is missing", "", "abc123", "gist-token is empty", false},
+		{"rejects id that is not a bare id", "ghp_x", "https://example.com/abc", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeWarnings()
+			err := validateConfig(Config{SeverityCutoff: "medium", BadgeHost: defaultBadgeHost, GistToken: tt.token, GistID: normalizeGistID(tt.id)})
+			out := strings.Join(takeWarnings(), "\n")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "gist-id") {
+				t.Errorf("validateConfig() error = %v, want gist-id error", err)
+			}
+			if tt.wantWarn == "" {
+				if out != "" {
+					t.Errorf("warnings = %q, want none", out)
+				}
+			} else if !strings.Contains(out, tt.wantWarn) {
+				t.Errorf("warnings = %q, want warning containing %q", out, tt.wantWarn)
+			}
+		})
+	}
+}
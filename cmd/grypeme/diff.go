@@ -0,0 +1,75 @@
+// Package main provides comparison of a scan against a baseline scan.
+// Diff mode lets pull request checks focus on newly introduced vulnerabilities.
+package main
+
+import (
+	"fmt"
+)
+
+// matchKey identifies a finding for diffing: the same vulnerability in the same
+// package at the same installed version is considered the same finding.
+func matchKey(m GrypeMatch) string {
+	return m.Vulnerability.ID + "\x00" + m.Artifact.Name + "\x00" + m.Artifact.Version
+}
+
+// diffMatches compares the current scan with a baseline scan.
+//
+// Matches are keyed by vulnerability ID, package name, and installed version
+// (see matchKey). added holds current matches whose key is absent from the
+// baseline; removed holds baseline matches whose key is absent from the current
+// scan, i.e., vulnerabilities that were fixed. Both keep the input order and
+// duplicate keys within one scan are reported once.
+func diffMatches(current, baseline *GrypeOutput) (added, removed []GrypeMatch) {
+	currentKeys := make(map[string]bool, len(current.Matches))
+	for _, m := range current.Matches {
+		currentKeys[matchKey(m)] = true
+	}
+	baselineKeys := make(map[string]bool, len(baseline.Matches))
+	for _, m := range baseline.Matches {
+		baselineKeys[matchKey(m)] = true
+	}
+
+	seen := map[string]bool{}
+	for _, m := range current.Matches {
+		key := matchKey(m)
+		if !baselineKeys[key] && !seen[key] {
+			added = append(added, m)
+			seen[key] = true
+		}
+	}
+
+	seen = map[string]bool{}
+	for _, m := range baseline.Matches {
+		key := matchKey(m)
+		if !currentKeys[key] && !seen[key] {
+			removed = append(removed, m)
+			seen[key] = true
+		}
+	}
+
+	return added, removed
+}
+
+// loadBaseline parses the grype JSON named by config.BaselineFile and applies
+// the same max-severity filter and ignores (see applyIgnores) as the current
+// scan, so filtered-out findings are not reported as fixed. Returns nil
+// without error when no baseline is set.
+func loadBaseline(config Config) (*GrypeOutput, error) {
+	if config.BaselineFile == "" {
+		return nil, nil
+	}
+
+	baseline, err := parseGrypeOutput(config.BaselineFile, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load baseline-file %q: %w", config.BaselineFile, err)
+	}
+
+	if config.MaxSeverity != "" {
+		baseline.Matches = filterMatchesByMaxSeverity(baseline.Matches, config.MaxSeverity)
+	}
+	baseline.Matches, _, err = applyIgnores(config, baseline.Matches)
+	if err != nil {
+		return nil, err
+	}
+	return baseline, nil
+}
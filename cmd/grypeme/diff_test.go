@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGrypeJSON marshals matches into a minimal grype JSON document in dir
+// and returns its path, for tests that feed files to the action.
+func writeGrypeJSON(t *testing.T, dir, name string, matches []GrypeMatch) string {
+	t.Helper()
+	data, err := json.Marshal(GrypeOutput{Matches: matches})
+	if err != nil {
+		t.Fatalf("failed to marshal grype output: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write grype output: %v", err)
+	}
+	return path
+}
+
+// TestDiffMatches verifies that PR checks can tell which vulnerabilities a
+// change introduced and which it resolved.
+//
+// This test covers diffMatches in diff.go, used by processResults when
+// baseline-file is set.
+//
+// It compares scans where one finding persists, one is new, one was fixed,
+// and one package was upgraded, and asserts that the upgrade counts as both
+// added and removed because findings are keyed by CVE, package, and version.
+func TestDiffMatches(t *testing.T) {
+	baseline := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-2", "Low", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-3", "Medium", "curl", "8.0.0", nil, "", ""),
+	}}
+	current := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-3", "Medium", "curl", "8.1.0", nil, "", ""),
+		makeMatch("CVE-4", "Critical", "libxml2", "2.9.0", nil, "", ""),
+		makeMatch("CVE-4", "Critical", "libxml2", "2.9.0", nil, "", ""),
+	}}
+
+	added, removed := diffMatches(current, baseline)
+
+	ids := func(matches []GrypeMatch) string {
+		var parts []string
+		for _, m := range matches {
+			parts = append(parts, m.Vulnerability.ID+"@"+m.Artifact.Version)
+		}
+		return strings.Join(parts, ",")
+	}
+	if got := ids(added); got != "CVE-3@8.1.0,CVE-4@2.9.0" {
+		t.Errorf("added = %s, want CVE-3@8.1.0,CVE-4@2.9.0", got)
+	}
+	if got := ids(removed); got != "CVE-2@1.2.11,CVE-3@8.0.0" {
+		t.Errorf("removed = %s, want CVE-2@1.2.11,CVE-3@8.0.0", got)
+	}
+}
+
+// TestProcessResultsDiffModeGatesOnNewOnly verifies that with a baseline a
+// PR only fails for vulnerabilities it introduced, not for existing debt.
+//
+// This test covers the diff-mode handling in processResults (main.go) and
+// loadBaseline in diff.go.
+//
+// It runs processResults with fail-build at "high" against a baseline that
+// already contains a critical finding and asserts that a new low finding
+// does not fail the build, that new-cve-count/fixed-cve-count are written to
+// GITHUB_OUTPUT, and that a new high finding does fail it.
+func TestProcessResultsDiffModeGatesOnNewOnly(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+
+	existing := makeMatch("CVE-OLD", "Critical", "openssl", "3.0.0", nil, "", "")
+	fixed := makeMatch("CVE-FIXED", "Medium", "zlib", "1.2.11", nil, "", "")
+	baselinePath := writeGrypeJSON(t, dir, "baseline.json", []GrypeMatch{existing, fixed})
+	config := Config{FailBuild: true, SeverityCutoff: "high", BaselineFile: baselinePath}
+
+	t.Run("passes when only low findings are new", func(t *testing.T) {
+		output := &GrypeOutput{Matches: []GrypeMatch{existing, makeMatch("CVE-NEW", "Low", "curl", "8.0.0", nil, "", "")}}
+		var err error
+		captureStdout(t, func() { _, err = processResults(config, output, nil, scanRunInfo{}) })
+		if err != nil {
+			t.Fatalf("processResults() error = %v, want nil", err)
+		}
+
+		content, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		for _, want := range []string{"new-cve-count=1", "fixed-cve-count=1", "critical=1"} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("outputs missing %q:\n%s", want, content)
+			}
+		}
+	})
+
+	t.Run("fails when a new finding reaches the cutoff", func(t *testing.T) {
+		output := &GrypeOutput{Matches: []GrypeMatch{existing, makeMatch("CVE-NEW", "High", "curl", "8.0.0", nil, "", "")}}
+		var err error
+		captureStdout(t, func() { _, err = processResults(config, output, nil, scanRunInfo{}) })
+		if err == nil || !strings.Contains(err.Error(), "new vulnerabilities") {
+			t.Errorf("processResults() error = %v, want new vulnerabilities error", err)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+// Package main provides first-seen tracking of vulnerabilities across runs.
+// The date each vulnerability ID was first reported is kept in a state file
+// next to the badge in the gist, so the badge can show how long the oldest
+// unresolved critical vulnerability has been present.
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// firstSeenDateLayout is the date format of first-seen entries.
+const firstSeenDateLayout = "2006-01-02"
+
+// firstSeenState is the gist state file written by publishGist.
+type firstSeenState struct {
+	// FirstSeen maps each currently reported vulnerability ID to the date
+	// (YYYY-MM-DD) of the first run that reported it.
+	FirstSeen map[string]string `json:"firstSeen"`
+}
+
+// parseFirstSeenState reads the first-seen dates from a state file. Content
+// that is empty or not valid state yields no dates, so tracking restarts
+// instead of failing the run.
+func parseFirstSeenState(content string) map[string]string {
+	var state firstSeenState
+	if err := json.Unmarshal([]byte(content), &state); err != nil || state.FirstSeen == nil {
+		return map[string]string{}
+	}
+	return state.FirstSeen
+}
+
+// mergeFirstSeen returns the first-seen dates for matches: vulnerabilities
+// already in previous keep their date, newly reported ones get today (a
+// YYYY-MM-DD date), and entries no longer reported are dropped as resolved.
+func mergeFirstSeen(previous map[string]string, matches []GrypeMatch, today string) map[string]string {
+	merged := make(map[string]string, len(matches))
+	for _, m := range matches {
+		id := m.Vulnerability.ID
+		if _, ok := merged[id]; ok {
+			continue
+		}
+		if date, ok := previous[id]; ok {
+			merged[id] = date
+		} else {
+			merged[id] = today
+		}
+	}
+	return merged
+}
+
+// formatFirstSeenState renders first-seen dates as the state file content.
+func formatFirstSeenState(firstSeen map[string]string) string {
+	data, err := json.MarshalIndent(firstSeenState{FirstSeen: firstSeen}, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(data) + "\n"
+}
+
+// oldestCriticalDays returns how many whole days before now the earliest
+// first-seen critical vulnerability among matches was first reported, or -1
+// when no critical vulnerability has a parsable first-seen date.
+func oldestCriticalDays(firstSeen map[string]string, matches []GrypeMatch, now time.Time) int {
+	oldest := -1
+	for _, m := range matches {
+		if !strings.EqualFold(m.Vulnerability.Severity, "critical") {
+			continue
+		}
+		seen, err := time.Parse(firstSeenDateLayout, firstSeen[m.Vulnerability.ID])
+		if err != nil {
+			continue
+		}
+		days := int(now.Sub(seen).Hours() / 24)
+		if days < 0 {
+			days = 0
+		}
+		if days > oldest {
+			oldest = days
+		}
+	}
+	return oldest
+}
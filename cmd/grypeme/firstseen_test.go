@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMergeFirstSeen verifies that the gist remembers when each
+// vulnerability was first reported, so the badge can show how long a
+// critical one has gone unresolved.
+//
+// This test covers mergeFirstSeen, parseFirstSeenState, and
+// formatFirstSeenState in firstseen.go, called from publishGist.
+//
+// It merges a previous state with the current matches and asserts that a
+// new CVE gets today's date, a persisting CVE keeps its original date (also
+// when reported for several packages), and a resolved CVE is dropped. It
+// also asserts that the state survives a format/parse round trip and that
+// invalid state starts over empty.
+func TestMergeFirstSeen(t *testing.T) {
+	previous := map[string]string{
+		"CVE-PERSIST":  "2026-01-05",
+		"CVE-RESOLVED": "2025-12-01",
+	}
+	matches := []GrypeMatch{
+		makeMatch("CVE-PERSIST", "Critical", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-PERSIST", "Critical", "libssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-NEW", "High", "zlib", "1.2.11", nil, "", ""),
+	}
+
+	got := mergeFirstSeen(previous, matches, "2026-03-10")
+	want := map[string]string{"CVE-PERSIST": "2026-01-05", "CVE-NEW": "2026-03-10"}
+	if len(got) != len(want) {
+		t.Errorf("mergeFirstSeen() = %v, want %v", got, want)
+	}
+	for id, date := range want {
+		if got[id] != date {
+			t.Errorf("mergeFirstSeen()[%s] = %q, want %q", id, got[id], date)
+		}
+	}
+
+	state := formatFirstSeenState(got)
+	if !strings.Contains(state, `"firstSeen"`) {
+		t.Errorf("formatFirstSeenState() = %s, want firstSeen field", state)
+	}
+	if parsed := parseFirstSeenState(state); parsed["CVE-PERSIST"] != "2026-01-05" || parsed["CVE-NEW"] != "2026-03-10" {
+		t.Errorf("parseFirstSeenState() = %v, want round-tripped dates", parsed)
+	}
+	for _, content := range []string{"", "not json", `{"schemaVersion":1}`} {
+		if parsed := parseFirstSeenState(content); len(parsed) != 0 {
+			t.Errorf("parseFirstSeenState(%q) = %v, want empty", content, parsed)
+		}
+	}
+}
+
+// TestOldestCriticalDays verifies the "oldest unresolved critical" figure
+// shown in the gist badge.
+//
+// This test covers oldestCriticalDays in firstseen.go and its message in
+// generateBadgeJSON (output.go).
+//
+// It asserts that only critical matches count, that the oldest of them
+// wins, that -1 means no critical finding and leaves the badge message
+// unchanged, and that a known age is appended to the message.
+func TestOldestCriticalDays(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	firstSeen := map[string]string{
+		"CVE-OLD-HIGH": "2025-01-01",
+		"CVE-CRIT-A":   "2026-03-01",
+		"CVE-CRIT-B":   "2026-02-08",
+	}
+	matches := []GrypeMatch{
+		makeMatch("CVE-OLD-HIGH", "High", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-CRIT-A", "Critical", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-CRIT-B", "Critical", "curl", "8.0.0", nil, "", ""),
+	}
+
+	if got := oldestCriticalDays(firstSeen, matches, now); got != 30 {
+		t.Errorf("oldestCriticalDays() = %d, want 30", got)
+	}
+	if got := oldestCriticalDays(firstSeen, matches[:1], now); got != -1 {
+		t.Errorf("oldestCriticalDays() without criticals = %d, want -1", got)
+	}
+
+	stats := VulnerabilityStats{Total: 1, Critical: 1}
+	if badge := generateBadgeJSON(badgeOptions{}, stats, nil, "0.87.0", "", "image", 30); !strings.Contains(badge, "oldest unresolved critical: 30 days") {
+		t.Errorf("generateBadgeJSON() = %s, want oldest critical age in message", badge)
+	}
+	if badge := generateBadgeJSON(badgeOptions{}, stats, nil, "0.87.0", "", "image", -1); strings.Contains(badge, "oldest") {
+		t.Errorf("generateBadgeJSON() = %s, want no age without a first-seen critical", badge)
+	}
+}
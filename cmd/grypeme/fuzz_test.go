@@ -135,27 +135,31 @@ func absInt(v int) int {
 }
 
 func FuzzDetermineBadgeColor(f *testing.F) {
-	f.Add(0, 0, 0, 0, 0)
-	f.Add(1, 0, 0, 0, 0)
-	f.Add(0, 2, 0, 0, 0)
-	f.Add(0, 0, 3, 0, 0)
-	f.Add(0, 0, 0, 4, 0)
+	f.Add(0, 0, 0, 0, 0, 0)
+	f.Add(1, 0, 0, 0, 0, 0)
+	f.Add(0, 2, 0, 0, 0, 0)
+	f.Add(0, 0, 3, 0, 0, 0)
+	f.Add(0, 0, 0, 4, 0, 0)
+	f.Add(0, 0, 0, 0, 5, 0)
+	f.Add(0, 0, 0, 0, 0, 6)
 
 	allowed := map[string]struct{}{
 		"critical":    {},
 		"orange":      {},
 		"yellow":      {},
 		"yellowgreen": {},
+		"lightgrey":   {},
 		"brightgreen": {},
 	}
 
-	f.Fuzz(func(t *testing.T, critical, high, medium, low, other int) {
+	f.Fuzz(func(t *testing.T, critical, high, medium, low, negligible, other int) {
 		stats := VulnerabilityStats{
-			Critical: absInt(critical % 50),
-			High:     absInt(high % 50),
-			Medium:   absInt(medium % 50),
-			Low:      absInt(low % 50),
-			Other:    absInt(other % 50),
+			Critical:   absInt(critical % 50),
+			High:       absInt(high % 50),
+			Medium:     absInt(medium % 50),
+			Low:        absInt(low % 50),
+			Negligible: absInt(negligible % 50),
+			Other:      absInt(other % 50),
 		}
 
 		color := determineBadgeColor(stats)
@@ -170,9 +174,11 @@ func FuzzDetermineBadgeColor(f *testing.F) {
 			t.Fatalf("high present without critical, color must be orange (got %q)", color)
 		case stats.Critical == 0 && stats.High == 0 && stats.Medium > 0 && color != "yellow":
 			t.Fatalf("medium present without higher severities, color must be yellow (got %q)", color)
-		case stats.Critical == 0 && stats.High == 0 && stats.Medium == 0 && (stats.Low > 0 || stats.Other > 0) && color != "yellowgreen":
-			t.Fatalf("only low/other present, color must be yellowgreen (got %q)", color)
-		case stats.Critical == 0 && stats.High == 0 && stats.Medium == 0 && stats.Low == 0 && stats.Other == 0 && color != "brightgreen":
+		case stats.Critical == 0 && stats.High == 0 && stats.Medium == 0 && stats.Low > 0 && color != "yellowgreen":
+			t.Fatalf("low present without higher severities, color must be yellowgreen (got %q)", color)
+		case stats.Critical == 0 && stats.High == 0 && stats.Medium == 0 && stats.Low == 0 && (stats.Negligible > 0 || stats.Other > 0) && color != "lightgrey":
+			t.Fatalf("only negligible/unknown present, color must be lightgrey (got %q)", color)
+		case stats.Critical == 0 && stats.High == 0 && stats.Medium == 0 && stats.Low == 0 && stats.Negligible == 0 && stats.Other == 0 && color != "brightgreen":
 			t.Fatalf("no vulnerabilities, color must be brightgreen (got %q)", color)
 		}
 	})
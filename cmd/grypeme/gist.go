@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -18,15 +20,57 @@ type GistClient struct {
 	Token      string       // GitHub token with gist scope
 	HTTPClient *http.Client // HTTP client (injectable for testing)
 	BaseURL    string       // API base URL (default: https://api.github.com)
+	BadgeHost  string       // shields.io-compatible server for endpoint badge URLs (empty: https://img.shields.io)
+	UserAgent  string       // User-Agent sent with every request, as GitHub asks API clients to (empty: Go's default)
+	// SkipUnchanged skips the PATCH when every file already has the given
+	// content, saving API quota on runs that change nothing (default true).
+	SkipUnchanged bool
 }
 
-// NewGistClient creates a GistClient with the given token and sensible defaults.
+// gistAPIBaseURL is the API base URL of clients created by NewGistClient; a
+// variable so tests can point publishGist at a local server.
+var gistAPIBaseURL = "https://api.github.com"
+
+// NewGistClient creates a GistClient with the given token and sensible defaults:
+// a 30s request timeout, so a hung connection cannot block the action, a
+// "grype_me/<version>" User-Agent, and the proxy from the environment (see
+// gistTransport).
 func NewGistClient(token string) *GistClient {
 	return &GistClient{
-		Token:      token,
-		HTTPClient: &http.Client{Timeout: 30 * time.Second},
-		BaseURL:    "https://api.github.com",
+		Token:         token,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second, Transport: gistTransport(nil)},
+		BaseURL:       gistAPIBaseURL,
+		BadgeHost:     defaultBadgeHost,
+		UserAgent:     "grype_me/" + version,
+		SkipUnchanged: true,
+	}
+}
+
+// gistTransport returns the transport of the gist HTTP client: Go's default
+// transport sending every request through proxy when it is non-nil (the
+// gist-proxy input), and otherwise through the proxy HTTP_PROXY, HTTPS_PROXY,
+// and NO_PROXY select for the request.
+func gistTransport(proxy *url.URL) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
 	}
+	return transport
+}
+
+// parseGistProxy parses the gist-proxy input, an http:// or https:// proxy
+// URL; empty yields nil. The URL is left out of errors since it may carry
+// proxy credentials.
+func parseGistProxy(value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(value)
+	if err != nil || (proxy.Scheme != "http" && proxy.Scheme != "https") || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid gist-proxy: must be an absolute http(s) URL such as http://proxy.example.com:3128")
+	}
+	return proxy, nil
 }
 
 // GistFile represents a single file in a gist update request.
@@ -45,9 +89,20 @@ type gistResponse struct {
 	Files   map[string]gistFileInfo `json:"files"`
 }
 
-// gistFileInfo contains per-file metadata from the gist response.
+// gistFileInfo contains per-file metadata from the gist response. Content is
+// only complete when Truncated is false (the API truncates large files).
 type gistFileInfo struct {
-	RawURL string `json:"raw_url"`
+	RawURL    string `json:"raw_url"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+// gistSnapshot is the state of a gist as read once per run by FetchGist.
+// The previous badge counts and first-seen dates are taken from it, and
+// UpdateGist compares against it and makes its PATCH conditional on its ETag.
+type gistSnapshot struct {
+	Gist *gistResponse // nil when the response body could not be parsed
+	ETag string        // "" when the API sent none
 }
 
 // GistResult contains the URLs returned after a successful gist update.
@@ -57,16 +112,29 @@ type GistResult struct {
 	ReportURL string // HTML URL of the Markdown report section in the gist
 }
 
+// FetchGist reads the current state of the gist with gistID (see fetchGist).
+func (c *GistClient) FetchGist(gistID string) (gistSnapshot, error) {
+	current, etag, err := c.fetchGist(c.gistAPIURL(gistID))
+	return gistSnapshot{Gist: current, ETag: etag}, err
+}
+
 // UpdateGist writes files to a GitHub Gist and returns the resulting URLs.
 // The badgeFilename and reportFilename parameters identify which files in
 // the map should be used for badge and report URL extraction.
 //
 // Parameters:
 //   - gistID: The ID of the gist to update (must already exist)
+//   - current: The gist as read by FetchGist earlier in the run
 //   - badgeFilename: Key in files map for the shields.io badge JSON
 //   - reportFilename: Key in files map for the Markdown report
 //   - files: Map of filename → content for all files to write to the gist
-func (c *GistClient) UpdateGist(gistID, badgeFilename, reportFilename string, files map[string]string) (*GistResult, error) {
+//
+// The update is conditional: current's ETag is sent as If-Match, and a 412
+// Precondition Failed (a concurrent update by another job) triggers one
+// retry with a freshly read ETag. With SkipUnchanged, no PATCH is sent when
+// the badge and report are unchanged (see gistFilesUnchanged); the URLs are
+// then taken from current.
+func (c *GistClient) UpdateGist(gistID string, current gistSnapshot, badgeFilename, reportFilename string, files map[string]string) (*GistResult, error) {
 	gistFiles := make(map[string]GistFile, len(files))
 	for name, content := range files {
 		gistFiles[name] = GistFile{Content: content}
@@ -81,30 +149,29 @@ func (c *GistClient) UpdateGist(gistID, badgeFilename, reportFilename string, fi
 		return nil, fmt.Errorf("failed to marshal gist request: %w", err)
 	}
 
-	apiURL := fmt.Sprintf("%s/gists/%s", c.BaseURL, gistID)
-	req, err := http.NewRequest(http.MethodPatch, apiURL, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	apiURL := c.gistAPIURL(gistID)
+	if c.SkipUnchanged && current.Gist != nil && gistFilesUnchanged(current.Gist, files, badgeFilename, reportFilename) {
+		logInfof("Gist content is unchanged, skipping update")
+		return c.gistResult(current.Gist, badgeFilename, reportFilename), nil
 	}
 
-	req.Header.Set("Authorization", "token "+c.Token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("gist API request failed: %w", err)
+	etag := current.ETag
+	respBody, status, err := c.patchGist(apiURL, body, etag)
+	if err == nil && status == http.StatusPreconditionFailed {
+		// Another job updated the gist since the GET. PATCH only replaces the
+		// files it names, so retrying with the fresh ETag keeps their files.
+		logInfof("Gist was modified concurrently, retrying update once")
+		if _, etag, err = c.fetchGist(apiURL); err != nil {
+			return nil, err
+		}
+		respBody, status, err = c.patchGist(apiURL, body, etag)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read gist response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("gist API returned %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("gist API returned %d: %s", status, truncate(string(respBody), 200))
 	}
 
 	var gistResp gistResponse
@@ -112,30 +179,177 @@ func (c *GistClient) UpdateGist(gistID, badgeFilename, reportFilename string, fi
 		return nil, fmt.Errorf("failed to parse gist response: %w", err)
 	}
 
+	return c.gistResult(&gistResp, badgeFilename, reportFilename), nil
+}
+
+// gistResult extracts the gist, badge endpoint, and report URLs from a gist
+// API response.
+func (c *GistClient) gistResult(gistResp *gistResponse, badgeFilename, reportFilename string) *GistResult {
 	result := &GistResult{
 		GistURL: gistResp.HTMLURL,
 	}
 
 	// Extract URLs for badge and report files
 	if fi, ok := gistResp.Files[badgeFilename]; ok {
+		badgeHost := c.BadgeHost
+		if badgeHost == "" {
+			badgeHost = defaultBadgeHost
+		}
 		// Strip commit hash from raw URL for a stable endpoint
-		result.BadgeURL = buildEndpointBadgeURL(fi.RawURL)
+		result.BadgeURL = buildEndpointBadgeURL(badgeHost, fi.RawURL)
 	}
 	if _, ok := gistResp.Files[reportFilename]; ok {
 		result.ReportURL = buildGistReportURL(gistResp.HTMLURL, reportFilename)
 	}
 
-	return result, nil
+	return result
+}
+
+// gistAPIURL returns the API URL of the gist with gistID.
+func (c *GistClient) gistAPIURL(gistID string) string {
+	return fmt.Sprintf("%s/gists/%s", c.BaseURL, gistID)
+}
+
+// fetchGist reads the gist's current state: its ETag, so the following PATCH
+// can be made conditional with If-Match ("" when the API sends none), and its
+// files for the SkipUnchanged comparison. The parsed gist is nil when the
+// body cannot be parsed, which only disables that comparison.
+func (c *GistClient) fetchGist(apiURL string) (*gistResponse, string, error) {
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	respBody, status, etag, err := c.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if status < 200 || status >= 300 {
+		return nil, "", fmt.Errorf("gist API returned %d: %s", status, truncate(string(respBody), 200))
+	}
+
+	var current gistResponse
+	if err := json.Unmarshal(respBody, &current); err != nil {
+		return nil, etag, nil
+	}
+	return &current, etag, nil
+}
+
+// file returns the content of filename in the snapshot. The boolean is false
+// when the gist could not be parsed or the file is missing or truncated.
+func (s gistSnapshot) file(filename string) (string, bool) {
+	if s.Gist == nil {
+		return "", false
+	}
+	file, ok := s.Gist.Files[filename]
+	if !ok || file.Truncated {
+		return "", false
+	}
+	return file.Content, true
+}
+
+// PreviousBadgeStats returns the counts stored in the badge JSON last
+// published to badgeFilename (see parseBadgeCounts). It returns nil when the
+// file is missing, truncated, or has no counts; the badge then simply shows
+// no trend.
+func (s gistSnapshot) PreviousBadgeStats(badgeFilename string) *VulnerabilityStats {
+	content, ok := s.file(badgeFilename)
+	if !ok {
+		return nil
+	}
+	stats, ok := parseBadgeCounts(content)
+	if !ok {
+		return nil
+	}
+	return &stats
+}
+
+// PreviousFirstSeen returns the first-seen dates last published to
+// stateFilename (see parseFirstSeenState). It returns no dates when the state
+// is missing or truncated, so every reported vulnerability then counts as
+// first seen today.
+func (s gistSnapshot) PreviousFirstSeen(stateFilename string) map[string]string {
+	content, ok := s.file(stateFilename)
+	if !ok {
+		return map[string]string{}
+	}
+	return parseFirstSeenState(content)
+}
+
+// gistFilesUnchanged reports whether writing files would leave what the gist
+// shows unchanged: every file already exists, and the badge and report have
+// the same content apart from the report's "Scanned:" line (see
+// withoutScanTime). The content of other files is not compared, since the
+// raw grype JSON carries a new timestamp on every run and the first-seen
+// state only changes along with the findings. A truncated badge or report
+// counts as changed, since its full content is unknown.
+func gistFilesUnchanged(current *gistResponse, files map[string]string, badgeFilename, reportFilename string) bool {
+	for name := range files {
+		if _, ok := current.Files[name]; !ok {
+			return false
+		}
+	}
+	for _, name := range []string{badgeFilename, reportFilename} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		existing := current.Files[name]
+		if existing.Truncated || withoutScanTime(existing.Content) != withoutScanTime(content) {
+			return false
+		}
+	}
+	return true
+}
+
+// patchGist sends the update, conditional on etag when one is known, and
+// returns the response body and status code.
+func (c *GistClient) patchGist(apiURL string, body []byte, etag string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodPatch, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	respBody, status, _, err := c.do(req)
+	return respBody, status, err
+}
+
+// do sets the common GitHub API headers (plus User-Agent when set), executes req, and returns the
+// response body, status code, and ETag header.
+func (c *GistClient) do(req *http.Request) ([]byte, int, string, error) {
+	req.Header.Set("Authorization", "token "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("gist API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to read gist response: %w", err)
+	}
+	return respBody, resp.StatusCode, resp.Header.Get("ETag"), nil
 }
 
 // buildEndpointBadgeURL creates a shields.io endpoint URL from a gist raw URL.
 // It strips the commit hash from the raw URL so the badge always shows the latest content.
 // Input:  https://gist.githubusercontent.com/user/id/raw/commithash/file.json
 // Output: https://img.shields.io/endpoint?url=https://gist.githubusercontent.com/user/id/raw/file.json
-func buildEndpointBadgeURL(rawURL string) string {
+// badgeHost replaces https://img.shields.io for self-hosted badge servers.
+func buildEndpointBadgeURL(badgeHost, rawURL string) string {
 	// Strip the commit hash segment: .../raw/<hash>/<file> → .../raw/<file>
 	stableURL := stripCommitHash(rawURL)
-	return fmt.Sprintf("https://img.shields.io/endpoint?url=%s", stableURL)
+	return fmt.Sprintf("%s/endpoint?url=%s", badgeHost, stableURL)
 }
 
 // stripCommitHash removes the commit hash from a gist raw URL.
@@ -157,15 +371,34 @@ func stripCommitHash(rawURL string) string {
 	return rawURL
 }
 
-// defaultGistFilenames returns the badge, report, and raw grype JSON filenames
-// based on scan mode. If a custom base filename is provided, it is used;
-// otherwise one is auto-generated from the scan mode.
-func defaultGistFilenames(customBase, scanMode string) (badgeFilename, reportFilename, grypeFilename string) {
-	base := customBase
+// gistFilenameUnsafe matches the characters sanitizeGistFilename drops.
+var gistFilenameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// sanitizeGistFilename turns the gist-filename input into a safe base for
+// gist file keys: whitespace runs become "-", directory separators and other
+// characters outside [A-Za-z0-9._-] are dropped, a trailing ".json" or ".md"
+// is removed (the extensions are added per file), and leading dots and
+// hyphens are trimmed so "../evil" cannot name a hidden or relative file.
+// E.g. "my report.md" becomes "my-report". The result may be empty.
+func sanitizeGistFilename(base string) string {
+	base = strings.Join(strings.Fields(base), "-")
+	base = gistFilenameUnsafe.ReplaceAllString(base, "")
+	for _, ext := range []string{".json", ".md"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	return strings.TrimLeft(base, ".-")
+}
+
+// defaultGistFilenames returns the badge, report, raw grype JSON, and
+// first-seen state filenames based on scan mode. If a custom base filename is
+// provided, its sanitized form (see sanitizeGistFilename) is used; otherwise
+// one is auto-generated from the scan mode.
+func defaultGistFilenames(customBase, scanMode string) (badgeFilename, reportFilename, grypeFilename, stateFilename string) {
+	base := sanitizeGistFilename(customBase)
 	if base == "" {
 		base = fmt.Sprintf("grype-%s", scanMode)
 	}
-	return base + ".json", base + ".md", base + "-grype.json"
+	return base + ".json", base + ".md", base + "-grype.json", base + "-state.json"
 }
 
 // buildGistReportURL creates a rendered Gist URL with file anchor.
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -24,11 +25,65 @@ func TestNewGistClient(t *testing.T) {
 	if c.HTTPClient.Timeout != 30*time.Second {
 		t.Errorf("HTTPClient.Timeout = %v, want %v", c.HTTPClient.Timeout, 30*time.Second)
 	}
+	if !c.SkipUnchanged {
+		t.Error("SkipUnchanged should default to true")
+	}
+	if c.UserAgent != "grype_me/"+version {
+		t.Errorf("UserAgent = %q, want %q", c.UserAgent, "grype_me/"+version)
+	}
+}
+
+// TestGistTransportProxy verifies that gist updates work on corporate runners
+// that route outbound traffic through an HTTP proxy.
+//
+// This test covers gistTransport and parseGistProxy in gist.go, used by
+// NewGistClient and publishGist for the gist-proxy input.
+//
+// It asserts that the default client resolves proxies from the environment,
+// that a gist-proxy URL is used for GitHub API requests, and that proxy URLs
+// without an http(s) scheme are rejected without echoing the URL.
+func TestGistTransportProxy(t *testing.T) {
+	transport, ok := NewGistClient("token").HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("HTTPClient.Transport = %T, want *http.Transport", NewGistClient("token").HTTPClient.Transport)
+	}
+	if reflect.ValueOf(transport.Proxy).Pointer() != reflect.ValueOf(http.ProxyFromEnvironment).Pointer() {
+		t.Error("default transport should use http.ProxyFromEnvironment")
+	}
+
+	proxy, err := parseGistProxy("http://proxy.example.com:3128")
+	if err != nil {
+		t.Fatalf("parseGistProxy() error = %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodPatch, "https://api.github.com/gists/abc", nil)
+	got, err := gistTransport(proxy).Proxy(req)
+	if err != nil || got == nil || got.String() != "http://proxy.example.com:3128" {
+		t.Errorf("Proxy() = %v, %v, want http://proxy.example.com:3128", got, err)
+	}
+
+	if p, err := parseGistProxy(""); p != nil || err != nil {
+		t.Errorf("parseGistProxy(\"\") = %v, %v, want nil, nil", p, err)
+	}
+	for _, value := range []string{"proxy.example.com:3128", "socks5://user:pw@proxy:1080", "http://"} {
+		_, err := parseGistProxy(value)
+		if err == nil || !strings.Contains(err.Error(), "gist-proxy") || strings.Contains(err.Error(), "pw@") {
+			t.Errorf("parseGistProxy(%q) error = %v, want gist-proxy error without the URL", value, err)
+		}
+	}
 }
 
 func TestUpdateGist_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify method and path
+		if r.Method == http.MethodGet {
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = fmt.Fprint(w, `{}`)
+			return
+		}
+
+		// Verify method, path, and precondition
+		if got := r.Header.Get("If-Match"); got != `"v1"` {
+			t.Errorf("If-Match = %q, want %q", got, `"v1"`)
+		}
 		if r.Method != http.MethodPatch {
 			t.Errorf("method = %s, want PATCH", r.Method)
 		}
@@ -73,7 +128,7 @@ func TestUpdateGist_Success(t *testing.T) {
 		BaseURL:    server.URL,
 	}
 
-	result, err := client.UpdateGist("abc123", "grype-release.json", "grype-release.md", map[string]string{
+	result, err := fetchAndUpdateGist(client, "abc123", "grype-release.json", "grype-release.md", map[string]string{
 		"grype-release.json": `{"test":"badge"}`,
 		"grype-release.md":   "# Report",
 	})
@@ -117,7 +172,7 @@ func TestUpdateGist_APIError(t *testing.T) {
 		BaseURL:    server.URL,
 	}
 
-	_, err := client.UpdateGist("nonexistent", "badge.json", "report.md", map[string]string{
+	_, err := fetchAndUpdateGist(client, "nonexistent", "badge.json", "report.md", map[string]string{
 		"badge.json": "{}",
 		"report.md":  "# Report",
 	})
@@ -144,7 +199,7 @@ func TestUpdateGist_Unauthorized(t *testing.T) {
 		BaseURL:    server.URL,
 	}
 
-	_, err := client.UpdateGist("abc123", "badge.json", "report.md", map[string]string{
+	_, err := fetchAndUpdateGist(client, "abc123", "badge.json", "report.md", map[string]string{
 		"badge.json": "{}",
 		"report.md":  "# Report",
 	})
@@ -156,6 +211,219 @@ func TestUpdateGist_Unauthorized(t *testing.T) {
 	}
 }
 
+// TestUpdateGist_RetriesOnPreconditionFailed verifies that two workflow jobs
+// updating the same gist concurrently do not silently overwrite each other.
+//
+// This test covers the ETag handling of GistClient.UpdateGist, fetchGist, and
+// patchGist in gist.go.
+//
+// It runs an httptest gist API whose ETag changes between the first GET and
+// the PATCH, and asserts that the 412 response leads to a second GET and a
+// retried PATCH carrying the new ETag, which then succeeds.
+func TestUpdateGist_RetriesOnPreconditionFailed(t *testing.T) {
+	var gets, patches int
+	var ifMatch []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+			w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, gets))
+			_, _ = fmt.Fprint(w, `{}`)
+			return
+		}
+
+		patches++
+		ifMatch = append(ifMatch, r.Header.Get("If-Match"))
+		if patches == 1 {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"html_url":"https://gist.github.com/user/abc123","files":{}}`)
+	}))
+	defer server.Close()
+
+	client := &GistClient{Token: "test-token", HTTPClient: server.Client(), BaseURL: server.URL}
+	var err error
+	captureStdout(t, func() {
+		_, err = fetchAndUpdateGist(client, "abc123", "badge.json", "report.md", map[string]string{"badge.json": "{}"})
+	})
+	if err != nil {
+		t.Fatalf("UpdateGist() error = %v", err)
+	}
+	if gets != 2 || patches != 2 {
+		t.Errorf("requests = %d GET, %d PATCH, want 2 each", gets, patches)
+	}
+	if strings.Join(ifMatch, ",") != `"v1","v2"` {
+		t.Errorf("If-Match headers = %v, want stale then fresh ETag", ifMatch)
+	}
+}
+
+// TestUpdateGist_SkipsUnchangedContent verifies that runs which produce the
+// same badge and report as the previous run do not spend API quota on a
+// pointless gist write.
+//
+// This test covers the SkipUnchanged handling of GistClient.UpdateGist and
+// gistFilesUnchanged in gist.go.
+//
+// It serves a gist whose files already hold the given content and asserts
+// that no PATCH is made while the badge and report URLs still come from the
+// GET response. It then asserts that a changed or truncated file, or
+// SkipUnchanged turned off, still leads to a PATCH.
+func TestUpdateGist_SkipsUnchangedContent(t *testing.T) {
+	files := map[string]string{"grype-release.json": `{"schemaVersion":1}`, "grype-release.md": "# Report\n"}
+
+	newServer := func(existing map[string]gistFileInfo, patches *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPatch {
+				*patches++
+			}
+			_ = json.NewEncoder(w).Encode(gistResponse{HTMLURL: "https://gist.github.com/user/abc123", Files: existing})
+		}))
+	}
+	existingFiles := func() map[string]gistFileInfo {
+		return map[string]gistFileInfo{
+			"grype-release.json": {RawURL: "https://gist.githubusercontent.com/user/abc123/raw/deadbeef/grype-release.json", Content: files["grype-release.json"]},
+			"grype-release.md":   {RawURL: "https://gist.githubusercontent.com/user/abc123/raw/deadbeef/grype-release.md", Content: files["grype-release.md"]},
+		}
+	}
+
+	t.Run("skips the PATCH when content matches", func(t *testing.T) {
+		patches := 0
+		server := newServer(existingFiles(), &patches)
+		defer server.Close()
+
+		client := &GistClient{Token: "test-token", HTTPClient: server.Client(), BaseURL: server.URL, SkipUnchanged: true}
+		var result *GistResult
+		var err error
+		captureStdout(t, func() {
+			result, err = fetchAndUpdateGist(client, "abc123", "grype-release.json", "grype-release.md", files)
+		})
+		if err != nil {
+			t.Fatalf("UpdateGist() error = %v", err)
+		}
+		if patches != 0 {
+			t.Errorf("PATCH requests = %d, want 0 for unchanged content", patches)
+		}
+		if result.BadgeURL != "https://img.shields.io/endpoint?url=https://gist.githubusercontent.com/user/abc123/raw/grype-release.json" {
+			t.Errorf("BadgeURL = %q, want endpoint URL of the existing file", result.BadgeURL)
+		}
+		if result.ReportURL != "https://gist.github.com/user/abc123#file-grype-release-md" {
+			t.Errorf("ReportURL = %q, want anchor of the existing report", result.ReportURL)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		mutate        func(map[string]gistFileInfo)
+		skipUnchanged bool
+	}{
+		{"patches when a file changed", func(f map[string]gistFileInfo) {
+			f["grype-release.md"] = gistFileInfo{Content: "# Old report\n"}
+		}, true},
+		{"patches when a file is truncated", func(f map[string]gistFileInfo) {
+			f["grype-release.md"] = gistFileInfo{Content: files["grype-release.md"], Truncated: true}
+		}, true},
+		{"patches when a file is missing", func(f map[string]gistFileInfo) { delete(f, "grype-release.md") }, true},
+		{"patches when SkipUnchanged is off", func(map[string]gistFileInfo) {}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := existingFiles()
+			tt.mutate(existing)
+			patches := 0
+			server := newServer(existing, &patches)
+			defer server.Close()
+
+			client := &GistClient{Token: "test-token", HTTPClient: server.Client(), BaseURL: server.URL, SkipUnchanged: tt.skipUnchanged}
+			if _, err := fetchAndUpdateGist(client, "abc123", "grype-release.json", "grype-release.md", files); err != nil {
+				t.Fatalf("UpdateGist() error = %v", err)
+			}
+			if patches != 1 {
+				t.Errorf("PATCH requests = %d, want 1", patches)
+			}
+		})
+	}
+}
+
+// TestGistFilesUnchanged verifies that the unchanged-content check looks at
+// what the gist shows, not at data that differs on every run.
+//
+// This test covers gistFilesUnchanged in gist.go and withoutScanTime in
+// output.go.
+//
+// It compares a gist with new files and asserts that a different raw grype
+// JSON or "Scanned:" line still counts as unchanged, while a changed badge or
+// report body, a truncated report, or a file missing from the gist do not.
+func TestGistFilesUnchanged(t *testing.T) {
+	report := "# Report\n**Scan mode:** image  \n**Scanned:** 2026-03-01 10:00 UTC  \n**Total CVEs:** 1\n"
+	current := func() *gistResponse {
+		return &gistResponse{Files: map[string]gistFileInfo{
+			"b.json":       {Content: `{"message":"1 critical"}`},
+			"r.md":         {Content: report},
+			"b-grype.json": {Content: `{"descriptor":{"timestamp":"2026-03-01T10:00:00Z"}}`},
+		}}
+	}
+	files := func() map[string]string {
+		return map[string]string{
+			"b.json":       `{"message":"1 critical"}`,
+			"r.md":         strings.Replace(report, "2026-03-01 10:00", "2026-03-02 09:30", 1),
+			"b-grype.json": `{"descriptor":{"timestamp":"2026-03-02T09:30:00Z"}}`,
+		}
+	}
+
+	if !gistFilesUnchanged(current(), files(), "b.json", "r.md") {
+		t.Error("gistFilesUnchanged() = false, want true when only timestamps differ")
+	}
+
+	tests := map[string]func(*gistResponse, map[string]string){
+		"changed badge":  func(_ *gistResponse, f map[string]string) { f["b.json"] = `{"message":"2 critical"}` },
+		"changed report": func(_ *gistResponse, f map[string]string) { f["r.md"] = strings.Replace(report, "1\n", "2\n", 1) },
+		"truncated report": func(g *gistResponse, _ map[string]string) {
+			g.Files["r.md"] = gistFileInfo{Content: report, Truncated: true}
+		},
+		"new file": func(_ *gistResponse, f map[string]string) { f["b-state.json"] = "{}" },
+	}
+	for name, mutate := range tests {
+		g, f := current(), files()
+		mutate(g, f)
+		if gistFilesUnchanged(g, f, "b.json", "r.md") {
+			t.Errorf("%s: gistFilesUnchanged() = true, want false", name)
+		}
+	}
+}
+
+// TestPreviousBadgeStats verifies that the badge trend is based on the badge
+// currently published in the gist, and that a missing badge simply means no
+// trend instead of a failure.
+//
+// This test covers gistSnapshot.PreviousBadgeStats in gist.go.
+//
+// It builds snapshots of a gist holding a badge with stored counts and
+// asserts they are returned, and that a missing file, a badge without counts,
+// a truncated badge, or an unparsable gist all yield nil.
+func TestPreviousBadgeStats(t *testing.T) {
+	badge := generateBadgeJSON(badgeOptions{}, VulnerabilityStats{Total: 3, Critical: 1, High: 2}, nil, "0.87.0", "", "release", -1)
+	snapshot := func(files map[string]gistFileInfo) gistSnapshot {
+		return gistSnapshot{Gist: &gistResponse{HTMLURL: "https://gist.github.com/user/abc123", Files: files}}
+	}
+
+	got := snapshot(map[string]gistFileInfo{"grype-release.json": {Content: badge}}).PreviousBadgeStats("grype-release.json")
+	if got == nil || got.Critical != 1 || got.High != 2 || got.Total != 3 {
+		t.Errorf("PreviousBadgeStats() = %+v, want counts from the badge", got)
+	}
+
+	tests := map[string]gistSnapshot{
+		"missing badge file":   snapshot(map[string]gistFileInfo{}),
+		"badge without counts": snapshot(map[string]gistFileInfo{"grype-release.json": {Content: `{"schemaVersion":1}`}}),
+		"truncated badge":      snapshot(map[string]gistFileInfo{"grype-release.json": {Content: badge, Truncated: true}}),
+		"unparsable gist":      {},
+	}
+	for name, current := range tests {
+		if got := current.PreviousBadgeStats("grype-release.json"); got != nil {
+			t.Errorf("%s: PreviousBadgeStats() = %+v, want nil", name, got)
+		}
+	}
+}
+
 func TestStripCommitHash(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -191,7 +459,7 @@ func TestStripCommitHash(t *testing.T) {
 
 func TestBuildEndpointBadgeURL(t *testing.T) {
 	rawURL := "https://gist.githubusercontent.com/user/abc123/raw/deadbeef/badge.json"
-	got := buildEndpointBadgeURL(rawURL)
+	got := buildEndpointBadgeURL(defaultBadgeHost, rawURL)
 
 	if !strings.HasPrefix(got, "https://img.shields.io/endpoint?url=") {
 		t.Errorf("got %q, want shields.io endpoint prefix", got)
@@ -202,6 +470,11 @@ func TestBuildEndpointBadgeURL(t *testing.T) {
 	if !strings.Contains(got, "badge.json") {
 		t.Errorf("got %q, should contain filename", got)
 	}
+
+	custom := buildEndpointBadgeURL("https://badges.internal.example", rawURL)
+	if custom != "https://badges.internal.example/endpoint?url=https://gist.githubusercontent.com/user/abc123/raw/badge.json" {
+		t.Errorf("got %q, want endpoint URL on the custom badge host", custom)
+	}
 }
 
 func TestDefaultGistFilenames(t *testing.T) {
@@ -211,16 +484,17 @@ func TestDefaultGistFilenames(t *testing.T) {
 		wantBadge  string
 		wantReport string
 		wantGrype  string
+		wantState  string
 	}{
-		{"", "release", "grype-release.json", "grype-release.md", "grype-release-grype.json"},
-		{"", "image", "grype-image.json", "grype-image.md", "grype-image-grype.json"},
-		{"my-scan", "release", "my-scan.json", "my-scan.md", "my-scan-grype.json"},
-		{"custom", "head", "custom.json", "custom.md", "custom-grype.json"},
+		{"", "release", "grype-release.json", "grype-release.md", "grype-release-grype.json", "grype-release-state.json"},
+		{"", "image", "grype-image.json", "grype-image.md", "grype-image-grype.json", "grype-image-state.json"},
+		{"my-scan", "release", "my-scan.json", "my-scan.md", "my-scan-grype.json", "my-scan-state.json"},
+		{"custom", "head", "custom.json", "custom.md", "custom-grype.json", "custom-state.json"},
 	}
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s/%s", tt.customBase, tt.scanMode), func(t *testing.T) {
-			badge, report, grype := defaultGistFilenames(tt.customBase, tt.scanMode)
+			badge, report, grype, state := defaultGistFilenames(tt.customBase, tt.scanMode)
 			if badge != tt.wantBadge {
 				t.Errorf("badge = %q, want %q", badge, tt.wantBadge)
 			}
@@ -230,8 +504,53 @@ func TestDefaultGistFilenames(t *testing.T) {
 			if grype != tt.wantGrype {
 				t.Errorf("grype = %q, want %q", grype, tt.wantGrype)
 			}
+			if state != tt.wantState {
+				t.Errorf("state = %q, want %q", state, tt.wantState)
+			}
+		})
+	}
+}
+
+// TestSanitizeGistFilename verifies that a sloppy or malicious gist-filename
+// can never produce odd or unsafe gist file keys.
+//
+// This test covers sanitizeGistFilename and its use by defaultGistFilenames
+// in gist.go, and the gist-filename check of validateConfig in config.go.
+//
+// It asserts that spaces collapse to hyphens, slashes and backslashes are
+// dropped, path-traversal attempts lose their leading dots, a pasted
+// extension is removed, and a base with nothing safe left is rejected.
+func TestSanitizeGistFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"collapses spaces", "my  big report", "my-big-report"},
+		{"strips pasted extension", "my report.md", "my-report"},
+		{"drops directory separators", `badges/sub\dir`, "badgessubdir"},
+		{"defuses path traversal", "../evil", "evil"},
+		{"defuses nested traversal", "../../etc/passwd", "etcpasswd"},
+		{"keeps safe names", "my-project_v1.2", "my-project_v1.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeGistFilename(tt.in); got != tt.want {
+				t.Errorf("sanitizeGistFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
 		})
 	}
+
+	badge, report, grype, state := defaultGistFilenames("../my report", "release")
+	if got := strings.Join([]string{badge, report, grype, state}, ","); got != "my-report.json,my-report.md,my-report-grype.json,my-report-state.json" {
+		t.Errorf("defaultGistFilenames() = %s, want sanitized base", got)
+	}
+
+	for _, base := range []string{"../..", "///", "???"} {
+		if err := validateConfig(Config{SeverityCutoff: "medium", BadgeHost: defaultBadgeHost, GistFilename: base}); err == nil || !strings.Contains(err.Error(), "gist-filename") {
+			t.Errorf("validateConfig(gist-filename %q) error = %v, want gist-filename error", base, err)
+		}
+	}
 }
 
 func TestBuildGistFileAnchor(t *testing.T) {
@@ -263,12 +582,15 @@ func TestUpdateGist_VerifiesRequestHeaders(t *testing.T) {
 		if got := r.Header.Get("Accept"); got != "application/vnd.github+json" {
 			t.Errorf("Accept = %q, want %q", got, "application/vnd.github+json")
 		}
-		if got := r.Header.Get("Content-Type"); got != "application/json" {
+		if got := r.Header.Get("Content-Type"); r.Method == http.MethodPatch && got != "application/json" {
 			t.Errorf("Content-Type = %q, want %q", got, "application/json")
 		}
 		if got := r.Header.Get("X-GitHub-Api-Version"); got != "2022-11-28" {
 			t.Errorf("X-GitHub-Api-Version = %q, want %q", got, "2022-11-28")
 		}
+		if got := r.Header.Get("User-Agent"); got != "grype_me/test" {
+			t.Errorf("User-Agent = %q, want %q", got, "grype_me/test")
+		}
 
 		resp := gistResponse{
 			HTMLURL: "https://gist.github.com/user/abc",
@@ -283,9 +605,10 @@ func TestUpdateGist_VerifiesRequestHeaders(t *testing.T) {
 		Token:      "tok",
 		HTTPClient: server.Client(),
 		BaseURL:    server.URL,
+		UserAgent:  "grype_me/test",
 	}
 
-	_, err := client.UpdateGist("abc", "b.json", "r.md", map[string]string{
+	_, err := fetchAndUpdateGist(client, "abc", "b.json", "r.md", map[string]string{
 		"b.json": "{}",
 		"r.md":   "# R",
 	})
@@ -293,3 +616,12 @@ func TestUpdateGist_VerifiesRequestHeaders(t *testing.T) {
 		t.Fatalf("UpdateGist() error = %v", err)
 	}
 }
+
+// fetchAndUpdateGist runs the FetchGist and UpdateGist sequence of publishGist.
+func fetchAndUpdateGist(client *GistClient, gistID, badgeFilename, reportFilename string, files map[string]string) (*GistResult, error) {
+	current, err := client.FetchGist(gistID)
+	if err != nil {
+		return nil, err
+	}
+	return client.UpdateGist(gistID, current, badgeFilename, reportFilename, files)
+}
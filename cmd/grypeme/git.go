@@ -2,8 +2,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,15 +17,27 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
 )
 
 // getLatestReleaseTag returns the latest stable release tag from the repository.
 // Tags are sorted by semantic version (descending), and pre-release tags are excluded
 // unless all tags are pre-releases.
 func getLatestReleaseTag() (string, error) {
+	tagNames, err := listRepoTags()
+	if err != nil {
+		return "", err
+	}
+
+	return selectLatestReleaseTag(tagNames)
+}
+
+// listRepoTags fetches tags from the remote (best effort) and returns the
+// names of all tags in the repository of the current working directory.
+func listRepoTags() ([]string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return "", fmt.Errorf("failed to get working directory: %w", err)
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
 	repo, err := git.PlainOpenWithOptions(cwd, &git.PlainOpenOptions{
@@ -31,24 +45,24 @@ func getLatestReleaseTag() (string, error) {
 		EnableDotGitCommonDir: true,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to open git repository: %w", err)
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
 	}
 
 	// Fetch all tags to ensure we have the latest
-	fmt.Println("Fetching tags...")
+	logDebugf("Fetching tags...")
 	err = repo.Fetch(&git.FetchOptions{
 		RefSpecs: []config.RefSpec{"refs/tags/*:refs/tags/*"},
 		Force:    true,
 		Tags:     git.AllTags,
 	})
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		fmt.Printf("Warning: Could not fetch tags: %v\n", err)
+		logWarnf("Could not fetch tags: %v", err)
 	}
 
 	// Get all tags
 	tagRefs, err := repo.Tags()
 	if err != nil {
-		return "", fmt.Errorf("failed to list tags: %w", err)
+		return nil, fmt.Errorf("failed to list tags: %w", err)
 	}
 
 	var tagNames []string
@@ -57,9 +71,16 @@ func getLatestReleaseTag() (string, error) {
 		return nil
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to iterate tags: %w", err)
+		return nil, fmt.Errorf("failed to iterate tags: %w", err)
 	}
 
+	return tagNames, nil
+}
+
+// selectLatestReleaseTag picks the latest stable release from tagNames using
+// semver-aware ordering. Pre-release tags are only used, with a warning, when
+// no stable tag exists. Shared by local and remote (repo-url) release scans.
+func selectLatestReleaseTag(tagNames []string) (string, error) {
 	if len(tagNames) == 0 {
 		return "", fmt.Errorf("no release tags found in repository. Use 'scan: head' to scan the current checkout, or create a semver tag (e.g., v1.0.0)")
 	}
@@ -77,7 +98,7 @@ func getLatestReleaseTag() (string, error) {
 	}
 
 	// If all tags are pre-release, use the highest one with a warning
-	fmt.Printf("Warning: All tags appear to be pre-release. Using: %s\n", tagNames[0])
+	logWarnf("All tags appear to be pre-release. Using: %s", tagNames[0])
 	return tagNames[0], nil
 }
 
@@ -244,7 +265,7 @@ func checkoutToWorktree(ref string) (string, error) {
 		return "", fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
-	fmt.Printf("Creating temporary worktree at %s for ref %s\n", tmpDir, ref)
+	logDebugf("Creating temporary worktree at %s for ref %s", tmpDir, ref)
 
 	// Resolve the ref to a hash
 	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
@@ -335,33 +356,37 @@ func cleanupWorktree(worktreeDir string) {
 		return
 	}
 
-	fmt.Printf("Cleaning up temporary worktree at %s\n", worktreeDir)
+	logDebugf("Cleaning up temporary worktree at %s", worktreeDir)
 
 	// Simply remove the directory (it's a standalone clone)
 	if err := os.RemoveAll(worktreeDir); err != nil {
-		fmt.Printf("Warning: failed to remove worktree directory: %v\n", err)
+		logWarnf("failed to remove worktree directory: %v", err)
 	}
 }
 
 // handleRepoScan handles repository-based scanning (latest_release, head, or specific ref).
 // Returns (target, tempDir, error) where tempDir is set if a temporary worktree was created.
 func handleRepoScan(scanMode string) (string, string, error) {
-	fmt.Printf("Repository scan mode: %s\n", scanMode)
+	logInfof("Repository scan mode: %s", scanMode)
 
 	switch strings.ToLower(scanMode) {
 	case "head":
 		// Scan current working directory as-is - no Git operations needed
 		// The user has already checked out what they want via actions/checkout
-		fmt.Println("Scanning current working directory (head mode)")
+		logInfof("Scanning current working directory (head mode)")
 		return "dir:.", "", nil
 
 	case "latest_release":
+		if err := requireGitRepository(); err != nil {
+			return "", "", err
+		}
+
 		// Get the latest release tag and checkout to a temporary worktree
 		latestTag, err := getLatestReleaseTag()
 		if err != nil {
 			return "", "", fmt.Errorf("could not determine latest release: %w", err)
 		}
-		fmt.Printf("Found latest release: %s\n", latestTag)
+		logInfof("Found latest release: %s", latestTag)
 
 		scanDir, err := checkoutToWorktree(latestTag)
 		if err != nil {
@@ -370,8 +395,12 @@ func handleRepoScan(scanMode string) (string, string, error) {
 		return "dir:" + scanDir, scanDir, nil
 
 	default:
+		if err := requireGitRepository(); err != nil {
+			return "", "", err
+		}
+
 		// Treat as a specific tag or branch name
-		fmt.Printf("Checking out ref: %s\n", scanMode)
+		logInfof("Checking out ref: %s", scanMode)
 
 		scanDir, err := checkoutToWorktree(scanMode)
 		if err != nil {
@@ -380,3 +409,158 @@ func handleRepoScan(scanMode string) (string, string, error) {
 		return "dir:" + scanDir, scanDir, nil
 	}
 }
+
+// requireGitRepository checks upfront that the working directory belongs to a
+// git repository, so release and ref scans in a workspace without a checkout
+// (or without its .git directory) fail with one actionable message instead of
+// a confusing tag or worktree error. The action uses go-git, so no git binary
+// is needed.
+func requireGitRepository() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	_, err = git.PlainOpenWithOptions(cwd, &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return fmt.Errorf("repository scan modes need a git checkout, but %s is not inside a git repository; run actions/checkout first (with fetch-depth: 0 for latest_release), or use path, image, or sbom instead", cwd)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open git repository: %w", err)
+	}
+	return nil
+}
+
+// validateRepoURL checks that a repo-url uses an allowed transport.
+// Only https:// and git:// are accepted: file:// could expose the runner's
+// filesystem and ssh:// (including scp-like "git@host:path") would require
+// keys the action never handles.
+func validateRepoURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid repo-url: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "https", "git":
+	default:
+		return fmt.Errorf("unsupported repo-url scheme %q (allowed: https, git)", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("repo-url %q has no host", parsed.Redacted())
+	}
+
+	return nil
+}
+
+// listRemoteTags returns the tag names advertised by the remote repository
+// without cloning it.
+func listRemoteTags(repoURL string) ([]string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repoURL},
+	})
+
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote refs: %w", err)
+	}
+
+	var tagNames []string
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			tagNames = append(tagNames, ref.Name().Short())
+		}
+	}
+	return tagNames, nil
+}
+
+// cloneRepoShallow clones repoURL with depth 1 into a new temporary directory.
+// ref may name a tag or a branch (tags are tried first); an empty ref clones
+// the remote's default branch. The caller must remove the returned directory,
+// typically via cleanupWorktree.
+func cloneRepoShallow(repoURL, ref string) (string, error) {
+	candidates := []plumbing.ReferenceName{""}
+	if ref != "" {
+		if err := validateRefName(ref); err != nil {
+			return "", fmt.Errorf("invalid ref %q: %w", ref, err)
+		}
+		candidates = []plumbing.ReferenceName{
+			plumbing.NewTagReferenceName(ref),
+			plumbing.NewBranchReferenceName(ref),
+		}
+	}
+
+	var lastErr error
+	for _, refName := range candidates {
+		tmpDir, err := os.MkdirTemp("", "grype-clone-*")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp directory: %w", err)
+		}
+
+		_, err = git.PlainClone(tmpDir, false, &git.CloneOptions{
+			URL:           repoURL,
+			ReferenceName: refName,
+			SingleBranch:  true,
+			Depth:         1,
+			Tags:          git.NoTags,
+		})
+		if err == nil {
+			return tmpDir, nil
+		}
+
+		_ = os.RemoveAll(tmpDir)
+		lastErr = err
+		// Only fall through to the next candidate when the ref was not found.
+		if !errors.Is(err, git.NoMatchingRefSpecError{}) && !errors.Is(err, plumbing.ErrReferenceNotFound) {
+			break
+		}
+	}
+
+	if ref == "" {
+		return "", fmt.Errorf("failed to clone repository: %w", lastErr)
+	}
+	return "", fmt.Errorf("failed to clone ref %q: %w", ref, lastErr)
+}
+
+// handleRemoteRepoScan handles scanning a remote repository given by repo-url.
+// scanMode has the same meaning as for local repository scans: "latest_release"
+// (the default) resolves the latest release tag from the remote, "head" uses the
+// default branch, and anything else is treated as a tag or branch name.
+// Returns (target, tempDir, error) where tempDir is the temporary clone.
+func handleRemoteRepoScan(repoURL, scanMode string) (string, string, error) {
+	if err := validateRepoURL(repoURL); err != nil {
+		return "", "", err
+	}
+
+	displayURL := repoURL
+	if parsed, err := url.Parse(repoURL); err == nil {
+		displayURL = parsed.Redacted()
+	}
+	logInfof("Remote repository scan: %s (mode: %s)", displayURL, scanMode)
+
+	ref := scanMode
+	switch strings.ToLower(scanMode) {
+	case "head":
+		ref = ""
+	case "latest_release":
+		tagNames, err := listRemoteTags(repoURL)
+		if err != nil {
+			return "", "", fmt.Errorf("could not determine latest release: %w", err)
+		}
+		ref, err = selectLatestReleaseTag(tagNames)
+		if err != nil {
+			return "", "", fmt.Errorf("could not determine latest release: %w", err)
+		}
+		logInfof("Found latest release: %s", ref)
+	}
+
+	scanDir, err := cloneRepoShallow(repoURL, ref)
+	if err != nil {
+		return "", "", err
+	}
+	logDebugf("Cloned repository into %s", scanDir)
+
+	return "dir:" + scanDir, scanDir, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -101,6 +102,47 @@ func TestHandleRepoScanHead(t *testing.T) {
 	}
 }
 
+// TestHandleRepoScanWithoutGitCheckout verifies that release and ref scans in a
+// workspace without a git checkout fail with one actionable message.
+//
+// This test covers requireGitRepository in git.go, called by handleRepoScan
+// before any tag lookup or worktree checkout. PATH is emptied to show that no
+// git binary is involved.
+//
+// It expects an error naming actions/checkout and the path/image/sbom
+// alternatives for latest_release and a plain ref, and still accepts head.
+func TestHandleRepoScanWithoutGitCheckout(t *testing.T) {
+	oldWD, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(oldWD) })
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+	t.Setenv("PATH", "")
+
+	for _, mode := range []string{"latest_release", "v1.0.0"} {
+		t.Run("rejects "+mode+" outside a repository", func(t *testing.T) {
+			_, tempDir, err := handleRepoScan(mode)
+			if err == nil {
+				t.Fatalf("handleRepoScan(%q) error = nil, want missing checkout error", mode)
+			}
+			if tempDir != "" {
+				t.Errorf("tempDir = %q, want empty", tempDir)
+			}
+			for _, want := range []string{"actions/checkout", "path, image, or sbom"} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	t.Run("accepts head without a repository", func(t *testing.T) {
+		if _, _, err := handleRepoScan("head"); err != nil {
+			t.Errorf("handleRepoScan(head) error = %v", err)
+		}
+	})
+}
+
 func TestGetLatestReleaseTagPrefersStable(t *testing.T) {
 	repoDir := setupTestRepoWithTags(t)
 	oldWD, _ := os.Getwd()
@@ -329,3 +371,108 @@ func setupRepoWithSymlink(t *testing.T) (string, string) {
 
 	return dir, tag
 }
+
+// TestValidateRepoURL verifies that repo-url only accepts transports that are
+// safe to use from a shared runner.
+//
+// This test covers validateRepoURL in git.go, called by handleRemoteRepoScan
+// before anything is fetched.
+//
+// It accepts https:// and git:// URLs and rejects file://, ssh://, scp-like,
+// plain http://, and host-less URLs.
+func TestValidateRepoURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"accepts https", "https://github.com/anchore/grype.git", false},
+		{"accepts git protocol", "git://example.com/repo.git", false},
+		{"rejects file scheme", "file:///etc", true},
+		{"rejects ssh scheme", "ssh://git@github.com/anchore/grype.git", true},
+		{"rejects scp-like ssh", "git@github.com:anchore/grype.git", true},
+		{"rejects plain http", "http://github.com/anchore/grype.git", true},
+		{"rejects missing host", "https:///repo.git", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRepoURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRepoURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCloneRepoShallow verifies that a remote repository can be scanned at a
+// tag, a branch, or its default branch without actions/checkout.
+//
+// This test covers cloneRepoShallow and listRemoteTags in git.go, used by
+// handleRemoteRepoScan for the repo-url input. A local repository stands in
+// for the remote because the URL validation is tested separately.
+//
+// It clones the tag v1.0.0 and the default branch and checks the README
+// content of each, expects an error for an unknown ref, and asserts that the
+// remote tag listing selects v1.10.0 as the latest release.
+func TestCloneRepoShallow(t *testing.T) {
+	repoDir := setupTestRepoWithTags(t)
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"clones tag", "v1.0.0", "stable", false},
+		{"clones default branch when ref is empty", "", "higher minor", false},
+		{"fails for unknown ref", "v9.9.9", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := cloneRepoShallow(repoDir, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cloneRepoShallow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer cleanupWorktree(dir)
+
+			content, err := os.ReadFile(filepath.Join(dir, "README.md"))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("README.md = %q, want %q", content, tt.want)
+			}
+		})
+	}
+
+	tags, err := listRemoteTags(repoDir)
+	if err != nil {
+		t.Fatalf("listRemoteTags() error = %v", err)
+	}
+	latest, err := selectLatestReleaseTag(tags)
+	if err != nil || latest != "v1.10.0" {
+		t.Errorf("latest remote release = %q (err %v), want v1.10.0", latest, err)
+	}
+}
+
+// TestHandleRemoteRepoScanRejectsUnsafeURL verifies that unsafe repo-url
+// values are refused before any network or filesystem access.
+//
+// This test covers handleRemoteRepoScan in git.go, reached from
+// determineScanTarget when repo-url is set.
+//
+// It asserts that a file:// URL returns a scheme error and no temp directory.
+func TestHandleRemoteRepoScanRejectsUnsafeURL(t *testing.T) {
+	target, tempDir, err := handleRemoteRepoScan("file:///etc", "head")
+	if err == nil || !strings.Contains(err.Error(), "scheme") {
+		t.Errorf("handleRemoteRepoScan() error = %v, want scheme error", err)
+	}
+	if target != "" || tempDir != "" {
+		t.Errorf("handleRemoteRepoScan() = (%q, %q), want empty results", target, tempDir)
+	}
+}
@@ -0,0 +1,190 @@
+// Package main provides posting of scan summaries to a generic GraphQL endpoint.
+// Teams with GraphQL-based dashboards supply a mutation template; the action
+// substitutes the summary fields and POSTs the resulting query.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphqlPlaceholder matches template placeholders such as {{critical}}.
+var graphqlPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z]+)\s*\}\}`)
+
+// GraphQLClient posts mutations to a GraphQL endpoint.
+type GraphQLClient struct {
+	Token      string       // Optional bearer token sent in the Authorization header
+	HTTPClient *http.Client // HTTP client (injectable for testing)
+	URL        string       // GraphQL endpoint URL
+}
+
+// NewGraphQLClient creates a GraphQLClient for endpointURL with sensible defaults.
+// token may be empty for endpoints that do not require authentication.
+func NewGraphQLClient(endpointURL, token string) *GraphQLClient {
+	return &GraphQLClient{
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		URL:        endpointURL,
+	}
+}
+
+// graphqlRequest is the standard GraphQL-over-HTTP request body.
+type graphqlRequest struct {
+	Query string `json:"query"`
+}
+
+// graphqlResponse captures the error list of a GraphQL response; data is ignored.
+type graphqlResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Post sends query to the endpoint and reports transport, HTTP, and GraphQL errors.
+//
+// query is the fully substituted mutation (see renderGraphQLTemplate). A
+// response is considered successful when the status is 2xx and the body does
+// not carry a non-empty "errors" array.
+//
+// Returns nil on success. Callers treat errors as warnings so a dashboard
+// outage never fails the scan.
+func (c *GraphQLClient) Post(query string) error {
+	body, err := json.Marshal(graphqlRequest{Query: query})
+	if err != nil {
+		return fmt.Errorf("failed to marshal GraphQL request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GraphQL request failed: %w", withoutURL(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read GraphQL response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GraphQL endpoint returned %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+
+	var gqlResp graphqlResponse
+	if err := json.Unmarshal(respBody, &gqlResp); err == nil && len(gqlResp.Errors) > 0 {
+		return fmt.Errorf("GraphQL endpoint reported error: %s", gqlResp.Errors[0].Message)
+	}
+
+	return nil
+}
+
+// graphqlValues returns the placeholder values available to mutation templates.
+// Counts are rendered as bare integers; strings are escaped for use inside a
+// quoted GraphQL string literal (e.g., version: "{{grypeVersion}}").
+func graphqlValues(output *GrypeOutput, stats VulnerabilityStats, scanMode string) map[string]string {
+	return map[string]string{
+		"total":        strconv.Itoa(stats.Total),
+		"critical":     strconv.Itoa(stats.Critical),
+		"high":         strconv.Itoa(stats.High),
+		"medium":       strconv.Itoa(stats.Medium),
+		"low":          strconv.Itoa(stats.Low),
+		"negligible":   strconv.Itoa(stats.Negligible),
+		"other":        strconv.Itoa(stats.Other),
+		"grypeVersion": escapeJSON(output.Descriptor.Version),
+		"dbDate":       escapeJSON(extractDBDate(output.DBBuilt())),
+		"scanMode":     escapeJSON(scanMode),
+	}
+}
+
+// validateGraphQLTemplate checks that a mutation template references at least
+// one known placeholder and no unknown ones, so typos surface as a clear error
+// instead of a literal "{{critcal}}" reaching the endpoint.
+func validateGraphQLTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("graphql-mutation is required when graphql-url is set")
+	}
+
+	known := graphqlValues(&GrypeOutput{}, VulnerabilityStats{}, "")
+	found := graphqlPlaceholder.FindAllStringSubmatch(template, -1)
+	if len(found) == 0 {
+		return fmt.Errorf("graphql-mutation contains no placeholders (available: %s)", strings.Join(sortedKeys(known), ", "))
+	}
+
+	for _, match := range found {
+		if _, ok := known[match[1]]; !ok {
+			return fmt.Errorf("graphql-mutation uses unknown placeholder %q (available: %s)", match[0], strings.Join(sortedKeys(known), ", "))
+		}
+	}
+
+	return nil
+}
+
+// renderGraphQLTemplate substitutes every {{name}} placeholder with its value.
+// The template must already have passed validateGraphQLTemplate.
+func renderGraphQLTemplate(template string, values map[string]string) string {
+	return graphqlPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := graphqlPlaceholder.FindStringSubmatch(placeholder)[1]
+		return values[name]
+	})
+}
+
+// validateGraphQLURL ensures the endpoint is an absolute http(s) URL. Like
+// validateWebhookURL, it never repeats the URL, which may carry credentials.
+func validateGraphQLURL(endpointURL string) error {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil {
+		return fmt.Errorf("invalid graphql-url: %w", withoutURL(err))
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("invalid graphql-url: must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// postGraphQLSummary renders the configured mutation for this scan and posts it.
+//
+// config provides GraphQLURL, GraphQLMutation, and the optional GraphQLToken;
+// output, stats, and scanMode supply the placeholder values (see graphqlValues).
+//
+// Returns an error for an invalid URL or template, or when the endpoint
+// rejects the request. Called from processResults when graphql-url is set;
+// the caller only warns on error so the scan result is never lost.
+func postGraphQLSummary(config Config, output *GrypeOutput, stats VulnerabilityStats, scanMode string) error {
+	if err := validateGraphQLURL(config.GraphQLURL); err != nil {
+		return err
+	}
+	if err := validateGraphQLTemplate(config.GraphQLMutation); err != nil {
+		return err
+	}
+
+	query := renderGraphQLTemplate(config.GraphQLMutation, graphqlValues(output, stats, scanMode))
+	return NewGraphQLClient(config.GraphQLURL, config.GraphQLToken).Post(query)
+}
+
+// sortedKeys returns the keys of m in ascending order for stable messages.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+// Package main provides a self-contained HTML report of scan results for
+// static dashboards such as GitHub Pages. The page mirrors the Markdown
+// report's summary and vulnerability tables and needs no external assets.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// htmlReportTemplate renders the report page. All styling is inline so the
+// file can be published as is; rows are color-coded by their severity class.
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>grype_me — Vulnerability Scan Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+td.num { text-align: right; }
+tr.critical { background: #ffd7d5; }
+tr.high { background: #ffe2cc; }
+tr.medium { background: #fff5b1; }
+tr.low { background: #e6f4d7; }
+tr.negligible, tr.other { background: #f6f8fa; }
+</style>
+</head>
+<body>
+<h1>✊ grype_me — Vulnerability Scan Report</h1>
+<p>
+<strong>Scan mode:</strong> {{.ScanMode}}<br>
+<strong>grype version:</strong> {{.GrypeVersion}}<br>
+<strong>DB version:</strong> {{.DBDate}}<br>
+<strong>Scanned:</strong> {{.Scanned}}<br>
+<strong>Total CVEs:</strong> {{.Stats.Total}}
+</p>
+<h2>Summary</h2>
+<table>
+<tr><th>Severity</th><th>Count</th></tr>
+<tr class="critical"><td>Critical</td><td class="num">{{.Stats.Critical}}</td></tr>
+<tr class="high"><td>High</td><td class="num">{{.Stats.High}}</td></tr>
+<tr class="medium"><td>Medium</td><td class="num">{{.Stats.Medium}}</td></tr>
+<tr class="low"><td>Low</td><td class="num">{{.Stats.Low}}</td></tr>
+{{- if .Stats.Negligible}}
+<tr class="negligible"><td>Negligible</td><td class="num">{{.Stats.Negligible}}</td></tr>
+{{- end}}
+{{- if .Stats.Other}}
+<tr class="other"><td>Other</td><td class="num">{{.Stats.Other}}</td></tr>
+{{- end}}
+<tr><th>Total</th><th class="num">{{.Stats.Total}}</th></tr>
+{{- if .Stats.Total}}
+<tr><td>Fixable (fix available, within total)</td><td class="num">{{.Stats.Fixable}}</td></tr>
+<tr><td>Without fix (not-fixed/wont-fix, within total)</td><td class="num">{{.Stats.NotFixed}}</td></tr>
+{{- end}}
+</table>
+{{- if .Rows}}
+<h2>Vulnerabilities</h2>
+<table>
+<tr><th>CVE</th><th>Severity</th><th>EPSS</th><th>Package</th><th>Installed</th><th>Fixed</th><th>Description</th><th>Source</th></tr>
+{{- range .Rows}}
+<tr class="{{.Class}}"><td>{{.ID}}</td><td>{{.Severity}}</td><td class="num">{{.EPSS}}</td><td>{{.Package}}</td><td>{{.Installed}}</td><td>{{.Fixed}}</td><td>{{.Description}}</td><td>{{if .Source}}<a href="{{.Source}}">link</a>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>✅ No vulnerabilities found.</p>
+{{- end}}
+<p><em>Generated by <a href="https://github.com/TomTonic/grype_me">grype_me</a></em></p>
+</body>
+</html>
+`))
+
+// htmlReportRow is one row of the vulnerability table.
+type htmlReportRow struct {
+	Class       string // severity CSS class, e.g. "critical"
+	ID          string
+	Severity    string
+	EPSS        string
+	Package     string
+	Installed   string
+	Fixed       string
+	Description string
+	Source      string
+}
+
+// htmlReportData is the data rendered by htmlReportTemplate.
+type htmlReportData struct {
+	ScanMode     string
+	GrypeVersion string
+	DBDate       string
+	Scanned      string
+	Stats        VulnerabilityStats
+	Rows         []htmlReportRow
+}
+
+// severityClass maps a Grype severity to the row class of the HTML report;
+// unrecognized severities share the "other" class.
+func severityClass(severity string) string {
+	switch s := strings.ToLower(severity); s {
+	case "critical", "high", "medium", "low", "negligible":
+		return s
+	default:
+		return "other"
+	}
+}
+
+// generateHTMLAt renders the HTML report with a specific fallback scan time
+// (for testability), preferring grype's descriptor timestamp like
+// generateReportAt. Rows are ordered with sortMatches.
+func generateHTMLAt(output *GrypeOutput, stats VulnerabilityStats, scanMode string, now time.Time) ([]byte, error) {
+	data := htmlReportData{
+		ScanMode:     scanMode,
+		GrypeVersion: output.Descriptor.Version,
+		DBDate:       extractDBDate(output.DBBuilt()),
+		Scanned:      scanTime(output, now).Format("2006-01-02 15:04 UTC"),
+		Stats:        stats,
+	}
+	for _, m := range sortMatches(output.Matches) {
+		fixed := strings.Join(m.Vulnerability.Fix.Versions, ", ")
+		if fixed == "" {
+			fixed = "—"
+		}
+		data.Rows = append(data.Rows, htmlReportRow{
+			Class:       severityClass(m.Vulnerability.Severity),
+			ID:          m.Vulnerability.ID,
+			Severity:    m.Vulnerability.Severity,
+			EPSS:        formatEPSS(m),
+			Package:     m.Artifact.Name,
+			Installed:   m.Artifact.Version,
+			Fixed:       fixed,
+			Description: truncate(m.Vulnerability.Description, 200),
+			Source:      m.Vulnerability.DataSource,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeHTML writes the scan as a self-contained HTML report.
+//
+// output is the parsed scan, stats its aggregate counts, and scanMode the
+// mode shown in the header. path is resolved and validated like every other
+// file output.
+//
+// Returns an error if the page cannot be rendered or written. Called from
+// writeExportFiles when the html-file input is set.
+func writeHTML(output *GrypeOutput, stats VulnerabilityStats, scanMode, path string) error {
+	data, err := generateHTMLAt(output, stats, scanMode, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if _, err := writeWorkspaceFile(path, data); err != nil {
+		return err
+	}
+	return nil
+}
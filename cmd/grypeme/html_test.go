@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteHTML verifies that teams can publish the scan as a static page,
+// e.g. on GitHub Pages, without any external assets.
+//
+// This test covers writeHTML, generateHTMLAt, and severityClass in html.go,
+// called from writeExportFiles when html-file is set.
+//
+// It writes a scan with critical and low matches and asserts the header
+// metadata, the summary counts, severity-ordered and color-coded rows,
+// escaped descriptions, inline CSS without external references, and the
+// "no vulnerabilities" note for a clean scan.
+func TestWriteHTML(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "minor issue", ""),
+		makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", []string{"3.0.1"}, "remote code execution <script>", "https://nvd.example/CVE-CRIT"),
+	}}
+	output.Descriptor.Version = "0.87.0"
+	output.Descriptor.DB.Status.Built = "2026-02-15T08:00:00Z"
+	stats := calculateStats(output, "", false)
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := writeHTML(output, stats, "image", path); err != nil {
+		t.Fatalf("writeHTML() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"<strong>Scan mode:</strong> image",
+		"<strong>grype version:</strong> 0.87.0",
+		"<strong>DB version:</strong> 2026-02-15",
+		`<tr class="critical"><td>Critical</td><td class="num">1</td></tr>`,
+		`<tr><th>Total</th><th class="num">2</th></tr>`,
+		`<tr class="critical"><td>CVE-CRIT</td><td>Critical</td>`,
+		`<tr class="low"><td>CVE-LOW</td>`,
+		"remote code execution &lt;script&gt;",
+		`<a href="https://nvd.example/CVE-CRIT">link</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML report missing %q", want)
+		}
+	}
+	if strings.Index(page, "CVE-CRIT</td>") > strings.Index(page, "CVE-LOW</td>") {
+		t.Error("rows should be sorted by severity (critical first)")
+	}
+	if strings.Contains(page, "<script>") || strings.Contains(page, "<link") {
+		t.Error("HTML report must not contain scripts or external stylesheets")
+	}
+
+	clean, err := generateHTMLAt(&GrypeOutput{}, VulnerabilityStats{}, "head", time.Now())
+	if err != nil {
+		t.Fatalf("generateHTMLAt() error = %v", err)
+	}
+	if !strings.Contains(string(clean), "No vulnerabilities found") || strings.Contains(string(clean), "<h2>Vulnerabilities</h2>") {
+		t.Errorf("clean report should only show the no-vulnerabilities note:\n%s", clean)
+	}
+}
@@ -0,0 +1,142 @@
+// Package main provides ignore rules for suppressing accepted findings before
+// they are counted. Rules come from the ignore-file input and compose with the
+// ignore-packages list; both are applied to the scan and to the baseline.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ignoreFixStates lists the fix states an ignore rule can match, as reported
+// by grype in Vulnerability.Fix.State.
+var ignoreFixStates = []string{"fixed", "not-fixed", "wont-fix", "unknown"}
+
+// ignoreRule is one line of the ignore-file. A match is ignored when every
+// field that is set matches; empty fields match anything.
+type ignoreRule struct {
+	Vulnerability string   // Vulnerability ID, compared case-insensitively (e.g. "CVE-2024-1234")
+	Package       string   // Package name glob (path.Match syntax, e.g. "golang.org/x/*")
+	FixStates     []string // Fix states, any of which matches (e.g. "not-fixed", "wont-fix")
+}
+
+// matches reports whether m is suppressed by the rule.
+func (r ignoreRule) matches(m GrypeMatch) bool {
+	if r.Vulnerability != "" && !strings.EqualFold(r.Vulnerability, m.Vulnerability.ID) {
+		return false
+	}
+	if r.Package != "" {
+		if ok, _ := path.Match(r.Package, m.Artifact.Name); !ok {
+			return false
+		}
+	}
+	if len(r.FixStates) > 0 {
+		state := strings.ToLower(m.Vulnerability.Fix.State)
+		if state == "" {
+			state = "unknown"
+		}
+		if !containsString(r.FixStates, state) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIgnoreRules parses ignore-file content: one rule per line made of
+// whitespace-separated key=value fields, e.g.
+//
+//	vulnerability=CVE-2024-1234
+//	package=golang.org/x/* fix-state=not-fixed,wont-fix
+//
+// Keys are vulnerability (alias cve), package, and fix-state (a comma-separated
+// list of ignoreFixStates). Blank lines and lines starting with "#" are
+// skipped. Returns an error naming the line for unknown keys, malformed
+// fields, and invalid globs or fix states.
+func parseIgnoreRules(content string) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		for _, field := range strings.Fields(line) {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("invalid ignore-file line %d: %q is not key=value", i+1, field)
+			}
+			switch strings.ToLower(key) {
+			case "vulnerability", "cve":
+				rule.Vulnerability = value
+			case "package":
+				if _, err := path.Match(value, ""); err != nil {
+					return nil, fmt.Errorf("invalid ignore-file line %d: package glob %q: %w", i+1, value, err)
+				}
+				rule.Package = value
+			case "fix-state":
+				for _, state := range strings.Split(strings.ToLower(value), ",") {
+					if !containsString(ignoreFixStates, state) {
+						return nil, fmt.Errorf("invalid ignore-file line %d: fix-state %q (allowed: %s)", i+1, state, strings.Join(ignoreFixStates, ", "))
+					}
+					rule.FixStates = append(rule.FixStates, state)
+				}
+			default:
+				return nil, fmt.Errorf("invalid ignore-file line %d: unknown key %q (allowed: vulnerability, package, fix-state)", i+1, key)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// loadIgnoreRules reads and parses the ignore-file at path; an empty path
+// yields no rules.
+func loadIgnoreRules(path string) ([]ignoreRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore-file: %w", err)
+	}
+	return parseIgnoreRules(string(data))
+}
+
+// filterIgnoreRules drops the matches suppressed by any of rules. Returns the
+// remaining matches and how many were suppressed; the input slice is not
+// modified.
+func filterIgnoreRules(matches []GrypeMatch, rules []ignoreRule) ([]GrypeMatch, int) {
+	filtered := make([]GrypeMatch, 0, len(matches))
+	for _, m := range matches {
+		ignored := false
+		for _, rule := range rules {
+			if rule.matches(m) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, len(matches) - len(filtered)
+}
+
+// applyIgnores removes the matches suppressed by config.IgnorePackages (see
+// filterIgnoredPackages) and by the rules of config.IgnoreFile. Returns the
+// remaining matches and the number suppressed, or an error if the
+// ignore-file cannot be loaded. Called from processResults and loadBaseline,
+// so ignored findings are never reported as new or fixed.
+func applyIgnores(config Config, matches []GrypeMatch) ([]GrypeMatch, int, error) {
+	filtered, suppressed := filterIgnoredPackages(matches, config.IgnorePackages)
+
+	rules, err := loadIgnoreRules(config.IgnoreFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	filtered, byRules := filterIgnoreRules(filtered, rules)
+	return filtered, suppressed + byRules, nil
+}
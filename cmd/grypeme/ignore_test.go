@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestIgnoreRules verifies that teams can maintain accepted findings as rules
+// by vulnerability, package glob, and fix state instead of a flat list.
+//
+// This test covers parseIgnoreRules, ignoreRule.matches, and
+// filterIgnoreRules in ignore.go.
+//
+// It asserts that a package glob rule drops every package under its prefix,
+// that a fix-state rule only drops findings in the listed states (an empty
+// state counting as unknown), that all fields of a rule must match, and that
+// malformed lines are rejected with their line number.
+func TestIgnoreRules(t *testing.T) {
+	rules, err := parseIgnoreRules(`# accepted risks
+
+package=golang.org/x/*
+fix-state=wont-fix,unknown
+cve=CVE-OPENSSL package=openssl fix-state=not-fixed
+`)
+	if err != nil {
+		t.Fatalf("parseIgnoreRules() error = %v", err)
+	}
+
+	matches := []GrypeMatch{
+		makeMatch("CVE-NET", "High", "golang.org/x/net", "0.1.0", []string{"0.2.0"}, "", ""),
+		makeMatch("CVE-NESTED", "High", "golang.org/x/crypto/ssh", "0.1.0", []string{"0.2.0"}, "", ""),
+		withFixState(makeMatch("CVE-WONT", "Medium", "zlib", "1.2.11", nil, "", ""), "wont-fix"),
+		makeMatch("CVE-NOSTATE", "Low", "curl", "8.0.0", nil, "", ""),
+		withFixState(makeMatch("CVE-OPENSSL", "Critical", "openssl", "3.0.0", nil, "", ""), "not-fixed"),
+		withFixState(makeMatch("CVE-OPENSSL", "Critical", "libssl", "3.0.0", nil, "", ""), "not-fixed"),
+		withFixState(makeMatch("CVE-OTHER", "High", "bash", "5.0", nil, "", ""), "not-fixed"),
+	}
+
+	got, suppressed := filterIgnoreRules(matches, rules)
+	var kept []string
+	for _, m := range got {
+		kept = append(kept, m.Vulnerability.ID+"/"+m.Artifact.Name)
+	}
+	// path.Match's "*" does not cross "/", so the nested package stays
+	if want := "CVE-NESTED/golang.org/x/crypto/ssh,CVE-OPENSSL/libssl,CVE-OTHER/bash"; strings.Join(kept, ",") != want {
+		t.Errorf("filterIgnoreRules() kept %v, want %s", kept, want)
+	}
+	if suppressed != 4 {
+		t.Errorf("filterIgnoreRules() suppressed %d, want 4", suppressed)
+	}
+
+	for name, content := range map[string]string{
+		"line 2: unknown key":        "package=zlib\nseverity=high\n",
+		"is not key=value":           "CVE-2024-1\n",
+		"line 1: fix-state \"none\"": "fix-state=none\n",
+		"line 1: package glob":       "package=[x\n",
+	} {
+		if _, err := parseIgnoreRules(content); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("parseIgnoreRules(%q) error = %v, want %q", content, err, name)
+		}
+	}
+}
+
+// TestApplyIgnores verifies that ignore-file rules and the ignore-packages
+// list combine, so both can be used at once.
+//
+// This test covers applyIgnores and loadIgnoreRules in ignore.go, called from
+// processResults and loadBaseline.
+//
+// It asserts that findings dropped by either input are removed and counted
+// once, and that a missing ignore-file fails with an error naming the input.
+func TestApplyIgnores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.txt")
+	if err := os.WriteFile(path, []byte("vulnerability=CVE-2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	matches := []GrypeMatch{
+		makeMatch("CVE-1", "High", "sandboxed", "1.0", nil, "", ""),
+		makeMatch("CVE-2", "High", "sandboxed", "1.0", nil, "", ""),
+		makeMatch("CVE-2", "High", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-3", "Low", "zlib", "1.2.11", nil, "", ""),
+	}
+
+	got, suppressed, err := applyIgnores(Config{IgnorePackages: []string{"sandboxed"}, IgnoreFile: path}, matches)
+	if err != nil {
+		t.Fatalf("applyIgnores() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Vulnerability.ID != "CVE-3" || suppressed != 3 {
+		t.Errorf("applyIgnores() = %d matches, %d suppressed, want only CVE-3 and 3 suppressed", len(got), suppressed)
+	}
+
+	if _, _, err := applyIgnores(Config{IgnoreFile: filepath.Join(t.TempDir(), "missing.txt")}, matches); err == nil || !strings.Contains(err.Error(), "ignore-file") {
+		t.Errorf("applyIgnores() error = %v, want ignore-file error", err)
+	}
+}
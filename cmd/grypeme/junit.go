@@ -0,0 +1,97 @@
+// Package main provides JUnit XML export of scan results for CI dashboards.
+// Each vulnerability becomes a testcase that fails when its severity is at or
+// above the configured severity-cutoff.
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// junitTestSuites is the root element understood by common JUnit consumers.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups all vulnerabilities of one scan.
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is one vulnerability match; Failure is nil for passing cases.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure carries the vulnerability description of a failing case.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// isAtOrAboveCutoff reports whether severity gates the build at cutoff, using
+// the same rules as shouldFail (negligible, all, and any count every finding;
+// none counts none).
+func isAtOrAboveCutoff(severity, cutoff string) bool {
+	switch strings.ToLower(cutoff) {
+	case "negligible", "all", "any":
+		return true
+	case "none":
+		return false
+	}
+	return severityOrder(severity) <= severityOrder(cutoff)
+}
+
+// buildJUnit converts matches into a JUnit test suite. Cases are ordered with
+// sortMatches; each is named after the vulnerability ID with the package and
+// installed version as classname.
+func buildJUnit(matches []GrypeMatch, total int, cutoff string) junitTestSuites {
+	suite := junitTestSuite{Name: "grype", Tests: total, Cases: []junitTestCase{}}
+	for _, m := range sortMatches(matches) {
+		tc := junitTestCase{
+			Name:      m.Vulnerability.ID,
+			ClassName: fmt.Sprintf("%s@%s", m.Artifact.Name, m.Artifact.Version),
+		}
+		if isAtOrAboveCutoff(m.Vulnerability.Severity, cutoff) {
+			tc.Failure = &junitFailure{
+				Message: m.Vulnerability.Description,
+				Type:    m.Vulnerability.Severity,
+				Text:    fmt.Sprintf("%s severity vulnerability in %s %s (%s)", m.Vulnerability.Severity, m.Artifact.Name, m.Artifact.Version, m.Vulnerability.DataSource),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return junitTestSuites{Suites: []junitTestSuite{suite}}
+}
+
+// writeJUnit writes the scan's matches as a JUnit XML test report.
+//
+// output is the parsed scan and stats its aggregate counts (stats.Total
+// becomes the tests attribute). path is resolved and validated like every
+// other file output. cutoff is the severity-cutoff input: matches at or above
+// it are failing testcases with the description as failure message, all
+// others pass.
+//
+// Returns an error if the report cannot be encoded or written. Called from
+// processResults when the junit-file input is set.
+func writeJUnit(output *GrypeOutput, stats VulnerabilityStats, path, cutoff string) error {
+	data, err := xml.MarshalIndent(buildJUnit(output.Matches, stats.Total, cutoff), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if _, err := writeWorkspaceFile(path, data); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWriteJUnit verifies that CI dashboards ingesting JUnit XML show one
+// test per vulnerability, failing exactly for the findings that gate builds.
+//
+// This test covers writeJUnit, buildJUnit, and isAtOrAboveCutoff in junit.go,
+// called from processResults when junit-file is set.
+//
+// It writes a scan with critical, medium, and low matches at cutoff "medium"
+// and asserts a well-formed document with tests=3 and failures=2, CVE IDs as
+// test names, package@version as classname, the description as failure
+// message, and no failure element on the low finding.
+func TestWriteJUnit(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "minor issue", ""),
+		makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", []string{"3.0.1"}, "remote code execution <RCE>", "https://nvd.example/CVE-CRIT"),
+		makeMatch("CVE-MED", "Medium", "curl", "8.0.0", nil, "redirect issue", ""),
+	}}
+	stats := calculateStats(output, "", false)
+
+	path := filepath.Join(t.TempDir(), "grype.junit.xml")
+	if err := writeJUnit(output, stats, path, "medium"); err != nil {
+		t.Fatalf("writeJUnit() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") {
+		t.Errorf("report does not start with an XML header:\n%s", data)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("report is not valid XML: %v", err)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("got %d testsuites, want 1", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 2 || len(suite.Cases) != 3 {
+		t.Fatalf("suite = tests %d, failures %d, cases %d; want 3, 2, 3", suite.Tests, suite.Failures, len(suite.Cases))
+	}
+
+	crit := suite.Cases[0]
+	if crit.Name != "CVE-CRIT" || crit.ClassName != "openssl@3.0.0" {
+		t.Errorf("first case = %s (%s), want CVE-CRIT (openssl@3.0.0)", crit.Name, crit.ClassName)
+	}
+	if crit.Failure == nil || crit.Failure.Message != "remote code execution <RCE>" {
+		t.Errorf("critical case failure = %+v, want description as message", crit.Failure)
+	}
+	if low := suite.Cases[2]; low.Name != "CVE-LOW" || low.Failure != nil {
+		t.Errorf("low case = %+v, want passing CVE-LOW", low)
+	}
+}
+
+// TestIsAtOrAboveCutoff verifies that JUnit failures agree with fail-build,
+// so the dashboard never disagrees with the job status.
+//
+// This test covers isAtOrAboveCutoff in junit.go against shouldFail in
+// scanner.go.
+//
+// It asserts for each severity and cutoff that a single finding fails the
+// testcase exactly when shouldFail would fail the build on it.
+func TestIsAtOrAboveCutoff(t *testing.T) {
+	for _, severity := range severityLadder {
+		for _, cutoff := range severityCutoffs {
+			stats := calculateStats(&GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-1", severity, "pkg", "1", nil, "", "")}}, "", false)
+			if got, want := isAtOrAboveCutoff(severity, cutoff), shouldFail(stats, cutoff, 0); got != want {
+				t.Errorf("isAtOrAboveCutoff(%q, %q) = %v, shouldFail = %v", severity, cutoff, got, want)
+			}
+		}
+	}
+}
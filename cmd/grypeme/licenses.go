@@ -0,0 +1,105 @@
+// Package main provides the license inventory of scanned packages. grype
+// reports the licenses syft found for each matched package; they are listed
+// per package in the license-report Markdown file and summarized in the
+// unique-licenses output.
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// unknownLicense stands in for packages grype reported without a license.
+const unknownLicense = "unknown"
+
+// packageLicense is one row of the license inventory.
+type packageLicense struct {
+	Name     string
+	Version  string
+	Type     string
+	Licenses []string // Sorted and deduplicated; [unknownLicense] when none were reported
+}
+
+// packageLicenses returns one entry per distinct package (name, version,
+// type) of matches, sorted by name and version, with its licenses.
+func packageLicenses(matches []GrypeMatch) []packageLicense {
+	byKey := make(map[string]*packageLicense)
+	var keys []string
+	for _, m := range matches {
+		key := m.Artifact.Name + "@" + m.Artifact.Version + "@" + m.Artifact.Type
+		entry, ok := byKey[key]
+		if !ok {
+			entry = &packageLicense{Name: m.Artifact.Name, Version: m.Artifact.Version, Type: m.Artifact.Type}
+			byKey[key] = entry
+			keys = append(keys, key)
+		}
+		for _, license := range m.Artifact.Licenses {
+			if license = strings.TrimSpace(license); license != "" && !containsString(entry.Licenses, license) {
+				entry.Licenses = append(entry.Licenses, license)
+			}
+		}
+	}
+
+	inventory := make([]packageLicense, 0, len(keys))
+	for _, key := range keys {
+		entry := *byKey[key]
+		if len(entry.Licenses) == 0 {
+			entry.Licenses = []string{unknownLicense}
+		}
+		sort.Strings(entry.Licenses)
+		inventory = append(inventory, entry)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].Name != inventory[j].Name {
+			return inventory[i].Name < inventory[j].Name
+		}
+		return inventory[i].Version < inventory[j].Version
+	})
+	return inventory
+}
+
+// uniqueLicenses returns the sorted, deduplicated licenses of matches for the
+// unique-licenses output, including unknownLicense when a package has none.
+func uniqueLicenses(matches []GrypeMatch) []string {
+	var licenses []string
+	for _, entry := range packageLicenses(matches) {
+		for _, license := range entry.Licenses {
+			if !containsString(licenses, license) {
+				licenses = append(licenses, license)
+			}
+		}
+	}
+	sort.Strings(licenses)
+	return licenses
+}
+
+// generateLicenseReport renders the license inventory of matches as a
+// Markdown table (package, version, type, licenses). Only packages grype
+// reported a vulnerability for are listed, since the grype JSON carries no
+// others.
+func generateLicenseReport(matches []GrypeMatch) string {
+	var b strings.Builder
+	b.WriteString("## License Inventory\n\n")
+
+	inventory := packageLicenses(matches)
+	if len(inventory) == 0 {
+		b.WriteString("No vulnerable packages found.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%d packages, licenses: %s\n\n", len(inventory), strings.Join(uniqueLicenses(matches), ", "))
+	b.WriteString("| Package | Version | Type | Licenses |\n")
+	b.WriteString("|---------|---------|------|----------|\n")
+	for _, entry := range inventory {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", entry.Name, entry.Version, entry.Type, strings.Join(entry.Licenses, ", "))
+	}
+	return b.String()
+}
+
+// writeLicenseReport writes the license inventory of matches (see
+// generateLicenseReport) to path via writeWorkspaceFile.
+func writeLicenseReport(matches []GrypeMatch, path string) error {
+	_, err := writeWorkspaceFile(path, []byte(generateLicenseReport(matches)))
+	return err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLicenseInventory verifies that teams get a license inventory of the
+// scanned packages alongside the vulnerabilities.
+//
+// This test covers the licenses field of GrypeMatch.Artifact (types.go)
+// parsed by parseGrypeOutput, and packageLicenses, uniqueLicenses, and
+// writeLicenseReport in licenses.go.
+//
+// It parses grype JSON where packages carry one, two, or no licenses and
+// asserts the parsed licenses, one inventory row per package with "unknown"
+// for the unlicensed one, the sorted unique-licenses list, and the Markdown
+// rows of the written report.
+func TestLicenseInventory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "grype.json")
+	doc := `{"matches":[
+		{"vulnerability":{"id":"CVE-1","severity":"High"},"artifact":{"name":"openssl","version":"3.0.0","type":"deb","licenses":["Apache-2.0"]}},
+		{"vulnerability":{"id":"CVE-2","severity":"Low"},"artifact":{"name":"openssl","version":"3.0.0","type":"deb","licenses":["Apache-2.0"]}},
+		{"vulnerability":{"id":"CVE-3","severity":"Medium"},"artifact":{"name":"curl","version":"8.0.0","type":"deb","licenses":["curl","MIT"]}},
+		{"vulnerability":{"id":"CVE-4","severity":"Low"},"artifact":{"name":"zlib","version":"1.2.11","type":"deb"}}
+	]}`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := parseGrypeOutput(path, false)
+	if err != nil {
+		t.Fatalf("parseGrypeOutput() error = %v", err)
+	}
+	if got := output.Matches[2].Artifact.Licenses; strings.Join(got, ",") != "curl,MIT" {
+		t.Errorf("Artifact.Licenses = %v, want [curl MIT]", got)
+	}
+
+	inventory := packageLicenses(output.Matches)
+	var rows []string
+	for _, entry := range inventory {
+		rows = append(rows, entry.Name+"="+strings.Join(entry.Licenses, "+"))
+	}
+	if got := strings.Join(rows, ","); got != "curl=MIT+curl,openssl=Apache-2.0,zlib=unknown" {
+		t.Errorf("packageLicenses() = %s, want curl=MIT+curl,openssl=Apache-2.0,zlib=unknown", got)
+	}
+
+	if got := strings.Join(uniqueLicenses(output.Matches), ","); got != "Apache-2.0,MIT,curl,unknown" {
+		t.Errorf("uniqueLicenses() = %s, want Apache-2.0,MIT,curl,unknown", got)
+	}
+
+	reportPath := filepath.Join(dir, "licenses.md")
+	if err := writeLicenseReport(output.Matches, reportPath); err != nil {
+		t.Fatalf("writeLicenseReport() error = %v", err)
+	}
+	report, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| curl | 8.0.0 | deb | MIT, curl |", "| zlib | 1.2.11 | deb | unknown |", "3 packages"} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("license report missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package main provides leveled console logging for the Grype GitHub Action.
+// Info and Warn messages are user-facing milestones and problems; Debug
+// messages (temporary directories, git fetches) only appear with debug: true,
+// and Info messages are dropped with quiet: true. Warn messages are collected
+// and printed together at the end of the run. With log-format: json each
+// message is one JSON object per line for log aggregation.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported values of the log-format input.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// debugLogging enables Debug-level messages. It follows the debug input and
+// is set by run once the configuration is loaded.
+var debugLogging = isDebugEnabled()
+
+// jsonLogging writes messages as JSON lines. It follows the log-format input,
+// read from the environment up front so that the privilege-drop messages
+// printed before the configuration is loaded are formatted too; run sets it
+// again once the configuration is loaded.
+var jsonLogging = strings.ToLower(strings.TrimSpace(getEnv("INPUT_LOG-FORMAT", ""))) == logFormatJSON
+
+// quietLogging suppresses Info-level messages. It follows the quiet input and
+// is set by run once the configuration is loaded.
+var quietLogging bool
+
+// warnings collects the logWarnf messages of the run for
+// printWarningSummary. warningsMu guards it, since tag scans log from
+// several goroutines.
+var (
+	warningsMu sync.Mutex
+	warnings   []string
+)
+
+// logger writes all levels to stdout without timestamps; the Actions runner
+// timestamps log lines itself.
+var logger = log.New(stdoutWriter{}, "", 0)
+
+// stdoutWriter forwards to the current os.Stdout, so tests that redirect
+// stdout capture log output too.
+type stdoutWriter struct{}
+
+// Write implements io.Writer.
+func (stdoutWriter) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+// setDebugLogging enables or disables Debug-level messages.
+func setDebugLogging(enabled bool) {
+	debugLogging = enabled
+}
+
+// setJSONLogging switches between JSON lines and human-readable text.
+func setJSONLogging(enabled bool) {
+	jsonLogging = enabled
+}
+
+// validateLogFormat checks the log-format input. Empty selects text.
+func validateLogFormat(format string) error {
+	if format == "" || format == logFormatText || format == logFormatJSON {
+		return nil
+	}
+	return fmt.Errorf("invalid log-format %q (allowed: %s, %s)", format, logFormatText, logFormatJSON)
+}
+
+// logEntry is one message in JSON log format.
+type logEntry struct {
+	Timestamp string `json:"timestamp"` // RFC 3339, UTC
+	Level     string `json:"level"`     // debug, info, or warn
+	Msg       string `json:"msg"`
+}
+
+// logLine writes msg at level, as a JSON object or as text with prefix.
+func logLine(level, prefix, msg string) {
+	if !jsonLogging {
+		logger.Print(prefix + msg)
+		return
+	}
+	data, err := json.Marshal(logEntry{Timestamp: time.Now().UTC().Format(time.RFC3339), Level: level, Msg: msg})
+	if err != nil {
+		logger.Print(prefix + msg)
+		return
+	}
+	logger.Print(string(data))
+}
+
+// setQuietLogging enables or disables suppression of Info-level messages.
+func setQuietLogging(enabled bool) {
+	quietLogging = enabled
+}
+
+// logDebugf prints a diagnostic message only when debug logging is enabled.
+func logDebugf(format string, args ...any) {
+	if debugLogging {
+		logLine("debug", "Debug: ", fmt.Sprintf(format, args...))
+	}
+}
+
+// logInfof prints a user-facing progress message unless quiet logging is
+// enabled.
+func logInfof(format string, args ...any) {
+	if quietLogging {
+		return
+	}
+	logLine("info", "", fmt.Sprintf(format, args...))
+}
+
+// logResultf prints a line of a result the user asked for, such as the scan
+// summary or the environment dump of debug: true. Unlike logInfof it is not
+// suppressed by quiet logging.
+func logResultf(format string, args ...any) {
+	logLine("info", "", fmt.Sprintf(format, args...))
+}
+
+// subprocessStdout returns where grype's standard output goes: the action's
+// stdout, or stderr in JSON log format so stdout holds only JSON lines.
+func subprocessStdout() io.Writer {
+	if jsonLogging {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// logWarnf records a non-fatal problem the user should know about for the
+// warning summary. With debug logging it is also printed where it occurs.
+func logWarnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	warningsMu.Lock()
+	warnings = append(warnings, msg)
+	warningsMu.Unlock()
+	if debugLogging {
+		logLine("warn", "Warning: ", msg)
+	}
+}
+
+// takeWarnings returns the warnings collected so far and clears them.
+func takeWarnings() []string {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+	taken := warnings
+	warnings = nil
+	return taken
+}
+
+// printWarningSummary prints the collected warnings as one block, so
+// everything that degraded the run shows up in one place. In JSON log format
+// each warning is one warn-level line instead. It prints nothing when there
+// were no warnings and is not affected by quiet: true.
+func printWarningSummary() {
+	taken := takeWarnings()
+	if len(taken) == 0 {
+		return
+	}
+	if jsonLogging {
+		for _, msg := range taken {
+			logLine("warn", "", msg)
+		}
+		return
+	}
+	logger.Printf("=== Warnings (%d) ===", len(taken))
+	for _, msg := range taken {
+		logger.Printf("- %s", msg)
+	}
+}
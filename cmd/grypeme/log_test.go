@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLogLevels verifies that normal runs stay quiet while debug: true still
+// shows the diagnostic detail needed to troubleshoot a run.
+//
+// This test covers logDebugf, logInfof, logWarnf, and setDebugLogging in
+// log.go.
+//
+// It captures stdout and asserts that Info messages always print, while
+// Debug messages and inline warnings (with a "Warning: " prefix) only print
+// after debug logging is enabled.
+func TestLogLevels(t *testing.T) {
+	t.Cleanup(func() {
+		setDebugLogging(false)
+		takeWarnings()
+	})
+
+	tests := []struct {
+		name      string
+		debug     bool
+		wantDebug bool
+	}{
+		{"hides debug messages by default", false, false},
+		{"shows debug messages with debug enabled", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setDebugLogging(tt.debug)
+			out := captureStdout(t, func() {
+				logDebugf("fetching %s", "tags")
+				logInfof("Found latest release: %s", "v1.0.0")
+				logWarnf("could not fetch tags: %v", "offline")
+			})
+
+			if !strings.Contains(out, "Found latest release: v1.0.0\n") {
+				t.Errorf("output = %q, want info message", out)
+			}
+			if got := strings.Contains(out, "Warning: could not fetch tags: offline\n"); got != tt.wantDebug {
+				t.Errorf("inline warning shown = %v, want %v (output %q)", got, tt.wantDebug, out)
+			}
+			if got := strings.Contains(out, "Debug: fetching tags"); got != tt.wantDebug {
+				t.Errorf("debug message shown = %v, want %v (output %q)", got, tt.wantDebug, out)
+			}
+		})
+	}
+}
+
+// TestQuietLogging verifies that quiet: true keeps CI logs down to what
+// matters: progress chatter disappears while warnings and the summary remain.
+//
+// This test covers setQuietLogging and logInfof in log.go, through which run
+// prints its scan target banner and other progress messages.
+//
+// It captures stdout with quiet logging enabled and asserts that the "Grype
+// scan target" banner and other Info messages are omitted, while the warning
+// summary and printSummary output still appear.
+func TestQuietLogging(t *testing.T) {
+	setQuietLogging(true)
+	t.Cleanup(func() { setQuietLogging(false) })
+	takeWarnings()
+
+	out := captureStdout(t, func() {
+		logInfof("Grype scan target: %s", "dir:.")
+		logInfof("Scan results saved to: %s", "results.json")
+		logWarnf("could not fetch tags: %v", "offline")
+		printSummary(VulnerabilityStats{Total: 1, High: 1}, &GrypeOutput{}, scanRunInfo{})
+		printWarningSummary()
+	})
+
+	if strings.Contains(out, "Grype scan target") || strings.Contains(out, "saved to") {
+		t.Errorf("output = %q, want info messages suppressed", out)
+	}
+	if !strings.Contains(out, "- could not fetch tags: offline\n") {
+		t.Errorf("output = %q, want warning summary", out)
+	}
+	if !strings.Contains(out, "CVEs") {
+		t.Errorf("output = %q, want summary", out)
+	}
+}
+
+// TestWarningSummary verifies that everything that degraded a run is listed
+// in one block at the end of the log instead of being scattered through it.
+//
+// This test covers logWarnf, takeWarnings, and printWarningSummary in
+// log.go; run defers printWarningSummary once the configuration is loaded.
+//
+// It records warnings, including from concurrent goroutines as tag scans do,
+// and asserts that they are not printed inline without debug logging, that
+// the summary counts and lists them in order, that the summary clears them,
+// and that nothing is printed when there were no warnings.
+func TestWarningSummary(t *testing.T) {
+	takeWarnings()
+	t.Cleanup(func() { takeWarnings() })
+
+	out := captureStdout(t, func() {
+		logWarnf("failed to update gist: %v", "401 Unauthorized")
+		logWarnf("could not fetch tags: %v", "offline")
+	})
+	if out != "" {
+		t.Errorf("inline output = %q, want none without debug logging", out)
+	}
+
+	out = captureStdout(t, printWarningSummary)
+	want := "=== Warnings (2) ===\n- failed to update gist: 401 Unauthorized\n- could not fetch tags: offline\n"
+	if out != want {
+		t.Errorf("printWarningSummary() output = %q, want %q", out, want)
+	}
+	if out := captureStdout(t, printWarningSummary); out != "" {
+		t.Errorf("second printWarningSummary() output = %q, want none", out)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logWarnf("tag scan %d failed", i)
+		}()
+	}
+	wg.Wait()
+	if got := len(takeWarnings()); got != 8 {
+		t.Errorf("takeWarnings() returned %d concurrent warnings, want 8", got)
+	}
+}
+
+// TestJSONLogging verifies that log-format: json gives log aggregators one
+// parsable object per line instead of free-form text.
+//
+// This test covers setJSONLogging, validateLogFormat, and logLine in log.go,
+// used by logDebugf, logInfof, logWarnf, and printWarningSummary.
+//
+// It logs at every level with debug logging and JSON enabled and asserts
+// that each line is a well-formed object with an RFC 3339 timestamp, the
+// level, and the unprefixed message (including quotes that need escaping),
+// and that only "text" and "json" are accepted as log formats.
+func TestJSONLogging(t *testing.T) {
+	setJSONLogging(true)
+	setDebugLogging(true)
+	t.Cleanup(func() {
+		setJSONLogging(false)
+		setDebugLogging(false)
+		takeWarnings()
+	})
+	takeWarnings()
+
+	out := captureStdout(t, func() {
+		logDebugf("fetching %s", "tags")
+		logInfof("Grype scan target: %q", "dir:.")
+		logWarnf("could not fetch tags: %v", "offline")
+		printWarningSummary()
+	})
+
+	want := []logEntry{
+		{Level: "debug", Msg: "fetching tags"},
+		{Level: "info", Msg: `Grype scan target: "dir:."`},
+		{Level: "warn", Msg: "could not fetch tags: offline"},
+		{Level: "warn", Msg: "could not fetch tags: offline"},
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out)
+	}
+	for i, line := range lines {
+		var entry logEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Errorf("line %d is not valid JSON: %q (%v)", i, line, err)
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, entry.Timestamp); err != nil {
+			t.Errorf("line %d timestamp = %q, want RFC 3339", i, entry.Timestamp)
+		}
+		if entry.Level != want[i].Level || entry.Msg != want[i].Msg {
+			t.Errorf("line %d = %s/%q, want %s/%q", i, entry.Level, entry.Msg, want[i].Level, want[i].Msg)
+		}
+	}
+
+	for format, wantErr := range map[string]bool{"": false, "text": false, "json": false, "logfmt": true} {
+		if err := validateLogFormat(format); (err != nil) != wantErr {
+			t.Errorf("validateLogFormat(%q) error = %v, want error = %v", format, err, wantErr)
+		}
+	}
+}
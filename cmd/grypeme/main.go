@@ -17,160 +17,573 @@
 //   - scanner.go: Grype scan execution and result parsing
 //   - git.go: Git operations (worktrees, tags, ref handling)
 //   - output.go: GitHub Actions outputs, file handling, badge generation
+//   - changelog.go: Release-to-release CVE changelog
+//   - tags.go: Scanning all tags matching a glob, reporting the worst
+//   - diff.go: Comparison against a baseline scan (new/fixed findings)
+//   - ignore.go: ignore-file rules and ignore-packages suppression
+//   - osv.go: OSV-format export of vulnerability matches
+//   - junit.go: JUnit XML export for CI test-result dashboards
+//   - sarif.go: SARIF export selected by a .sarif output-file
+//   - html.go: Self-contained HTML report for static dashboards
+//   - licenses.go: License inventory of the matched packages
+//   - annotations.go: GitHub workflow annotations per finding
+//   - preflight.go: Preflight mode checking grype and DB availability
+//   - gist.go: GitHub Gist API integration for badges and reports
+//   - firstseen.go: First-seen dates of CVEs kept in the gist state file
+//   - graphql.go: Posting scan summaries to a GraphQL endpoint
+//   - webhook.go: Posting scan results as JSON to a webhook URL
+//   - prcomment.go: Auto-updating pull request comment with the scan report
+//   - log.go: Leveled console logging (Debug gated by the debug input)
+//   - privilege.go: UID/GID drop handling for the scratch runtime image
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// version identifies this build of grype_me, e.g. in HTTP User-Agent headers.
+// Release builds set it with -ldflags "-X main.version=<version>".
+var version = "dev"
+
 func main() {
 	// Drop privileges early for security hardening (if running as root)
 	if err := dropPrivileges(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error dropping privileges: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitInternalError)
 	}
 
-	if err := run(); err != nil {
+	outcome, err := run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	}
+	os.Exit(exitCode(outcome, err, os.Getenv("GITHUB_ACTIONS") == "true"))
+}
+
+// Process exit codes for scripts running the binary directly. Inside GitHub
+// Actions only failures are non-zero, so a step with unfailing findings
+// still succeeds.
+const (
+	exitClean         = 0 // no vulnerabilities reported
+	exitFindings      = 1 // vulnerabilities reported, fail-build not triggered
+	exitPolicyFailure = 2 // fail-build or fail-on-stale-db triggered (see policyError)
+	exitInternalError = 3 // the action could not produce or process results
+)
+
+// runOutcome classifies a run that completed without error.
+type runOutcome int
+
+const (
+	outcomeClean    runOutcome = iota // no vulnerabilities reported
+	outcomeFindings                   // vulnerabilities reported
+)
+
+// findingsOutcome returns the outcome for a run that reported stats.
+func findingsOutcome(stats VulnerabilityStats) runOutcome {
+	if stats.Total > 0 {
+		return outcomeFindings
+	}
+	return outcomeClean
+}
+
+// policyError reports that fail-build or fail-on-stale-db rejected the scan
+// results, as opposed to an error preventing the results from being produced
+// or processed.
+type policyError struct {
+	msg string
+}
+
+// Error implements the error interface.
+func (e *policyError) Error() string {
+	return e.msg
+}
+
+// exitCode maps the result of run to the process exit code: exitPolicyFailure
+// for a policyError, exitInternalError for any other error, and
+// exitFindings or exitClean by outcome. inActions (GITHUB_ACTIONS=true) maps
+// exitFindings to exitClean, keeping the GitHub Actions contract that only a
+// failure fails the step.
+func exitCode(outcome runOutcome, err error, inActions bool) int {
+	var policyErr *policyError
+	switch {
+	case errors.As(err, &policyErr):
+		return exitPolicyFailure
+	case err != nil:
+		return exitInternalError
+	case outcome == outcomeFindings && !inActions:
+		return exitFindings
+	default:
+		return exitClean
 	}
 }
 
 // run is the main entry point that orchestrates the vulnerability scanning workflow.
 // It loads configuration, determines the scan target, executes the scan, and processes results.
-func run() error {
-	config := loadConfig()
+func run() (runOutcome, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return outcomeClean, err
+	}
+	setDebugLogging(config.Debug)
+	setQuietLogging(config.Quiet)
+	setJSONLogging(config.LogFormat == logFormatJSON)
+	defer printWarningSummary()
+	if err := validateConfig(config); err != nil {
+		return outcomeClean, err
+	}
 
 	if config.Debug {
 		printDebugEnv()
 	}
 
-	// Determine what to scan based on configuration
-	target, tempDir, err := determineScanTarget(config)
-	if err != nil {
-		return fmt.Errorf("failed to determine scan target: %w", err)
+	if config.Mode == modePreflight {
+		return outcomeClean, runPreflight(config, time.Now().UTC())
 	}
 
-	// Clean up temporary worktree if one was created (for repository scanning)
-	if tempDir != "" {
-		defer cleanupWorktree(tempDir)
-	}
+	// Determine what to scan based on configuration (dry runs with input-json
+	// neither scan nor touch the database); scan-tags-glob scans its tags later
+	target := ""
+	var info scanRunInfo
+	scanTags := config.InputJSON == "" && config.ScanTagsGlob != ""
+	if config.InputJSON == "" {
+		if !scanTags {
+			scanTarget, tempDir, err := determineScanTarget(config)
+			if err != nil {
+				return outcomeClean, fmt.Errorf("failed to determine scan target: %w", err)
+			}
+			target = scanTarget
+
+			// Clean up the temporary worktree or inline SBOM directory, if one was created
+			if tempDir != "" {
+				defer cleanupWorktree(tempDir)
+			}
 
-	fmt.Printf("Grype scan target: %s\n", target)
+			logInfof("Grype scan target: %s", target)
+		}
 
-	// Update vulnerability database if requested
-	if config.DBUpdate {
-		if err := updateGrypeDB(); err != nil {
-			return fmt.Errorf("failed to update grype database: %w", err)
+		// Update vulnerability database if requested
+		if config.DBUpdate {
+			fresh, err := isCachedDBFresh(config, time.Now().UTC())
+			if err != nil {
+				return outcomeClean, err
+			}
+			if !fresh {
+				duration, err := timed(func() error { return updateGrypeDB(config) })
+				info.DBUpdateDuration = duration
+				if err != nil {
+					return outcomeClean, fmt.Errorf("failed to update grype database: %w", err)
+				}
+			}
 		}
 	}
 
-	// Execute Grype scan and get results
-	grypeOutput, rawJSON, err := executeScan(config, target)
+	// Execute Grype scan and get results; with scan-tags-glob the worst tag's
+	// results (or all tags' with aggregate-mode sum) stand in for the single scan
+	var grypeOutput *GrypeOutput
+	var rawJSON []byte
+	if scanTags {
+		var worstTag string
+		grypeOutput, rawJSON, worstTag, err = scanMatchingTags(config)
+		if err == nil {
+			if err := tolerateOutputsError(config, writeOutputs(map[string]string{"worst-tag": worstTag})); err != nil {
+				return outcomeClean, err
+			}
+		}
+	} else {
+		var scanInfo scanRunInfo
+		grypeOutput, rawJSON, scanInfo, err = executeScan(config, target)
+		info.Target, info.Scanned, info.ExitCode, info.ScanDuration = target, scanInfo.Scanned, scanInfo.ExitCode, scanInfo.ScanDuration
+	}
 	if err != nil {
-		return err
+		var grypeErr *GrypeError
+		if errors.As(err, &grypeErr) {
+			if grypeErr.ExitCode >= 0 {
+				_ = writeOutputs(map[string]string{"grype-exit-code": strconv.Itoa(grypeErr.ExitCode)})
+			}
+			return outcomeClean, fmt.Errorf("%w\nHint: %s", err, grypeErr.Hint())
+		}
+		return outcomeClean, err
+	}
+
+	// Guard against unexpected Grype version drift
+	if err := checkGrypeVersionRange(config, grypeOutput.Descriptor.Version); err != nil {
+		return outcomeClean, err
+	}
+
+	// Optional release-to-release CVE changelog (scans additional releases)
+	if config.CVEChangelogFile != "" {
+		if err := writeCVEChangelog(config); err != nil {
+			return outcomeClean, fmt.Errorf("failed to write CVE changelog: %w", err)
+		}
+		logInfof("CVE changelog saved to: %s", config.CVEChangelogFile)
 	}
 
 	// Process and output results
-	return processResults(config, grypeOutput, rawJSON)
+	return processResults(config, grypeOutput, rawJSON, info)
 }
 
 // executeScan runs the Grype vulnerability scan and parses the output.
-// It returns the parsed output, the raw JSON bytes, and any error.
-func executeScan(config Config, target string) (*GrypeOutput, []byte, error) {
-	// Create a temporary file for Grype output
-	tmpFile, err := os.CreateTemp("", "grype-output-*.json")
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create temp file: %w", err)
-	}
-	tmpFilePath := tmpFile.Name()
+// It returns the parsed output, the raw JSON bytes, the exit code and
+// duration of the grype run (see scanRunInfo), and any error.
+// When config.InputJSON is set (dry run), grype is not executed and the given
+// file is used as the scan output instead.
+func executeScan(config Config, target string) (*GrypeOutput, []byte, scanRunInfo, error) {
+	var tmpFilePath string
+	var info scanRunInfo
+	if config.InputJSON != "" {
+		if err := validateInputJSON(config.InputJSON); err != nil {
+			return nil, nil, scanRunInfo{}, err
+		}
+		logInfof("Dry run: using existing grype output %s instead of scanning", config.InputJSON)
+		if config.SBOMOutput != "" {
+			logWarnf("sbom-output is ignored in dry runs with input-json")
+		}
+		if config.TemplateFile != "" {
+			logWarnf("template-file is ignored in dry runs with input-json")
+		}
+		tmpFilePath = config.InputJSON
+	} else {
+		// Create a temporary file for Grype output
+		tmpFile, err := os.CreateTemp("", "grype-output-*.json")
+		if err != nil {
+			return nil, nil, scanRunInfo{}, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		tmpFilePath = tmpFile.Name()
 
-	if err := tmpFile.Close(); err != nil {
-		return nil, nil, fmt.Errorf("failed to close temp file: %w", err)
-	}
-	defer func() { _ = os.Remove(tmpFilePath) }()
+		if err := tmpFile.Close(); err != nil {
+			return nil, nil, scanRunInfo{}, fmt.Errorf("failed to close temp file: %w", err)
+		}
+		defer func() { _ = os.Remove(tmpFilePath) }()
 
-	// Run the Grype scan
-	if err := runGrypeScan(config, target, tmpFilePath); err != nil {
-		return nil, nil, fmt.Errorf("grype scan failed: %w", err)
+		// Run the Grype scan
+		var exitCode int
+		duration, err := timed(func() error {
+			var scanErr error
+			exitCode, scanErr = runGrypeScan(config, target, tmpFilePath)
+			return scanErr
+		})
+		info = scanRunInfo{Scanned: true, ExitCode: exitCode, ScanDuration: duration}
+		if err != nil {
+			return nil, nil, scanRunInfo{}, fmt.Errorf("grype scan failed: %w", err)
+		}
+
+		if err := generateSBOM(config, target); err != nil {
+			return nil, nil, scanRunInfo{}, err
+		}
+		if err := generateTemplateOutput(config, target); err != nil {
+			return nil, nil, scanRunInfo{}, err
+		}
 	}
 
 	// Read the raw JSON before parsing (for gist upload)
 	rawJSON, err := os.ReadFile(tmpFilePath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read grype output: %w", err)
+		return nil, nil, scanRunInfo{}, fmt.Errorf("failed to read grype output: %w", err)
 	}
 
 	// Parse the scan output
-	output, err := parseGrypeOutput(tmpFilePath)
+	output, err := parseGrypeOutput(tmpFilePath, config.AllowPartial)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse grype output: %w", err)
+		return nil, nil, scanRunInfo{}, fmt.Errorf("failed to parse grype output: %w", err)
 	}
 
-	// Copy output file to user-specified location if requested
-	if config.OutputFile != "" {
-		jsonOutputPath, err := copyOutputFile(tmpFilePath, config.OutputFile)
+	// Save output files to user-specified locations, each in the format its
+	// extension selects, unless template-file rendered into it
+	if len(config.OutputFiles) > 0 && (config.TemplateFile == "" || config.InputJSON != "") {
+		maxSize, err := parseMaxOutputSize(config.MaxOutputSize)
+		if err != nil {
+			return nil, nil, scanRunInfo{}, err
+		}
+		savedPaths, err := saveOutputFiles(tmpFilePath, config.OutputFiles, output, config.IncludeFindingHash, maxSize)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to copy output file: %w", err)
+			return nil, nil, scanRunInfo{}, fmt.Errorf("failed to save output file: %w", err)
+		}
+		for _, savedPath := range savedPaths {
+			logInfof("Scan results saved to: %s", savedPath)
 		}
-		fmt.Printf("Scan results saved to: %s\n", jsonOutputPath)
 	}
 
-	return output, rawJSON, nil
+	return output, rawJSON, info, nil
 }
 
 // processResults calculates statistics, optionally writes to a gist, sets outputs, prints summary, and checks fail conditions.
-func processResults(config Config, output *GrypeOutput, rawJSON []byte) error {
-	stats := calculateStats(output)
+func processResults(config Config, output *GrypeOutput, rawJSON []byte, info scanRunInfo) (runOutcome, error) {
+	// A stale database fails the run before any vulnerability is evaluated,
+	// filtered, or published
+	// An invalid db-stale-age was already rejected by validateConfig
+	staleAge, _ := parseDBStaleAge(config.DBStaleAge)
+	if config.FailOnStaleDB {
+		if err := staleDBError(output.DBBuilt(), staleAge, time.Now().UTC()); err != nil {
+			return outcomeClean, err
+		}
+	}
+
+	// Restrict results to the requested severity band before aggregating; an
+	// invalid max-severity was already rejected by validateConfig
+	if config.MaxSeverity != "" {
+		output.Matches = filterMatchesByMaxSeverity(output.Matches, config.MaxSeverity)
+	}
+	if len(config.IgnorePackages) > 0 || config.IgnoreFile != "" {
+		matches, suppressed, err := applyIgnores(config, output.Matches)
+		if err != nil {
+			return outcomeClean, err
+		}
+		output.Matches = matches
+		logInfof("Suppressed %d vulnerabilities by ignore-packages and ignore-file", suppressed)
+	}
+
+	stats := calculateStats(output, config.UnknownSeverityAs, config.DedupeCVEs)
 	scanMode := determineScanMode(config)
 
+	// Optional outputs: per-package-type counts plus outputs whose input is enabled
+	extraOutputs := map[string]string{
+		"unique-licenses": strings.Join(uniqueLicenses(output.Matches), ","),
+	}
+	for pkgType, count := range countByType(output.Matches) {
+		extraOutputs[typeOutputKey(pkgType)] = fmt.Sprintf("%d", count)
+	}
+
+	// The fail-build decision may be scoped to a subset of the reported matches:
+	// in diff mode only vulnerabilities missing from the baseline count, and
+	// fail-on-types restricts it to the listed package types.
+	gateMatches := output.Matches
+	baseline, err := loadBaseline(config)
+	if err != nil {
+		return outcomeClean, err
+	}
+	if baseline != nil {
+		added, removed := diffMatches(output, baseline)
+		gateMatches = added
+		extraOutputs["new-cve-count"] = fmt.Sprintf("%d", len(added))
+		extraOutputs["fixed-cve-count"] = fmt.Sprintf("%d", len(removed))
+		logInfof("Compared to baseline: %d new, %d fixed", len(added), len(removed))
+	}
+	if len(config.FailOnTypes) > 0 {
+		gateMatches = filterMatchesByTypes(gateMatches, config.FailOnTypes)
+	}
+	gateStats := calculateStats(&GrypeOutput{Matches: gateMatches}, config.UnknownSeverityAs, config.DedupeCVEs)
+	// An invalid max-allowed was already rejected by validateConfig
+	maxAllowed, _ := parseMaxAllowed(config.MaxAllowed)
+	for key, value := range wouldFailOutputs(gateStats, maxAllowed) {
+		extraOutputs[key] = value
+	}
+
+	// Write optional export files (CSV, JSON summary, OSV)
+	if err := writeExportFiles(config, output, stats, scanMode); err != nil {
+		return outcomeClean, err
+	}
+
 	// Determine JSON output path for GitHub Actions outputs
 	jsonOutputPath := ""
-	if config.OutputFile != "" {
-		resolved, _ := resolveDestinationPath(config.OutputFile)
+	if len(config.OutputFiles) > 0 {
+		resolved, _ := resolveDestinationPath(config.OutputFiles[0])
 		jsonOutputPath = resolved
 	}
 
 	// Gist integration: write badge JSON + report + raw grype output if configured
-	var reportURL string
-	var gistBadgeURL string
-	if config.GistToken != "" && config.GistID != "" {
-		badgeJSON := generateBadgeJSON(stats, output.Descriptor.Version, output.DBBuilt(), scanMode)
-		report := generateReport(output, stats, scanMode, config.Description)
+	reportURL, gistBadgeURL := publishGist(config, output, stats, scanMode, rawJSON)
+
+	// Post results to optional integrations (GraphQL, webhook, PR comment);
+	// failures only warn unless webhook-required is set
+	if err := publishIntegrations(config, output, stats, scanMode); err != nil {
+		return outcomeClean, err
+	}
+
+	freshness, stale := dbFreshnessOutputs(output.DBBuilt(), staleAge, time.Now().UTC())
+	for key, value := range freshness {
+		extraOutputs[key] = value
+	}
+	if stale {
+		logWarnf("vulnerability database is %s days old (db-stale-age %s); results may miss recently published CVEs", freshness["db-age-days"], formatDBAge(staleAge))
+	}
 
-		badgeFile, reportFile, grypeFile := defaultGistFilenames(config.GistFilename, scanMode)
+	if config.DBAgeBadge {
+		label := buildBadgeLabel(output.Descriptor.Version)
+		extraOutputs["db-age-badge-url"] = generateDBAgeBadgeURL(badgeOptionsFromConfig(config), label, output.DBBuilt(), time.Now().UTC())
+	}
+
+	// Set GitHub Actions outputs (use gist badge URL when available); a write
+	// failure only warns unless strict-outputs is set
+	// An invalid matches-json-limit was already rejected by validateConfig
+	matchesLimit, _ := parseMatchesJSONLimit(config.MatchesJSONLimit)
+	outputsErr := setOutputs(stats, output, info, jsonOutputPath, scanMode, badgeOptionsFromConfig(config), matchesLimit, reportURL, gistBadgeURL, extraOutputs)
+	if err := tolerateOutputsError(config, outputsErr); err != nil {
+		return outcomeClean, fmt.Errorf("failed to set outputs: %w", err)
+	}
+
+	// Print compact summary
+	printSummary(stats, output, info)
+	if config.Annotations {
+		printAnnotations(output.Matches, config.SeverityCutoff)
+	}
 
-		gistFiles := map[string]string{
-			badgeFile:  badgeJSON,
-			reportFile: report,
+	// Check if build should fail due to vulnerabilities; a severity budget
+	// takes precedence over severity-cutoff and max-allowed
+	// An invalid severity-budget was already rejected by validateConfig
+	budget, _ := parseSeverityBudget(config.SeverityBudget)
+	if config.FailBuild && budget != nil {
+		if shouldFailBudget(gateStats, budget) {
+			return outcomeFindings, &policyError{fmt.Sprintf("%s exceed severity-budget (%s)", describeGatedFindings(config, baseline != nil), strings.Join(severityBudgetViolations(gateStats, budget), ", "))}
 		}
-		if len(rawJSON) > 0 {
-			gistFiles[grypeFile] = string(rawJSON)
+		return findingsOutcome(stats), nil
+	}
+	if config.FailBuild && shouldFail(gateStats, config.SeverityCutoff, maxAllowed) {
+		count := countAtOrAboveCutoff(gateStats, config.SeverityCutoff)
+		if maxAllowed > 0 {
+			return outcomeFindings, &policyError{fmt.Sprintf("%d %s found at or above %s severity, more than max-allowed %d", count, describeGatedFindings(config, baseline != nil), config.SeverityCutoff, maxAllowed)}
 		}
+		return outcomeFindings, &policyError{fmt.Sprintf("%d %s found at or above %s severity", count, describeGatedFindings(config, baseline != nil), config.SeverityCutoff)}
+	}
 
-		client := NewGistClient(config.GistToken)
-		result, err := client.UpdateGist(config.GistID, badgeFile, reportFile, gistFiles)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to update gist: %v\n", err)
+	return findingsOutcome(stats), nil
+}
+
+// describeGatedFindings names the findings that gated the build for the
+// fail-build error, e.g. "new vulnerabilities (not in baseline) in go-module, npm packages".
+func describeGatedFindings(config Config, diffMode bool) string {
+	desc := "vulnerabilities"
+	if diffMode {
+		desc = "new vulnerabilities (not in baseline)"
+	}
+	if len(config.FailOnTypes) > 0 {
+		desc += fmt.Sprintf(" in %s packages", strings.Join(config.FailOnTypes, ", "))
+	}
+	return desc
+}
+
+// publishGist writes the badge JSON, Markdown report, and raw grype output to
+// the configured gist. The badge shows the trend against the counts of the
+// badge it replaces. It returns the report and endpoint badge URLs, or empty
+// strings when gist integration is not configured or the update failed (the
+// failure is only a warning so the scan result is never lost).
+func publishGist(config Config, output *GrypeOutput, stats VulnerabilityStats, scanMode string, rawJSON []byte) (string, string) {
+	if config.GistToken == "" || config.GistID == "" {
+		return "", ""
+	}
+
+	badgeFile, reportFile, grypeFile, stateFile := defaultGistFilenames(config.GistFilename, scanMode)
+
+	client := NewGistClient(config.GistToken)
+	client.BadgeHost = config.BadgeHost
+	// An invalid gist-proxy was already rejected by validateConfig
+	if proxy, _ := parseGistProxy(config.GistProxy); proxy != nil {
+		client.HTTPClient.Transport = gistTransport(proxy)
+	}
+
+	// One GET serves the badge trend, the first-seen state, and the update
+	current, err := client.FetchGist(config.GistID)
+	if err != nil {
+		logWarnf("failed to update gist: %v", err)
+		return "", ""
+	}
+	previous := current.PreviousBadgeStats(badgeFile)
+
+	// Carry first-seen dates over from the last run; resolved findings drop out
+	now := time.Now().UTC()
+	firstSeen := mergeFirstSeen(current.PreviousFirstSeen(stateFile), output.Matches, now.Format(firstSeenDateLayout))
+	oldestCritical := oldestCriticalDays(firstSeen, output.Matches, now)
+
+	badgeJSON := generateBadgeJSON(badgeOptionsFromConfig(config), stats, previous, output.Descriptor.Version, output.DBBuilt(), scanMode, oldestCritical)
+	report := generateReport(output, stats, scanMode, reportOptionsFromConfig(config))
+
+	gistFiles := map[string]string{
+		badgeFile:  badgeJSON,
+		reportFile: report,
+		stateFile:  formatFirstSeenState(firstSeen),
+	}
+	if len(rawJSON) > 0 {
+		gistFiles[grypeFile] = string(rawJSON)
+	}
+
+	result, err := client.UpdateGist(config.GistID, current, badgeFile, reportFile, gistFiles)
+	if err != nil {
+		logWarnf("failed to update gist: %v", err)
+		return "", ""
+	}
+
+	logInfof("Gist updated: %s", result.GistURL)
+	return result.ReportURL, result.BadgeURL
+}
+
+// publishIntegrations posts the scan results to the optional external
+// integrations. Integrations are best-effort: failures are printed as
+// warnings and never fail the action. The only exception is a webhook failure
+// with webhook-required set, which is returned as an error.
+func publishIntegrations(config Config, output *GrypeOutput, stats VulnerabilityStats, scanMode string) error {
+	if config.GraphQLURL != "" {
+		if err := postGraphQLSummary(config, output, stats, scanMode); err != nil {
+			logWarnf("failed to post results to GraphQL endpoint: %v", err)
 		} else {
-			reportURL = result.ReportURL
-			gistBadgeURL = result.BadgeURL
-			fmt.Printf("Gist updated: %s\n", result.GistURL)
+			logInfof("Results posted to GraphQL endpoint")
 		}
 	}
 
-	// Set GitHub Actions outputs (use gist badge URL when available)
-	if err := setOutputs(stats, output, jsonOutputPath, scanMode, reportURL, gistBadgeURL); err != nil {
-		return fmt.Errorf("failed to set outputs: %w", err)
+	if config.WebhookURL != "" {
+		if err := publishWebhook(config, output, stats, scanMode); err != nil {
+			if config.WebhookRequired {
+				return fmt.Errorf("failed to post results to webhook: %w", err)
+			}
+			logWarnf("failed to post results to webhook: %v", err)
+		} else {
+			logInfof("Results posted to webhook")
+		}
 	}
 
-	// Print compact summary
-	printSummary(stats, output)
+	if config.PRComment {
+		report := generateReport(output, stats, scanMode, reportOptionsFromConfig(config))
+		if err := postPRComment(config, report, scanMode); err != nil {
+			logWarnf("failed to post pull request comment: %v", err)
+		}
+	}
+	return nil
+}
 
-	// Check if build should fail due to vulnerabilities
-	if config.FailBuild && shouldFail(stats, config.SeverityCutoff) {
-		return fmt.Errorf("vulnerabilities found at or above %s severity", config.SeverityCutoff)
+// writeExportFiles writes the optional per-format export files requested via inputs.
+func writeExportFiles(config Config, output *GrypeOutput, stats VulnerabilityStats, scanMode string) error {
+	if config.CSVFile != "" {
+		if err := writeCSV(output, config.CSVFile, config.IncludeFindingHash); err != nil {
+			return fmt.Errorf("failed to write CSV file: %w", err)
+		}
+		logInfof("CSV export saved to: %s", config.CSVFile)
+	}
+
+	if config.SummaryFile != "" {
+		if err := writeSummaryJSON(output, stats, scanMode, config.SummaryFile); err != nil {
+			return fmt.Errorf("failed to write summary file: %w", err)
+		}
+		logInfof("Summary saved to: %s", config.SummaryFile)
+	}
+
+	if config.OSVFile != "" {
+		if err := writeOSV(output, config.OSVFile, config.IncludeFindingHash); err != nil {
+			return fmt.Errorf("failed to write OSV file: %w", err)
+		}
+		logInfof("OSV export saved to: %s", config.OSVFile)
+	}
+
+	if config.JUnitFile != "" {
+		if err := writeJUnit(output, stats, config.JUnitFile, config.SeverityCutoff); err != nil {
+			return fmt.Errorf("failed to write JUnit file: %w", err)
+		}
+		logInfof("JUnit report saved to: %s", config.JUnitFile)
+	}
+
+	if config.HTMLFile != "" {
+		if err := writeHTML(output, stats, scanMode, config.HTMLFile); err != nil {
+			return fmt.Errorf("failed to write HTML file: %w", err)
+		}
+		logInfof("HTML report saved to: %s", config.HTMLFile)
+	}
+
+	if config.LicenseReport != "" {
+		if err := writeLicenseReport(output.Matches, config.LicenseReport); err != nil {
+			return fmt.Errorf("failed to write license report: %w", err)
+		}
+		logInfof("License report saved to: %s", config.LicenseReport)
 	}
 
 	return nil
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunDryRunWithInputJSON verifies that workflow authors can exercise the
+// whole output pipeline without grype or a vulnerability database.
+//
+// This test covers the input-json handling of run and executeScan in main.go
+// and validateInputJSON in scanner.go.
+//
+// It points INPUT_INPUT-JSON at a prepared grype document, hides any grype
+// binary from PATH, and asserts that outputs are written from the file and
+// fail-build and max-allowed still apply; a missing or malformed file yields a
+// clear error.
+func TestRunDryRunWithInputJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", t.TempDir())
+	outFile := filepath.Join(dir, "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+
+	inputPath := writeGrypeJSON(t, dir, "grype.json", []GrypeMatch{
+		makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", ""),
+	})
+
+	t.Run("processes input json without running grype", func(t *testing.T) {
+		t.Setenv("INPUT_INPUT-JSON", inputPath)
+		var err error
+		captureStdout(t, func() { _, err = run() })
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		content, readErr := os.ReadFile(outFile)
+		if readErr != nil {
+			t.Fatalf("ReadFile() error = %v", readErr)
+		}
+		if !strings.Contains(string(content), "high=1") || !strings.Contains(string(content), "cve-count=1") {
+			t.Errorf("outputs not derived from input json:\n%s", content)
+		}
+	})
+
+	t.Run("applies fail-build to input json", func(t *testing.T) {
+		t.Setenv("INPUT_INPUT-JSON", inputPath)
+		t.Setenv("INPUT_FAIL-BUILD", "true")
+		var err error
+		captureStdout(t, func() { _, err = run() })
+		if err == nil || !strings.Contains(err.Error(), "1 vulnerabilities found at or above medium") {
+			t.Errorf("run() error = %v, want fail-build error with count", err)
+		}
+		if code := exitCode(outcomeFindings, err, false); code != exitPolicyFailure {
+			t.Errorf("exitCode() = %d, want %d for fail-build", code, exitPolicyFailure)
+		}
+	})
+
+	t.Run("tolerates findings up to max-allowed", func(t *testing.T) {
+		t.Setenv("INPUT_INPUT-JSON", inputPath)
+		t.Setenv("INPUT_FAIL-BUILD", "true")
+		t.Setenv("INPUT_MAX-ALLOWED", "1")
+		var err error
+		var outcome runOutcome
+		captureStdout(t, func() { outcome, err = run() })
+		if err != nil {
+			t.Errorf("run() error = %v, want nil with max-allowed 1", err)
+		}
+		if outcome != outcomeFindings {
+			t.Errorf("run() outcome = %v, want outcomeFindings", outcome)
+		}
+	})
+
+	invalidPath := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, path := range map[string]string{
+		"rejects missing input json": filepath.Join(dir, "missing.json"),
+		"rejects invalid input json": invalidPath,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("INPUT_INPUT-JSON", path)
+			var err error
+			captureStdout(t, func() { _, err = run() })
+			if err == nil || !strings.Contains(err.Error(), "input-json") {
+				t.Errorf("run() error = %v, want input-json error", err)
+			}
+		})
+	}
+}
+
+// TestExitCode verifies that scripts running the binary directly can tell a
+// clean scan, tolerated findings, a fail-build policy failure, and an internal
+// error apart, while GitHub Actions steps only fail on failures.
+//
+// This test covers exitCode, findingsOutcome, and policyError in main.go.
+//
+// It asserts the 0/1/2/3 mapping outside GitHub Actions, that tolerated
+// findings exit 0 inside GitHub Actions while failures stay non-zero, and that
+// a wrapped policyError is still recognized.
+func TestExitCode(t *testing.T) {
+	policyErr := &policyError{"1 vulnerabilities found at or above high severity"}
+	tests := []struct {
+		name      string
+		outcome   runOutcome
+		err       error
+		inActions bool
+		want      int
+	}{
+		{"clean scan", findingsOutcome(VulnerabilityStats{}), nil, false, exitClean},
+		{"findings below the policy", findingsOutcome(VulnerabilityStats{Total: 2, Low: 2}), nil, false, exitFindings},
+		{"findings below the policy in actions", outcomeFindings, nil, true, exitClean},
+		{"fail-build triggered", outcomeFindings, policyErr, false, exitPolicyFailure},
+		{"wrapped fail-build in actions", outcomeFindings, fmt.Errorf("scan: %w", policyErr), true, exitPolicyFailure},
+		{"internal error", outcomeClean, errors.New("failed to parse grype output"), false, exitInternalError},
+		{"internal error in actions", outcomeClean, errors.New("grype not found"), true, exitInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.outcome, tt.err, tt.inActions); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestProcessResultsUnwritableGitHubOutput verifies that a misconfigured
+// runner whose GITHUB_OUTPUT cannot be written does not throw away the
+// results of a successful scan.
+//
+// This test covers tolerateOutputsError in output.go as used by
+// processResults in main.go.
+//
+// It points GITHUB_OUTPUT into a missing directory and asserts that
+// processResults still prints the summary with a workflow warning and
+// returns no error, and that strict-outputs turns the failure into an error.
+func TestProcessResultsUnwritableGitHubOutput(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "missing", "github_output.txt"))
+	output := &GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", "")}}
+
+	var err error
+	out := captureStdout(t, func() { _, err = processResults(Config{}, output, nil, scanRunInfo{}) })
+	if err != nil {
+		t.Fatalf("processResults() error = %v, want nil without strict-outputs", err)
+	}
+	if !strings.Contains(out, "::warning title=Step outputs not written::") {
+		t.Errorf("output = %q, want outputs warning", out)
+	}
+	if !strings.Contains(out, "CVEs") {
+		t.Errorf("output = %q, want summary", out)
+	}
+
+	captureStdout(t, func() { _, err = processResults(Config{StrictOutputs: true}, output, nil, scanRunInfo{}) })
+	if err == nil || !strings.Contains(err.Error(), "GITHUB_OUTPUT") {
+		t.Errorf("processResults() error = %v, want GITHUB_OUTPUT error with strict-outputs", err)
+	}
+}
+
+// TestProcessResultsStaleDBFailsBeforePublishing verifies that a run on a
+// stale vulnerability database fails without publishing results that may
+// miss recently published CVEs.
+//
+// This test covers the fail-on-stale-db check of processResults in main.go
+// with staleDBError in output.go, against an in-memory gist API.
+//
+// It processes a scan whose database is a year old with a gist configured
+// and asserts that processResults returns a policyError and neither reads
+// nor writes the gist, while the same scan on a fresh database publishes.
+func TestProcessResultsStaleDBFailsBeforePublishing(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "github_output.txt"))
+	fake := &fakeGistServer{files: map[string]gistFileInfo{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	orig := gistAPIBaseURL
+	gistAPIBaseURL = server.URL
+	t.Cleanup(func() { gistAPIBaseURL = orig })
+
+	config := Config{GistToken: "test-token", GistID: "abc123", BadgeHost: defaultBadgeHost, FailOnStaleDB: true, DBStaleAge: "7d"}
+	output := &GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", nil, "", "")}}
+	output.Descriptor.DB.Built = time.Now().UTC().AddDate(-1, 0, 0).Format(time.RFC3339)
+
+	var err error
+	captureStdout(t, func() { _, err = processResults(config, output, nil, scanRunInfo{}) })
+	var policyErr *policyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("processResults() error = %v, want fail-on-stale-db policyError", err)
+	}
+	if fake.gets != 0 || fake.patches != 0 {
+		t.Errorf("gist requests with a stale database = %d GET, %d PATCH, want none", fake.gets, fake.patches)
+	}
+
+	output.Descriptor.DB.Built = time.Now().UTC().Format(time.RFC3339)
+	captureStdout(t, func() { _, err = processResults(config, output, nil, scanRunInfo{}) })
+	if err != nil {
+		t.Fatalf("processResults() error = %v, want nil with a fresh database", err)
+	}
+	if fake.patches != 1 {
+		t.Errorf("PATCH requests with a fresh database = %d, want 1", fake.patches)
+	}
+}
+
+// fakeGistServer is an in-memory gist API for publishGist tests. It keeps
+// the files of one gist, counts GET and PATCH requests, and applies PATCHes
+// like GitHub does (only the named files are replaced).
+type fakeGistServer struct {
+	files   map[string]gistFileInfo
+	gets    int
+	patches int
+}
+
+// ServeHTTP implements http.Handler.
+func (s *fakeGistServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.gets++
+	case http.MethodPatch:
+		s.patches++
+		var req gistUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for name, file := range req.Files {
+			s.files[name] = gistFileInfo{RawURL: "https://gist.githubusercontent.com/user/abc123/raw/deadbeef/" + name, Content: file.Content}
+		}
+	}
+	_ = json.NewEncoder(w).Encode(gistResponse{HTMLURL: "https://gist.github.com/user/abc123", Files: s.files})
+}
+
+// TestPublishGistReadsGistOnce verifies that publishing to a gist costs one
+// read per run, keeping runs on busy repositories within the API rate limit.
+//
+// This test covers publishGist in main.go with FetchGist, PreviousBadgeStats,
+// PreviousFirstSeen, and UpdateGist in gist.go, against an in-memory gist API.
+//
+// It publishes two runs and asserts that each makes exactly one GET, that
+// the first run writes the badge, report, and first-seen state, and that the
+// second run keeps the first-seen date of a persisting vulnerability.
+func TestPublishGistReadsGistOnce(t *testing.T) {
+	fake := &fakeGistServer{files: map[string]gistFileInfo{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	orig := gistAPIBaseURL
+	gistAPIBaseURL = server.URL
+	t.Cleanup(func() { gistAPIBaseURL = orig })
+
+	config := Config{GistToken: "test-token", GistID: "abc123", BadgeHost: defaultBadgeHost}
+	output := &GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", nil, "", "")}}
+	stats := calculateStats(output, "", false)
+
+	var badgeURL string
+	captureStdout(t, func() { _, badgeURL = publishGist(config, output, stats, "image", nil) })
+	if fake.gets != 1 {
+		t.Errorf("first run: GET requests = %d, want 1", fake.gets)
+	}
+	if !strings.Contains(badgeURL, "grype-image.json") {
+		t.Errorf("badge URL = %q, want endpoint of grype-image.json", badgeURL)
+	}
+	for _, name := range []string{"grype-image.json", "grype-image.md", "grype-image-state.json"} {
+		if _, ok := fake.files[name]; !ok {
+			t.Errorf("gist is missing %s after the first run", name)
+		}
+	}
+
+	// Backdate the state as if an earlier run had first reported the CVE
+	fake.files["grype-image-state.json"] = gistFileInfo{Content: formatFirstSeenState(map[string]string{"CVE-CRIT": "2020-01-01"})}
+	fake.gets = 0
+	captureStdout(t, func() { publishGist(config, output, stats, "image", nil) })
+	if fake.gets != 1 {
+		t.Errorf("second run: GET requests = %d, want 1", fake.gets)
+	}
+	if state := parseFirstSeenState(fake.files["grype-image-state.json"].Content); state["CVE-CRIT"] != "2020-01-01" {
+		t.Errorf("first-seen state = %v, want the earlier date kept", state)
+	}
+}
+
+// TestPublishGistSkipsIdenticalScan verifies that a scheduled scan finding
+// the same vulnerabilities as the last run does not rewrite the gist.
+//
+// This test covers the SkipUnchanged handling of publishGist in main.go and
+// UpdateGist and gistFilesUnchanged in gist.go, against an in-memory gist
+// API.
+//
+// It publishes two scans with identical findings but different grype
+// timestamps, as real runs have, and asserts that only the first one sends a
+// PATCH, and that a scan with a new finding sends one again.
+func TestPublishGistSkipsIdenticalScan(t *testing.T) {
+	fake := &fakeGistServer{files: map[string]gistFileInfo{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	orig := gistAPIBaseURL
+	gistAPIBaseURL = server.URL
+	t.Cleanup(func() { gistAPIBaseURL = orig })
+
+	config := Config{GistToken: "test-token", GistID: "abc123", BadgeHost: defaultBadgeHost}
+	publish := func(timestamp string, matches ...GrypeMatch) {
+		output := &GrypeOutput{Matches: matches}
+		output.Descriptor.Timestamp = timestamp
+		rawJSON, err := json.Marshal(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		captureStdout(t, func() { publishGist(config, output, calculateStats(output, "", false), "image", rawJSON) })
+	}
+	crit := makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", nil, "", "")
+
+	publish("2026-03-01T10:00:00Z", crit)
+	publish("2026-03-01T11:00:00Z", crit)
+	if fake.patches != 1 {
+		t.Errorf("PATCH requests after two identical scans = %d, want 1", fake.patches)
+	}
+
+	publish("2026-03-01T12:00:00Z", crit, makeMatch("CVE-HIGH", "High", "curl", "8.0.0", nil, "", ""))
+	if fake.patches != 2 {
+		t.Errorf("PATCH requests after a new finding = %d, want 2", fake.patches)
+	}
+}
+
+// TestRunJSONLogFormat verifies that with log-format: json a SIEM can parse
+// every line the action prints, not only its log messages.
+//
+// This test covers log-format handling of run in main.go with logLine and
+// logResultf in log.go, through printDebugEnv, printSummary,
+// tolerateOutputsError, and runPreflight.
+//
+// It runs a dry run with debug: true and an unwritable GITHUB_OUTPUT, and a
+// preflight against a stub grype, in JSON log format, and asserts that every
+// stdout line parses as a JSON log entry with a level and message.
+func TestRunJSONLogFormat(t *testing.T) {
+	t.Cleanup(func() {
+		setJSONLogging(false)
+		setDebugLogging(false)
+		takeWarnings()
+	})
+	dir := t.TempDir()
+	t.Setenv("INPUT_LOG-FORMAT", "json")
+	t.Setenv("GITHUB_OUTPUT", dir) // a directory, so writing outputs fails
+
+	assertJSONLines := func(t *testing.T, out string) {
+		t.Helper()
+		if out == "" {
+			t.Fatal("no output captured")
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			var entry logEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Level == "" || entry.Msg == "" {
+				t.Errorf("stdout line is not a JSON log entry: %q", line)
+			}
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		t.Setenv("INPUT_DEBUG", "true")
+		t.Setenv("INPUT_INPUT-JSON", writeGrypeJSON(t, dir, "grype.json", []GrypeMatch{
+			makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", ""),
+		}))
+
+		var err error
+		out := captureStdout(t, func() { _, err = run() })
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		assertJSONLines(t, out)
+		for _, want := range []string{"CVEs", "Environment Variables", "failed to write step outputs"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("stdout = %q, want %q", out, want)
+			}
+		}
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		installFakeGrype(t, `if [ "$1" = "--version" ]; then echo "grype 0.87.0"; exit 0; fi
+echo '{"built":"`+time.Now().UTC().Format(time.RFC3339)+`","valid":true}'`)
+		t.Setenv("INPUT_MODE", "preflight")
+		t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "output"))
+
+		var err error
+		out := captureStdout(t, func() { _, err = run() })
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		assertJSONLines(t, out)
+		if !strings.Contains(out, "Preflight passed") {
+			t.Errorf("stdout = %q, want preflight result", out)
+		}
+	})
+}
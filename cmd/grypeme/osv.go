@@ -0,0 +1,154 @@
+// Package main provides OSV (Open Source Vulnerability) export of scan results.
+// The export is a pure transform over GrypeMatch values; see https://ossf.github.io/osv-schema/.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// osvSchemaVersion is the OSV schema version the export conforms to.
+const osvSchemaVersion = "1.6.0"
+
+// osvEcosystems maps Grype package types to OSV ecosystem names.
+// Types without an OSV equivalent keep the Grype type as ecosystem.
+var osvEcosystems = map[string]string{
+	"go-module":    "Go",
+	"npm":          "npm",
+	"python":       "PyPI",
+	"gem":          "RubyGems",
+	"java-archive": "Maven",
+	"rust-crate":   "crates.io",
+	"dotnet":       "NuGet",
+	"php-composer": "Packagist",
+	"pub":          "Pub",
+	"hex":          "Hex",
+	"deb":          "Debian",
+	"apk":          "Alpine",
+	"rpm":          "Red Hat",
+}
+
+// osvVulnerability is one OSV vulnerability object; matches sharing an ID are
+// merged into a single object with multiple affected entries.
+type osvVulnerability struct {
+	SchemaVersion    string            `json:"schema_version"`
+	ID               string            `json:"id"`
+	Modified         string            `json:"modified,omitempty"`
+	Details          string            `json:"details,omitempty"`
+	Affected         []osvAffected     `json:"affected"`
+	References       []osvReference    `json:"references,omitempty"`
+	DatabaseSpecific map[string]string `json:"database_specific,omitempty"`
+}
+
+// osvAffected describes one affected package with its installed version and fix range.
+type osvAffected struct {
+	Package          osvPackage        `json:"package"`
+	Ranges           []osvRange        `json:"ranges,omitempty"`
+	Versions         []string          `json:"versions,omitempty"`
+	DatabaseSpecific map[string]string `json:"database_specific,omitempty"`
+}
+
+// osvPackage identifies a package within an OSV ecosystem.
+type osvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// osvRange is an ECOSYSTEM version range expressed as introduced/fixed events.
+type osvRange struct {
+	Type   string              `json:"type"`
+	Events []map[string]string `json:"events"`
+}
+
+// osvReference links to an external advisory.
+type osvReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// osvEcosystem returns the OSV ecosystem name for a Grype package type.
+func osvEcosystem(pkgType string) string {
+	if eco, ok := osvEcosystems[pkgType]; ok {
+		return eco
+	}
+	return pkgType
+}
+
+// convertToOSV transforms Grype matches into OSV vulnerability objects.
+//
+// matches are grouped by vulnerability ID in sortMatches order (most severe
+// first). Each match becomes an affected entry naming the package, its
+// installed version, and an ECOSYSTEM range ending at each fix version when
+// one is known. Grype's severity has no CVSS vector, so it is reported in
+// database_specific.severity as GitHub advisories do. modified is the DB build
+// timestamp when available. includeHash adds the findingHash of each match to
+// the affected entry's database_specific.
+func convertToOSV(matches []GrypeMatch, modified string, includeHash bool) []osvVulnerability {
+	vulns := []osvVulnerability{}
+	index := map[string]int{}
+
+	for _, m := range sortMatches(matches) {
+		id := m.Vulnerability.ID
+		i, seen := index[id]
+		if !seen {
+			vuln := osvVulnerability{
+				SchemaVersion: osvSchemaVersion,
+				ID:            id,
+				Modified:      modified,
+				Details:       m.Vulnerability.Description,
+			}
+			if m.Vulnerability.Severity != "" {
+				vuln.DatabaseSpecific = map[string]string{"severity": strings.ToUpper(m.Vulnerability.Severity)}
+			}
+			if m.Vulnerability.DataSource != "" {
+				vuln.References = []osvReference{{Type: "ADVISORY", URL: m.Vulnerability.DataSource}}
+			}
+			vulns = append(vulns, vuln)
+			i = len(vulns) - 1
+			index[id] = i
+		}
+
+		affected := osvAffected{
+			Package: osvPackage{Ecosystem: osvEcosystem(m.Artifact.Type), Name: m.Artifact.Name},
+		}
+		if m.Artifact.Version != "" {
+			affected.Versions = []string{m.Artifact.Version}
+		}
+		if len(m.Vulnerability.Fix.Versions) > 0 {
+			events := []map[string]string{{"introduced": "0"}}
+			for _, fixed := range m.Vulnerability.Fix.Versions {
+				events = append(events, map[string]string{"fixed": fixed})
+			}
+			affected.Ranges = []osvRange{{Type: "ECOSYSTEM", Events: events}}
+		}
+		if includeHash {
+			affected.DatabaseSpecific = map[string]string{"findingHash": findingHash(m)}
+		}
+		vulns[i].Affected = append(vulns[i].Affected, affected)
+	}
+
+	return vulns
+}
+
+// writeOSV writes the scan's matches as a JSON array of OSV vulnerability objects.
+//
+// output is the parsed scan; its DB build timestamp becomes each entry's
+// modified field. path is resolved and validated like every other file output.
+// includeHash mirrors the include-finding-hash input (see convertToOSV).
+//
+// Returns an error if the document cannot be marshaled or written. Called from
+// processResults when the osv-file input is set.
+func writeOSV(output *GrypeOutput, path string, includeHash bool) error {
+	vulns := convertToOSV(output.Matches, output.DBBuilt(), includeHash)
+
+	data, err := json.MarshalIndent(vulns, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal OSV export: %w", err)
+	}
+
+	if _, err := writeWorkspaceFile(path, append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
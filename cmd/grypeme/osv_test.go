@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteOSV verifies that teams feeding OSV-based tooling receive a
+// schema-shaped document with the fields those tools require.
+//
+// This test covers writeOSV and convertToOSV in osv.go, called from
+// processResults when osv-file is set.
+//
+// It writes a sample scan and asserts every entry has schema_version, id, and
+// a non-empty affected list; that two matches for one CVE merge into one
+// entry; and that the Go module entry carries the Go ecosystem, installed
+// version, fix range, severity, advisory reference, and finding hash.
+func TestWriteOSV(t *testing.T) {
+	output := &GrypeOutput{}
+	output.Descriptor.DB.Status.Built = "2026-03-08T08:00:00Z"
+	goMatch := makeMatch("CVE-2026-0001", "High", "golang.org/x/net", "0.1.0", []string{"0.2.0"}, "HTTP/2 flaw", "https://nvd.nist.gov/vuln/detail/CVE-2026-0001")
+	goMatch.Artifact.Type = "go-module"
+	npmMatch := makeMatch("CVE-2026-0001", "High", "left-pad", "1.0.0", nil, "HTTP/2 flaw", "")
+	npmMatch.Artifact.Type = "npm"
+	other := makeMatch("GHSA-xxxx-yyyy-zzzz", "Low", "zlib", "1.2.11", nil, "", "")
+	other.Artifact.Type = "deb"
+	output.Matches = []GrypeMatch{other, goMatch, npmMatch}
+
+	path := filepath.Join(t.TempDir(), "results.osv.json")
+	if err := writeOSV(output, path, true); err != nil {
+		t.Fatalf("writeOSV() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var vulns []osvVulnerability
+	if err := json.Unmarshal(data, &vulns); err != nil {
+		t.Fatalf("OSV export is not valid JSON: %v\n%s", err, data)
+	}
+
+	if len(vulns) != 2 {
+		t.Fatalf("got %d OSV entries, want 2 (matches sharing an ID merged)", len(vulns))
+	}
+	for _, v := range vulns {
+		if v.SchemaVersion == "" || v.ID == "" || len(v.Affected) == 0 {
+			t.Errorf("entry %+v is missing schema_version, id, or affected", v)
+		}
+		if v.Modified != "2026-03-08T08:00:00Z" {
+			t.Errorf("modified = %q, want DB build time", v.Modified)
+		}
+	}
+
+	cve := vulns[0]
+	if cve.ID != "CVE-2026-0001" || len(cve.Affected) != 2 {
+		t.Fatalf("first entry = %s with %d affected, want CVE-2026-0001 with 2", cve.ID, len(cve.Affected))
+	}
+	if cve.DatabaseSpecific["severity"] != "HIGH" {
+		t.Errorf("severity = %q, want HIGH", cve.DatabaseSpecific["severity"])
+	}
+	if len(cve.References) != 1 || cve.References[0].URL != goMatch.Vulnerability.DataSource {
+		t.Errorf("references = %+v, want data source advisory", cve.References)
+	}
+
+	goAffected := cve.Affected[0]
+	if goAffected.Package != (osvPackage{Ecosystem: "Go", Name: "golang.org/x/net"}) {
+		t.Errorf("package = %+v, want Go golang.org/x/net", goAffected.Package)
+	}
+	if len(goAffected.Versions) != 1 || goAffected.Versions[0] != "0.1.0" {
+		t.Errorf("versions = %v, want [0.1.0]", goAffected.Versions)
+	}
+	if len(goAffected.Ranges) != 1 || goAffected.Ranges[0].Type != "ECOSYSTEM" ||
+		goAffected.Ranges[0].Events[1]["fixed"] != "0.2.0" {
+		t.Errorf("ranges = %+v, want ECOSYSTEM range fixed at 0.2.0", goAffected.Ranges)
+	}
+	if goAffected.DatabaseSpecific["findingHash"] != findingHash(goMatch) {
+		t.Errorf("findingHash = %q, want %q", goAffected.DatabaseSpecific["findingHash"], findingHash(goMatch))
+	}
+	if len(cve.Affected[1].Ranges) != 0 {
+		t.Errorf("unfixed match has ranges %+v, want none", cve.Affected[1].Ranges)
+	}
+}
+
+// TestWriteOSVWithoutMatches verifies that a clean scan produces a valid,
+// empty OSV document rather than "null".
+//
+// This test covers writeOSV in osv.go.
+//
+// It asserts the file content is an empty JSON array.
+func TestWriteOSVWithoutMatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.osv.json")
+	if err := writeOSV(&GrypeOutput{}, path, false); err != nil {
+		t.Fatalf("writeOSV() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "[]\n" {
+		t.Errorf("content = %q, want empty JSON array", data)
+	}
+}
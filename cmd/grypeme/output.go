@@ -3,11 +3,18 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,38 +22,55 @@ import (
 // setOutputs writes scan results to GitHub Actions step outputs.
 // It generates a badge URL and writes core outputs (counts, versions, badge URL).
 // When gistBadgeURL is non-empty, it is used instead of the static badge URL.
-func setOutputs(stats VulnerabilityStats, output *GrypeOutput, jsonPath, scanMode string, reportURL, gistBadgeURL string) error {
-	githubOutput := os.Getenv("GITHUB_OUTPUT")
-	if githubOutput == "" {
-		fmt.Println("Warning: GITHUB_OUTPUT not set, skipping output generation")
+// info describes the scan target, grype exit code, and step durations.
+// badge holds the badge-host, badge-style, and badge-color settings.
+// matchesLimit caps the entries of the matches-json output (0 = no cap).
+// extra holds optional outputs computed by the caller (e.g., db-age-badge-url)
+// and may be nil.
+func setOutputs(stats VulnerabilityStats, output *GrypeOutput, info scanRunInfo, jsonPath, scanMode string, badge badgeOptions, matchesLimit int, reportURL, gistBadgeURL string, extra map[string]string) error {
+	if os.Getenv("GITHUB_OUTPUT") == "" {
+		logWarnf("GITHUB_OUTPUT not set, skipping output generation")
 		return nil
 	}
 
-	outputFile, isSharedHandle, err := getGitHubOutputWriter(githubOutput)
-	if err != nil {
-		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
-	}
-	if !isSharedHandle {
-		defer func() { _ = outputFile.Close() }()
-	}
-
 	// Use gist endpoint badge URL when available, otherwise fall back to static URL
 	badgeURL := gistBadgeURL
 	if badgeURL == "" {
 		label := buildBadgeLabel(output.Descriptor.Version)
-		badgeURL = generateBadgeURL(stats, label, output.DBBuilt(), scanMode)
+		badgeURL = generateBadgeURL(badge, stats, label, output.DBBuilt(), scanMode)
 	}
 
 	outputs := map[string]string{
-		"grype-version": output.Descriptor.Version,
-		"db-version":    output.DBBuilt(),
-		"cve-count":     fmt.Sprintf("%d", stats.Total),
-		"critical":      fmt.Sprintf("%d", stats.Critical),
-		"high":          fmt.Sprintf("%d", stats.High),
-		"medium":        fmt.Sprintf("%d", stats.Medium),
-		"low":           fmt.Sprintf("%d", stats.Low),
-		"badge-url":     badgeURL,
+		"grype-version":      output.Descriptor.Version,
+		"db-version":         output.DBBuilt(),
+		"cve-count":          fmt.Sprintf("%d", stats.Total),
+		"distinct-cve-count": fmt.Sprintf("%d", stats.Distinct),
+		"critical":           fmt.Sprintf("%d", stats.Critical),
+		"high":               fmt.Sprintf("%d", stats.High),
+		"medium":             fmt.Sprintf("%d", stats.Medium),
+		"low":                fmt.Sprintf("%d", stats.Low),
+		"badge-url":          badgeURL,
+		"ignored-count":      fmt.Sprintf("%d", len(output.IgnoredMatches)),
+		"fixable-count":      fmt.Sprintf("%d", stats.Fixable),
+		"not-fixed-count":    fmt.Sprintf("%d", stats.NotFixed),
+		"scan-mode":          scanMode,
+	}
+
+	if schema := output.Descriptor.DB.Status.SchemaVersion; schema > 0 {
+		outputs["db-schema-version"] = strconv.Itoa(schema)
+	}
+
+	top, _ := topMatch(output.Matches)
+	outputs["top-cve-id"] = top.Vulnerability.ID
+	outputs["top-cve-severity"] = top.Vulnerability.Severity
+	outputs["top-cve-package"] = top.Artifact.Name
+
+	matchesJSON, err := compactMatchesJSON(output.Matches, matchesLimit)
+	if err != nil {
+		return err
 	}
+	outputs["matches-json"] = matchesJSON
+	outputs["affected-packages"] = affectedPackages(output.Matches, maxAffectedPackagesLen)
 
 	privilegeMode, privilegeDetail := getRuntimePrivilegeInfo()
 	if privilegeMode != "" {
@@ -62,6 +86,138 @@ func setOutputs(stats VulnerabilityStats, output *GrypeOutput, jsonPath, scanMod
 	if reportURL != "" {
 		outputs["report-url"] = reportURL
 	}
+	if info.Scanned {
+		outputs["grype-exit-code"] = strconv.Itoa(info.ExitCode)
+	}
+	if info.Target != "" {
+		outputs["scan-target"] = info.Target
+	}
+	if info.ScanDuration > 0 {
+		outputs["scan-duration-seconds"] = formatSeconds(info.ScanDuration)
+	}
+	if info.DBUpdateDuration > 0 {
+		outputs["db-update-duration-seconds"] = formatSeconds(info.DBUpdateDuration)
+	}
+	for key, value := range extra {
+		outputs[key] = value
+	}
+
+	return writeOutputs(outputs)
+}
+
+// defaultMatchesJSONLimit keeps the matches-json output well below GitHub's
+// size limits for step outputs, even for noisy scans.
+const defaultMatchesJSONLimit = 50
+
+// matchSummary is one entry of the matches-json output: just enough for a
+// downstream step to list or filter findings without reading the grype JSON.
+type matchSummary struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Package  string `json:"package"`
+	Version  string `json:"version"`
+	Fixed    bool   `json:"fixed"`
+}
+
+// parseMatchesJSONLimit parses the matches-json-limit input. An empty value
+// selects defaultMatchesJSONLimit and "0" disables the cap.
+func parseMatchesJSONLimit(value string) (int, error) {
+	if value == "" {
+		return defaultMatchesJSONLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid matches-json-limit %q: must be a non-negative integer", value)
+	}
+	return limit, nil
+}
+
+// compactMatchesJSON renders matches as the single-line JSON array of the
+// matches-json output, most severe first (see sortMatches). Only the first
+// limit matches are included unless limit is 0; cve-count still holds the
+// full count. Returns "[]" when there are no matches.
+func compactMatchesJSON(matches []GrypeMatch, limit int) (string, error) {
+	sorted := sortMatches(matches)
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	summaries := make([]matchSummary, 0, len(sorted))
+	for _, m := range sorted {
+		summaries = append(summaries, matchSummary{
+			ID:       m.Vulnerability.ID,
+			Severity: m.Vulnerability.Severity,
+			Package:  m.Artifact.Name,
+			Version:  m.Artifact.Version,
+			Fixed:    strings.EqualFold(m.Vulnerability.Fix.State, "fixed"),
+		})
+	}
+
+	data, err := json.Marshal(summaries)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal matches-json output: %w", err)
+	}
+	return string(data), nil
+}
+
+// maxAffectedPackagesLen caps the affected-packages output so images with
+// hundreds of vulnerable packages stay well within step output size limits.
+const maxAffectedPackagesLen = 1024
+
+// affectedPackages returns the sorted, deduplicated package names of matches
+// as a comma-separated list for the affected-packages output. Names that
+// would push the list beyond maxLen bytes are left out and summarized as a
+// " +N more" suffix. Returns "" when there are no matches.
+func affectedPackages(matches []GrypeMatch, maxLen int) string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range matches {
+		if name := m.Artifact.Name; name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if list := strings.Join(names, ","); len(list) <= maxLen {
+		return list
+	}
+
+	// Keep room for the suffix, which is at most as long as the current one
+	var b strings.Builder
+	for i, name := range names {
+		suffix := fmt.Sprintf(" +%d more", len(names)-i)
+		sep := 0
+		if i > 0 {
+			sep = 1
+		}
+		if b.Len()+sep+len(name)+len(suffix) > maxLen {
+			b.WriteString(suffix)
+			break
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// writeOutputs appends outputs to the GITHUB_OUTPUT file. It does nothing when
+// GITHUB_OUTPUT is not set, so it is also safe on error paths outside Actions.
+func writeOutputs(outputs map[string]string) error {
+	githubOutput := os.Getenv("GITHUB_OUTPUT")
+	if githubOutput == "" {
+		return nil
+	}
+
+	outputFile, isSharedHandle, err := getGitHubOutputWriter(githubOutput)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+	}
+	if !isSharedHandle {
+		defer func() { _ = outputFile.Close() }()
+	}
 
 	for key, value := range outputs {
 		if _, err := fmt.Fprintf(outputFile, "%s=%s\n", key, value); err != nil {
@@ -72,13 +228,72 @@ func setOutputs(stats VulnerabilityStats, output *GrypeOutput, jsonPath, scanMod
 	return nil
 }
 
-// printSummary prints a compact one-line summary of the scan results to stdout.
-func printSummary(stats VulnerabilityStats, output *GrypeOutput) {
+// tolerateOutputsError decides how a failure to write GITHUB_OUTPUT (e.g. a
+// misconfigured runner) affects the run. With strict-outputs it is returned
+// and fails the action; otherwise it is printed as a workflow warning (a
+// logged warning in JSON log format) and nil is returned, so the summary,
+// gist, and fail-build checks still happen instead of losing the results of
+// a successful scan.
+func tolerateOutputsError(config Config, err error) error {
+	if err == nil || config.StrictOutputs {
+		return err
+	}
+	msg := "failed to write step outputs, continuing without them (set strict-outputs to fail instead): " + err.Error()
+	if jsonLogging {
+		logWarnf("%s", msg)
+		return nil
+	}
+	fmt.Printf("::warning title=Step outputs not written::%s\n", escapeAnnotationData(msg))
+	return nil
+}
+
+// printSummary prints a compact one-line summary of the scan results,
+// followed by the step durations from info and a per-package-type breakdown
+// when vulnerabilities were found. It prints through logResultf, so quiet:
+// true keeps it.
+func printSummary(stats VulnerabilityStats, output *GrypeOutput, info scanRunInfo) {
 	msg := formatBadgeMessage(stats)
-	fmt.Printf("✊ grype %s | db %s | %s CVEs\n",
+	logResultf("✊ grype %s | db %s | %s CVEs",
 		output.Descriptor.Version,
 		extractDBDate(output.DBBuilt()),
 		msg)
+
+	if schema := output.Descriptor.DB.Status.SchemaVersion; schema > 0 {
+		logResultf("  db schema: v%d", schema)
+	}
+	if stats.Total > 0 {
+		logResultf("  fixable: %d of %d", stats.Fixable, stats.Total)
+	}
+	if info.ScanDuration > 0 {
+		logResultf("  scan took: %ss", formatSeconds(info.ScanDuration))
+	}
+	if info.DBUpdateDuration > 0 {
+		logResultf("  db update took: %ss", formatSeconds(info.DBUpdateDuration))
+	}
+	if breakdown := formatTypeBreakdown(countByType(output.Matches)); breakdown != "" {
+		logResultf("  by type: %s", breakdown)
+	}
+}
+
+// formatTypeBreakdown renders per-type counts as "go-module 3 | npm 1",
+// sorted by descending count and then by type name.
+func formatTypeBreakdown(counts map[string]int) string {
+	types := make([]string, 0, len(counts))
+	for pkgType := range counts {
+		types = append(types, pkgType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	parts := make([]string, 0, len(types))
+	for _, pkgType := range types {
+		parts = append(parts, fmt.Sprintf("%s %d", pkgType, counts[pkgType]))
+	}
+	return strings.Join(parts, " | ")
 }
 
 // buildBadgeLabel creates the badge label with the Grype version.
@@ -96,10 +311,54 @@ func extractDBDate(timestamp string) string {
 	return timestamp
 }
 
+// defaultBadgeHost is the public shields.io server used unless badge-host is set.
+const defaultBadgeHost = "https://img.shields.io"
+
+// badgeStyles lists the shields.io badge styles accepted by badge-style.
+var badgeStyles = []string{"flat", "flat-square", "plastic", "for-the-badge", "social"}
+
+// badgeOptions holds the user-configurable appearance of the generated badges.
+type badgeOptions struct {
+	Host  string // shields.io-compatible server (e.g., https://img.shields.io)
+	Style string // shields.io style parameter; empty keeps the server default
+	Color string // Fixed color overriding the severity color; empty keeps it
+	Label string // Fixed label overriding the generated one; empty keeps it
+}
+
+// badgeOptionsFromConfig extracts the badge settings from the action inputs.
+func badgeOptionsFromConfig(config Config) badgeOptions {
+	return badgeOptions{
+		Host:  config.BadgeHost,
+		Style: config.BadgeStyle,
+		Color: config.BadgeColor,
+		Label: config.BadgeLabel,
+	}
+}
+
+// staticLabel returns the label segment of a static badge URL: the badge-label
+// override with shields.io's dash and underscore escaping, so it renders
+// verbatim, or else the generated label.
+func (o badgeOptions) staticLabel(generated string) string {
+	if o.Label == "" {
+		return generated
+	}
+	return strings.NewReplacer("-", "--", "_", "__").Replace(o.Label)
+}
+
+// styleQuery returns the "?style=" query for static badge URLs, or "" when
+// no style is configured so default URLs stay unchanged.
+func (o badgeOptions) styleQuery() string {
+	if o.Style == "" {
+		return ""
+	}
+	return "?style=" + url.QueryEscape(o.Style)
+}
+
 // generateBadgeURL creates a shields.io badge URL based on scan statistics.
 // Label: "✊ grype <version>", Message: "db <date>: <counts> CVEs in <scanMode>".
-// Colors indicate the highest severity found.
-func generateBadgeURL(stats VulnerabilityStats, label, dbBuilt, scanMode string) string {
+// Colors indicate the highest severity found unless opts forces a color;
+// opts also selects the badge server and style and may override the label.
+func generateBadgeURL(opts badgeOptions, stats VulnerabilityStats, label, dbBuilt, scanMode string) string {
 	counts := formatBadgeMessage(stats)
 
 	message := fmt.Sprintf("%s CVEs in %s", counts, scanMode)
@@ -111,39 +370,101 @@ func generateBadgeURL(stats VulnerabilityStats, label, dbBuilt, scanMode string)
 	}
 
 	color := determineBadgeColor(stats)
+	if opts.Color != "" {
+		color = opts.Color
+	}
 
 	// shields.io static badge format: https://img.shields.io/badge/{label}-{message}-{color}
-	encodedLabel := url.PathEscape(label)
+	encodedLabel := url.PathEscape(opts.staticLabel(label))
 	encodedMessage := url.PathEscape(message)
 
-	return fmt.Sprintf("https://img.shields.io/badge/%s-%s-%s", encodedLabel, encodedMessage, color)
+	return fmt.Sprintf("%s/badge/%s-%s-%s%s", opts.Host, encodedLabel, encodedMessage, url.PathEscape(color), opts.styleQuery())
 }
 
 // formatBadgeMessage creates the count portion of the badge message.
 // Returns "0" if no vulnerabilities, otherwise severity counts like "3 critical | 1 high".
 func formatBadgeMessage(stats VulnerabilityStats) string {
+	return formatBadgeMessageWithDelta(stats, nil)
+}
+
+// formatBadgeMessageWithDelta is formatBadgeMessage with the trend against a
+// previous scan: each shown count that changed gets an arrow and the difference,
+// e.g. "3 critical (▲1) | 1 high (▼2)", and a clean result after findings
+// reads "0 (▼5)". Unchanged counts and a nil previous get no suffix.
+func formatBadgeMessageWithDelta(stats VulnerabilityStats, previous *VulnerabilityStats) string {
+	withDelta := func(current int, prior func(VulnerabilityStats) int) string {
+		if previous == nil {
+			return ""
+		}
+		return formatDelta(current - prior(*previous))
+	}
+
 	if stats.Total == 0 {
-		return "0"
+		return "0" + withDelta(0, func(s VulnerabilityStats) int { return s.Total })
 	}
 
 	var parts []string
-	if stats.Critical > 0 {
-		parts = append(parts, fmt.Sprintf("%d critical", stats.Critical))
+	addPart := func(count int, severity string, prior func(VulnerabilityStats) int) {
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s%s", count, severity, withDelta(count, prior)))
+		}
 	}
-	if stats.High > 0 {
-		parts = append(parts, fmt.Sprintf("%d high", stats.High))
+	addPart(stats.Critical, "critical", func(s VulnerabilityStats) int { return s.Critical })
+	addPart(stats.High, "high", func(s VulnerabilityStats) int { return s.High })
+	addPart(stats.Medium, "medium", func(s VulnerabilityStats) int { return s.Medium })
+	addPart(stats.Low, "low", func(s VulnerabilityStats) int { return s.Low })
+	if len(parts) == 0 {
+		addPart(stats.Negligible, "negligible", func(s VulnerabilityStats) int { return s.Negligible })
+		addPart(stats.Other, "other", func(s VulnerabilityStats) int { return s.Other })
 	}
-	if stats.Medium > 0 {
-		parts = append(parts, fmt.Sprintf("%d medium", stats.Medium))
+
+	return strings.Join(parts, " | ")
+}
+
+// formatDelta renders a count change as " (▲n)" or " (▼n)", or "" for none.
+func formatDelta(delta int) string {
+	switch {
+	case delta > 0:
+		return fmt.Sprintf(" (▲%d)", delta)
+	case delta < 0:
+		return fmt.Sprintf(" (▼%d)", -delta)
+	default:
+		return ""
 	}
-	if stats.Low > 0 {
-		parts = append(parts, fmt.Sprintf("%d low", stats.Low))
+}
+
+// badgeCounts is the machine-readable "counts" field of the endpoint badge
+// JSON. shields.io ignores it; the next run reads it back to show the trend.
+type badgeCounts struct {
+	Total      int `json:"total"`
+	Critical   int `json:"critical"`
+	High       int `json:"high"`
+	Medium     int `json:"medium"`
+	Low        int `json:"low"`
+	Negligible int `json:"negligible"`
+	Other      int `json:"other"`
+}
+
+// parseBadgeCounts reads the counts stored by generateBadgeJSON back from a
+// badge JSON document. The boolean is false when the document is not JSON or
+// predates the counts field, so no trend is shown.
+func parseBadgeCounts(badgeJSON string) (VulnerabilityStats, bool) {
+	var badge struct {
+		Counts *badgeCounts `json:"counts"`
 	}
-	if len(parts) == 0 && stats.Other > 0 {
-		parts = append(parts, fmt.Sprintf("%d other", stats.Other))
+	if err := json.Unmarshal([]byte(badgeJSON), &badge); err != nil || badge.Counts == nil {
+		return VulnerabilityStats{}, false
 	}
-
-	return strings.Join(parts, " | ")
+	c := badge.Counts
+	return VulnerabilityStats{
+		Total:      c.Total,
+		Critical:   c.Critical,
+		High:       c.High,
+		Medium:     c.Medium,
+		Low:        c.Low,
+		Negligible: c.Negligible,
+		Other:      c.Other,
+	}, true
 }
 
 // determineBadgeColor returns the shields.io badge color based on the highest severity found.
@@ -155,8 +476,10 @@ func determineBadgeColor(stats VulnerabilityStats) string {
 		return "orange"
 	case stats.Medium > 0:
 		return "yellow"
-	case stats.Low > 0 || stats.Other > 0:
+	case stats.Low > 0:
 		return "yellowgreen"
+	case stats.Negligible > 0 || stats.Other > 0:
+		return "lightgrey" // Negligible or unknown severity only
 	default:
 		return "brightgreen"
 	}
@@ -164,43 +487,351 @@ func determineBadgeColor(stats VulnerabilityStats) string {
 
 // generateBadgeJSON creates a shields.io endpoint badge JSON for use with gists.
 // This JSON is consumed by shields.io/endpoint to render a dynamic badge.
-func generateBadgeJSON(stats VulnerabilityStats, grypeVersion, dbBuilt, scanMode string) string {
+// opts.Color overrides the severity color and opts.Style adds a "style" field.
+// previous, when non-nil, holds the counts of the last published badge (see
+// parseBadgeCounts) and adds trend arrows to the message; the current counts
+// are always stored in a "counts" field for the next run. oldestCritical, the
+// days since the oldest unresolved critical vulnerability was first seen (see
+// oldestCriticalDays), is appended to the message unless negative.
+func generateBadgeJSON(opts badgeOptions, stats VulnerabilityStats, previous *VulnerabilityStats, grypeVersion, dbBuilt, scanMode string, oldestCritical int) string {
 	label := buildBadgeLabel(grypeVersion)
-	counts := formatBadgeMessage(stats)
+	if opts.Label != "" {
+		label = opts.Label
+	}
+	counts := formatBadgeMessageWithDelta(stats, previous)
 	message := fmt.Sprintf("%s CVEs in %s", counts, scanMode)
 	if dbBuilt != "" {
 		if dbDate := extractDBDate(dbBuilt); dbDate != "" {
 			message = fmt.Sprintf("db %s: %s", dbDate, message)
 		}
 	}
+	if oldestCritical >= 0 {
+		message += fmt.Sprintf(", oldest unresolved critical: %d days", oldestCritical)
+	}
 	color := determineBadgeColor(stats)
+	if opts.Color != "" {
+		color = opts.Color
+	}
+
+	style := ""
+	if opts.Style != "" {
+		style = fmt.Sprintf(`,"style":"%s"`, escapeJSON(opts.Style))
+	}
+
+	countsJSON := fmt.Sprintf(`,"counts":{"total":%d,"critical":%d,"high":%d,"medium":%d,"low":%d,"negligible":%d,"other":%d}`,
+		stats.Total, stats.Critical, stats.High, stats.Medium, stats.Low, stats.Negligible, stats.Other)
 
 	// Minimal JSON without external dependencies
-	return fmt.Sprintf(`{"schemaVersion":1,"label":"%s","message":"%s","color":"%s"}`,
-		escapeJSON(label), escapeJSON(message), escapeJSON(color))
+	return fmt.Sprintf(`{"schemaVersion":1,"label":"%s","message":"%s","color":"%s"%s%s}`,
+		escapeJSON(label), escapeJSON(message), escapeJSON(color), style, countsJSON)
+}
+
+// Database age thresholds for the DB-freshness badge colors.
+const (
+	dbAgeFresh = 2 * 24 * time.Hour // Up to this age the badge is green
+	dbAgeStale = 7 * 24 * time.Hour // Beyond this age the badge is red
+)
+
+// dbAge returns how old the vulnerability database is at now.
+// dbBuilt is the value of GrypeOutput.DBBuilt() (RFC3339 or a plain YYYY-MM-DD date).
+// The boolean is false when the timestamp is missing or unparsable.
+// A build time in the future (clock skew) is reported as age zero.
+func dbAge(dbBuilt string, now time.Time) (time.Duration, bool) {
+	built, err := time.Parse(time.RFC3339, dbBuilt)
+	if err != nil {
+		built, err = time.Parse("2006-01-02", extractDBDate(dbBuilt))
+		if err != nil {
+			return 0, false
+		}
+	}
+
+	age := now.Sub(built)
+	if age < 0 {
+		age = 0
+	}
+	return age, true
+}
+
+// parseDBStaleAge parses the db-stale-age input: a Go duration (e.g. "72h")
+// or a whole number of days (e.g. "7d"). Empty selects dbAgeStale.
+func parseDBStaleAge(value string) (time.Duration, error) {
+	if value == "" {
+		return dbAgeStale, nil
+	}
+	var staleAge time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid db-stale-age %q: want e.g. 7d or 72h", value)
+		}
+		staleAge = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid db-stale-age %q: want e.g. 7d or 72h", value)
+		}
+		staleAge = d
+	}
+	if staleAge <= 0 {
+		return 0, fmt.Errorf("invalid db-stale-age %q: must be positive", value)
+	}
+	return staleAge, nil
+}
+
+// dbFreshnessOutputs returns the db-stale ("true"/"false") and db-age-days
+// outputs for a database built at dbBuilt (see dbAge), and whether it is
+// older than staleAge at now. Both outputs are omitted when the build time is
+// unknown, since no age can be claimed then.
+func dbFreshnessOutputs(dbBuilt string, staleAge time.Duration, now time.Time) (map[string]string, bool) {
+	age, ok := dbAge(dbBuilt, now)
+	if !ok {
+		return nil, false
+	}
+	stale := age > staleAge
+	return map[string]string{
+		"db-stale":    strconv.FormatBool(stale),
+		"db-age-days": strconv.Itoa(int(age.Hours() / 24)),
+	}, stale
+}
+
+// staleDBError returns the fail-on-stale-db error for a database built at
+// dbBuilt that is older than staleAge at now, or nil for a fresh one. A
+// database without a build time fails too, since its freshness cannot be
+// shown.
+func staleDBError(dbBuilt string, staleAge time.Duration, now time.Time) error {
+	age, ok := dbAge(dbBuilt, now)
+	if !ok {
+		return &policyError{"fail-on-stale-db: vulnerability database reports no build time, so its age cannot be verified"}
+	}
+	if age > staleAge {
+		return &policyError{fmt.Sprintf("fail-on-stale-db: vulnerability database built %s is %s old, more than the allowed db-stale-age %s", extractDBDate(dbBuilt), formatDBAge(age), formatDBAge(staleAge))}
+	}
+	return nil
+}
+
+// formatDBAge renders a database age compactly, in hours below one day and in whole days otherwise.
+func formatDBAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
+
+// determineDBAgeBadgeColor returns green for a fresh DB, yellow for an aging
+// one, and red once it is older than dbAgeStale.
+func determineDBAgeBadgeColor(age time.Duration) string {
+	switch {
+	case age <= dbAgeFresh:
+		return "brightgreen"
+	case age <= dbAgeStale:
+		return "yellow"
+	default:
+		return "critical" // Red
+	}
+}
+
+// generateDBAgeBadgeURL creates a shields.io badge URL showing the vulnerability DB age.
+// Label: "✊ grype <version>", Message: "db <age> old" (e.g., "db 2d old").
+// An unknown build time yields a lightgrey "db age unknown" badge. The color
+// always reflects the age; opts only selects the badge server and style.
+func generateDBAgeBadgeURL(opts badgeOptions, label, dbBuilt string, now time.Time) string {
+	message := "db age unknown"
+	color := "lightgrey"
+	if age, ok := dbAge(dbBuilt, now); ok {
+		message = fmt.Sprintf("db %s old", formatDBAge(age))
+		color = determineDBAgeBadgeColor(age)
+	}
+
+	return fmt.Sprintf("%s/badge/%s-%s-%s%s", opts.Host, url.PathEscape(label), url.PathEscape(message), color, opts.styleQuery())
+}
+
+// reportOptions holds the user-configurable presentation settings of the Markdown report.
+type reportOptions struct {
+	Description      string // Optional free text shown under the report title
+	CollapseVersions bool   // Merge rows of one CVE+package across installed versions
+	MaxRows          int    // Maximum rows in the CVE table; 0 renders all rows
+	Sort             string // Row order: "severity" (default) or "package"
+	MinSeverity      string // Lowest severity listed in the detailed tables; empty lists all
+	CVELinkTemplate  string // Source link URL with {id} replaced by the vulnerability ID; empty uses grype's data source
+}
+
+// defaultReportMaxRows keeps reports of noisy scans well below the size GitHub
+// accepts for a gist file and readable when linked from a README.
+const defaultReportMaxRows = 200
+
+// parseReportMaxRows parses the report-max-rows input. An empty value selects
+// defaultReportMaxRows and "0" disables truncation.
+func parseReportMaxRows(value string) (int, error) {
+	if value == "" {
+		return defaultReportMaxRows, nil
+	}
+	maxRows, err := strconv.Atoi(value)
+	if err != nil || maxRows < 0 {
+		return 0, fmt.Errorf("invalid report-max-rows %q: must be a non-negative integer", value)
+	}
+	return maxRows, nil
+}
+
+// reportOptionsFromConfig extracts the report presentation settings from the action inputs.
+// An invalid report-max-rows was already rejected by validateConfig.
+func reportOptionsFromConfig(config Config) reportOptions {
+	maxRows, _ := parseReportMaxRows(config.ReportMaxRows)
+	return reportOptions{
+		Description:      config.Description,
+		CollapseVersions: config.CollapseVersions,
+		MaxRows:          maxRows,
+		Sort:             config.ReportSort,
+		MinSeverity:      config.ReportMinSeverity,
+		CVELinkTemplate:  config.CVELinkTemplate,
+	}
+}
+
+// cveLinkPlaceholder is replaced by the vulnerability ID in cve-link-template.
+const cveLinkPlaceholder = "{id}"
+
+// validateCVELinkTemplate checks that a cve-link-template is an http(s) URL
+// containing the {id} placeholder. Empty disables the template.
+func validateCVELinkTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	if !strings.Contains(template, cveLinkPlaceholder) {
+		return fmt.Errorf("invalid cve-link-template %q: must contain %s", template, cveLinkPlaceholder)
+	}
+	parsed, err := url.Parse(strings.ReplaceAll(template, cveLinkPlaceholder, "CVE-0000-0000"))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid cve-link-template %q: must be an http or https URL", template)
+	}
+	return nil
+}
+
+// vulnerabilityLink returns the Source link target of a report row: the
+// cve-link-template with {id} replaced by the vulnerability ID when set,
+// otherwise grype's data source URL (possibly empty).
+func vulnerabilityLink(m GrypeMatch, template string) string {
+	if template == "" {
+		return m.Vulnerability.DataSource
+	}
+	return strings.ReplaceAll(template, cveLinkPlaceholder, url.PathEscape(m.Vulnerability.ID))
+}
+
+// reportRow is one row of the detailed CVE table. Versions lists the installed
+// versions covered by the row; it has more than one entry only when rows were
+// merged by collapseVersions.
+type reportRow struct {
+	Match    GrypeMatch
+	Versions []string
+}
+
+// buildReportRows shapes the matches into the rows of the detailed CVE table:
+// sorted by sortMatches and, when opts.CollapseVersions is set, merged per
+// CVE and package by collapseVersions.
+func buildReportRows(matches []GrypeMatch, opts reportOptions) []reportRow {
+	sorted := sortMatchesBy(matches, opts.Sort)
+	if opts.CollapseVersions {
+		return collapseVersions(sorted)
+	}
+
+	rows := make([]reportRow, 0, len(sorted))
+	for _, m := range sorted {
+		rows = append(rows, reportRow{Match: m, Versions: []string{m.Artifact.Version}})
+	}
+	return rows
+}
+
+// collapseVersions merges matches that share a vulnerability ID, package name,
+// and package type into one row listing every affected installed version, so
+// images bundling a library twice do not repeat the same CVE. Fix versions are
+// merged as well. Rows keep the order of the first match of each group.
+func collapseVersions(matches []GrypeMatch) []reportRow {
+	rows := []reportRow{}
+	index := map[string]int{}
+
+	for _, m := range matches {
+		key := m.Vulnerability.ID + "\x00" + m.Artifact.Name + "\x00" + m.Artifact.Type
+		i, seen := index[key]
+		if !seen {
+			// Copy the fix versions so appending below never aliases the input match.
+			m.Vulnerability.Fix.Versions = append([]string(nil), m.Vulnerability.Fix.Versions...)
+			rows = append(rows, reportRow{Match: m, Versions: []string{m.Artifact.Version}})
+			index[key] = len(rows) - 1
+			continue
+		}
+
+		row := &rows[i]
+		row.Versions = appendUnique(row.Versions, m.Artifact.Version)
+		for _, fixed := range m.Vulnerability.Fix.Versions {
+			row.Match.Vulnerability.Fix.Versions = appendUnique(row.Match.Vulnerability.Fix.Versions, fixed)
+		}
+	}
+
+	return rows
+}
+
+// appendUnique appends value to values unless it is already present.
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
 }
 
 // generateReport creates a Markdown vulnerability report suitable for storing in a gist.
 // Includes a summary table and a detailed CVE table with package info, fix versions, and data source links.
-func generateReport(output *GrypeOutput, stats VulnerabilityStats, scanMode, description string) string {
-	return generateReportAt(output, stats, scanMode, description, time.Now().UTC())
+func generateReport(output *GrypeOutput, stats VulnerabilityStats, scanMode string, opts reportOptions) string {
+	return generateReportAt(output, stats, scanMode, opts, time.Now().UTC())
+}
+
+// scanTime returns the time grype recorded in descriptor.timestamp, in UTC,
+// or fallback when the timestamp is missing or not RFC3339.
+func scanTime(output *GrypeOutput, fallback time.Time) time.Time {
+	if output == nil || output.Descriptor.Timestamp == "" {
+		return fallback
+	}
+	scanned, err := time.Parse(time.RFC3339, output.Descriptor.Timestamp)
+	if err != nil {
+		logDebugf("Ignoring unparsable descriptor timestamp %q: %v", output.Descriptor.Timestamp, err)
+		return fallback
+	}
+	return scanned.UTC()
+}
+
+// reportScannedLabel starts the report line holding the scan time, the only
+// part of the report that differs between runs with identical results.
+const reportScannedLabel = "**Scanned:**"
+
+// withoutScanTime returns report without its "Scanned:" line, for comparing
+// the reports of two runs.
+func withoutScanTime(report string) string {
+	lines := strings.SplitAfter(report, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, reportScannedLabel) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
 }
 
 // generateReportAt creates a Markdown report with a specific timestamp (for testability).
-func generateReportAt(output *GrypeOutput, stats VulnerabilityStats, scanMode, description string, now time.Time) string {
+// The "Scanned:" line prefers grype's descriptor timestamp over now, so
+// archived scans keep their real scan time (see scanTime).
+func generateReportAt(output *GrypeOutput, stats VulnerabilityStats, scanMode string, opts reportOptions, now time.Time) string {
 	var b strings.Builder
 
 	grypeVersion := output.Descriptor.Version
 	dbDate := extractDBDate(output.DBBuilt())
 
 	b.WriteString("# ✊ grype_me — Vulnerability Scan Report\n\n")
-	if description != "" {
-		fmt.Fprintf(&b, "**Description:** %s \n", description)
+	if description := strings.TrimSpace(opts.Description); description != "" {
+		fmt.Fprintf(&b, "**Description:** %s  \n", description)
 	}
 	fmt.Fprintf(&b, "**Scan mode:** %s  \n", scanMode)
 	fmt.Fprintf(&b, "**grype version:** %s  \n", grypeVersion)
 	fmt.Fprintf(&b, "**DB version:** %s  \n", dbDate)
-	fmt.Fprintf(&b, "**Scanned:** %s  \n", now.Format("2006-01-02 15:04 UTC"))
+	fmt.Fprintf(&b, "%s %s  \n", reportScannedLabel, scanTime(output, now).Format("2006-01-02 15:04 UTC"))
 	fmt.Fprintf(&b, "**Total CVEs:** %d\n\n", stats.Total)
 
 	// Summary table
@@ -211,37 +842,71 @@ func generateReportAt(output *GrypeOutput, stats VulnerabilityStats, scanMode, d
 	fmt.Fprintf(&b, "| High | %d |\n", stats.High)
 	fmt.Fprintf(&b, "| Medium | %d |\n", stats.Medium)
 	fmt.Fprintf(&b, "| Low | %d |\n", stats.Low)
+	if stats.Negligible > 0 {
+		fmt.Fprintf(&b, "| Negligible | %d |\n", stats.Negligible)
+	}
 	if stats.Other > 0 {
 		fmt.Fprintf(&b, "| Other | %d |\n", stats.Other)
 	}
 	fmt.Fprintf(&b, "| **Total** | **%d** |\n", stats.Total)
+	if stats.Total > 0 {
+		fmt.Fprintf(&b, "| Fixable (fix available, within total) | %d |\n", stats.Fixable)
+		fmt.Fprintf(&b, "| Without fix (not-fixed/wont-fix, within total) | %d |\n", stats.NotFixed)
+	}
+	if ignored := len(output.IgnoredMatches); ignored > 0 {
+		fmt.Fprintf(&b, "| Ignored (grype ignore rules, not in total) | %d |\n", ignored)
+	}
 
 	// Detailed CVE table (only if vulnerabilities found)
 	if stats.Total > 0 {
 		b.WriteString("\n## Vulnerabilities\n\n")
-		b.WriteString("| CVE | Severity | Package | Installed | Fixed | Description | Source |\n")
-		b.WriteString("|-----|----------|---------|-----------|-------|-------------|--------|\n")
 
-		sorted := sortMatches(output.Matches)
-		for _, m := range sorted {
+		// The note must precede the table: a paragraph between the
+		// delimiter row and the data rows would end the table early.
+		matches := output.Matches
+		if opts.MinSeverity != "" {
+			matches = filterMatchesByMinSeverity(matches, opts.MinSeverity)
+			if hidden := len(output.Matches) - len(matches); hidden > 0 {
+				fmt.Fprintf(&b, "Showing %s severity and above; %s lower-severity findings are only counted in the summary.\n\n", opts.MinSeverity, formatThousands(hidden))
+			}
+		}
+
+		b.WriteString("| CVE | Severity | EPSS | Package | Installed | Fixed | Description | Source |\n")
+		b.WriteString("|-----|----------|-----:|---------|-----------|-------|-------------|--------|\n")
+
+		rows := buildReportRows(matches, opts)
+		omitted := 0
+		if opts.MaxRows > 0 && len(rows) > opts.MaxRows {
+			omitted = len(rows) - opts.MaxRows
+			rows = rows[:opts.MaxRows]
+		}
+
+		for _, row := range rows {
+			m := row.Match
 			fixed := strings.Join(m.Vulnerability.Fix.Versions, ", ")
 			if fixed == "" {
 				fixed = "—"
 			}
 			desc := truncate(m.Vulnerability.Description, 80)
 			source := ""
-			if m.Vulnerability.DataSource != "" {
-				source = fmt.Sprintf("[link](%s)", m.Vulnerability.DataSource)
+			if link := vulnerabilityLink(m, opts.CVELinkTemplate); link != "" {
+				source = fmt.Sprintf("[link](%s)", link)
 			}
-			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s |\n",
 				m.Vulnerability.ID,
 				m.Vulnerability.Severity,
+				formatEPSS(m),
 				m.Artifact.Name,
-				m.Artifact.Version,
+				strings.Join(row.Versions, ", "),
 				fixed,
 				desc,
 				source)
 		}
+		if omitted > 0 {
+			fmt.Fprintf(&b, "\n… and %s more (see full JSON)\n", formatThousands(omitted))
+		}
+
+		writeNotFixedSection(&b, matches, opts.MaxRows)
 	} else {
 		b.WriteString("\n✅ No vulnerabilities found.\n")
 	}
@@ -251,35 +916,143 @@ func generateReportAt(output *GrypeOutput, stats VulnerabilityStats, scanMode, d
 	return b.String()
 }
 
-// sortMatches returns a copy of matches sorted by severity (critical first), then by CVE ID.
+// writeNotFixedSection appends a collapsed list of the matches grype reports
+// as not-fixed or wont-fix, since those need mitigation rather than an
+// upgrade. Rows follow sortMatches and are capped at maxRows like the main
+// table (0 = no cap). Nothing is written when there are no such matches.
+func writeNotFixedSection(b *strings.Builder, matches []GrypeMatch, maxRows int) {
+	var notFixed []GrypeMatch
+	for _, m := range matches {
+		if isNotFixed(m) {
+			notFixed = append(notFixed, m)
+		}
+	}
+	if len(notFixed) == 0 {
+		return
+	}
+
+	notFixed = sortMatches(notFixed)
+	omitted := 0
+	if maxRows > 0 && len(notFixed) > maxRows {
+		omitted = len(notFixed) - maxRows
+		notFixed = notFixed[:maxRows]
+	}
+
+	fmt.Fprintf(b, "\n<details>\n<summary>Without a fix (%s): mitigate instead of upgrading</summary>\n\n", formatThousands(len(notFixed)+omitted))
+	b.WriteString("| CVE | Severity | Package | Installed | Fix state |\n")
+	b.WriteString("|-----|----------|---------|-----------|-----------|\n")
+	for _, m := range notFixed {
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n",
+			m.Vulnerability.ID,
+			m.Vulnerability.Severity,
+			m.Artifact.Name,
+			m.Artifact.Version,
+			strings.ToLower(m.Vulnerability.Fix.State))
+	}
+	if omitted > 0 {
+		fmt.Fprintf(b, "\n… and %s more (see full JSON)\n", formatThousands(omitted))
+	}
+	b.WriteString("\n</details>\n")
+}
+
+// formatThousands renders n with comma thousands separators, e.g. "1,234".
+func formatThousands(n int) string {
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// reportSortKeys lists the values accepted by report-sort; the first is the default.
+var reportSortKeys = []string{"severity", "package"}
+
+// sortMatches returns a copy of matches sorted by severity (critical first),
+// then by descending EPSS score (matches without EPSS last), then by CVE ID.
 func sortMatches(matches []GrypeMatch) []GrypeMatch {
+	return sortMatchesBy(matches, "severity")
+}
+
+// sortMatchesBy returns a copy of matches sorted by key. "package" groups
+// matches by package name and orders each group like sortMatches; any other
+// key sorts like sortMatches.
+func sortMatchesBy(matches []GrypeMatch, key string) []GrypeMatch {
 	sorted := make([]GrypeMatch, len(matches))
 	copy(sorted, matches)
 	sort.Slice(sorted, func(i, j int) bool {
-		si := severityOrder(sorted[i].Vulnerability.Severity)
-		sj := severityOrder(sorted[j].Vulnerability.Severity)
-		if si != sj {
-			return si < sj
+		if key == "package" && sorted[i].Artifact.Name != sorted[j].Artifact.Name {
+			return sorted[i].Artifact.Name < sorted[j].Artifact.Name
 		}
-		return sorted[i].Vulnerability.ID < sorted[j].Vulnerability.ID
+		return lessBySeverity(sorted[i], sorted[j])
 	})
 	return sorted
 }
 
+// lessBySeverity orders a before b by severity, then descending EPSS score
+// (matches without EPSS last), then CVE ID.
+func lessBySeverity(a, b GrypeMatch) bool {
+	sa := severityOrder(a.Vulnerability.Severity)
+	sb := severityOrder(b.Vulnerability.Severity)
+	if sa != sb {
+		return sa < sb
+	}
+	ea, aok := a.MaxEPSS()
+	eb, bok := b.MaxEPSS()
+	if aok != bok {
+		return aok
+	}
+	if ea.EPSS != eb.EPSS {
+		return ea.EPSS > eb.EPSS
+	}
+	return a.Vulnerability.ID < b.Vulnerability.ID
+}
+
+// topMatch returns the single most severe match in sortMatches order
+// (severity, then EPSS). The boolean is false when there are no matches.
+func topMatch(matches []GrypeMatch) (GrypeMatch, bool) {
+	if len(matches) == 0 {
+		return GrypeMatch{}, false
+	}
+	return sortMatches(matches)[0], true
+}
+
+// formatEPSS renders a match's EPSS score and percentile for the report, e.g. "0.973 (p99.8)",
+// or "—" when Grype did not report one.
+func formatEPSS(m GrypeMatch) string {
+	score, ok := m.MaxEPSS()
+	if !ok {
+		return "—"
+	}
+	return fmt.Sprintf("%.3f (p%.1f)", score.EPSS, score.Percentile*100)
+}
+
+// severityLadder lists Grype severities from most to least severe.
+// Anything not on the ladder is treated as "unknown", the lowest rung.
+var severityLadder = []string{"critical", "high", "medium", "low", "negligible", "unknown"}
+
 // severityOrder returns a numeric order for severity (lower = more severe).
 func severityOrder(severity string) int {
-	switch strings.ToLower(severity) {
-	case "critical":
-		return 0
-	case "high":
-		return 1
-	case "medium":
-		return 2
-	case "low":
-		return 3
-	default:
-		return 4
+	lower := strings.ToLower(severity)
+	for i, s := range severityLadder {
+		if s == lower {
+			return i
+		}
 	}
+	return len(severityLadder) - 1
+}
+
+// validateSeverityName checks that severity is a rung of the severity ladder.
+func validateSeverityName(severity string) error {
+	for _, s := range severityLadder {
+		if s == strings.ToLower(severity) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid severity %q (allowed: %s)", severity, strings.Join(severityLadder, ", "))
 }
 
 // truncate shortens a string to maxLen characters, appending "…" if truncated.
@@ -293,6 +1066,27 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-1] + "…"
 }
 
+// findingHash returns a stable identifier for a single finding so downstream
+// deduplication and ticketing systems can recognize it across runs.
+//
+// The hash is the hex-encoded SHA-256 of the vulnerability ID, package name,
+// installed version, and package type, joined with NUL separators so that
+// distinct field combinations can never collide by concatenation. It depends
+// only on those four fields, so rescanning the same artifact yields the same
+// value regardless of severity or description updates in the vulnerability DB.
+//
+// Used by per-finding exports when the include-finding-hash input is enabled.
+func findingHash(m GrypeMatch) string {
+	key := strings.Join([]string{
+		m.Vulnerability.ID,
+		m.Artifact.Name,
+		m.Artifact.Version,
+		m.Artifact.Type,
+	}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
 // escapeJSON escapes a string for embedding in a JSON value.
 func escapeJSON(s string) string {
 	s = strings.ReplaceAll(s, `\`, `\\`)
@@ -327,10 +1121,105 @@ func validatePathInWorkspace(destPath, workspace string) error {
 	return nil
 }
 
+// byteSizeUnits maps the unit suffixes of max-output-size to their size in
+// bytes; units are binary (1 KB = 1024 bytes).
+var byteSizeUnits = map[string]int64{"": 1, "B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30}
+
+// parseMaxOutputSize parses the max-output-size input, e.g. "50MB", "512 KB",
+// or a plain byte count. An empty value or 0 selects no limit.
+func parseMaxOutputSize(value string) (int64, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	if normalized == "" {
+		return 0, nil
+	}
+	number := strings.TrimRight(normalized, "BKMG ")
+	unit, ok := byteSizeUnits[strings.TrimSpace(normalized[len(number):])]
+	size, err := strconv.ParseInt(number, 10, 64)
+	if !ok || err != nil || size < 0 || size > math.MaxInt64/unit {
+		return 0, fmt.Errorf("invalid max-output-size %q: want a size such as 50MB, 512KB, or a byte count", value)
+	}
+	return size * unit, nil
+}
+
 // copyOutputFile copies the scan results from a temporary file to the user-specified location.
 // It handles relative paths by resolving them against the GitHub workspace.
+// maxSize, when positive, is the max-output-size limit: larger results are
+// refused instead of being copied onto the runner disk.
 // Returns the absolute path to the copied file.
-func copyOutputFile(srcPath, destPath string) (string, error) {
+func copyOutputFile(srcPath, destPath string, maxSize int64) (string, error) {
+	if maxSize > 0 {
+		info, err := os.Stat(srcPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read source: %w", err)
+		}
+		if info.Size() > maxSize {
+			return "", fmt.Errorf("scan results are %d bytes, more than max-output-size (%d bytes); narrow the scan (e.g., exclude, only-fixed, or max-severity) or raise the limit", info.Size(), maxSize)
+		}
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source: %w", err)
+	}
+
+	return writeWorkspaceFile(destPath, data)
+}
+
+// saveOutputFile writes the scan results to output-file in the format selected
+// by its extension: ".csv" uses writeCSV, ".sarif" uses writeSARIF, and any
+// other extension receives grype's raw JSON from srcPath via copyOutputFile.
+// includeHash mirrors the include-finding-hash input for CSV, and maxSize the
+// max-output-size limit of the raw JSON copy.
+// Returns the absolute path of the written file.
+func saveOutputFile(srcPath, destPath string, output *GrypeOutput, includeHash bool, maxSize int64) (string, error) {
+	switch strings.ToLower(filepath.Ext(destPath)) {
+	case ".csv":
+		if err := writeCSV(output, destPath, includeHash); err != nil {
+			return "", err
+		}
+	case ".sarif":
+		if err := writeSARIF(output, destPath); err != nil {
+			return "", err
+		}
+	default:
+		return copyOutputFile(srcPath, destPath, maxSize)
+	}
+
+	resolved, _ := resolveDestinationPath(destPath)
+	return resolved, nil
+}
+
+// saveOutputFiles writes the scan results to every output-file entry via
+// saveOutputFile, so one run can produce e.g. both raw JSON and CSV. All
+// destinations are checked against the workspace before any is written, so
+// a bad entry leaves no partial set of files behind. Returns the absolute
+// paths of the written files, in input order.
+func saveOutputFiles(srcPath string, destPaths []string, output *GrypeOutput, includeHash bool, maxSize int64) ([]string, error) {
+	for _, destPath := range destPaths {
+		if resolved, workspace := resolveDestinationPath(destPath); workspace != "" {
+			if err := validatePathInWorkspace(resolved, workspace); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	saved := make([]string, 0, len(destPaths))
+	for _, destPath := range destPaths {
+		path, err := saveOutputFile(srcPath, destPath, output, includeHash, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, path)
+	}
+	return saved, nil
+}
+
+// writeWorkspaceFile writes data to a user-specified destination, resolving
+// relative paths against the GitHub workspace and rejecting path traversal.
+// Shared by every file-based output so they all apply the same path checks,
+// and written atomically (see writeFileAtomic).
+// Returns the absolute path of the written file.
+func writeWorkspaceFile(destPath string, data []byte) (string, error) {
 	resolvedDest, workspace := resolveDestinationPath(destPath)
 
 	if workspace != "" {
@@ -343,16 +1232,153 @@ func copyOutputFile(srcPath, destPath string) (string, error) {
 		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	data, err := os.ReadFile(srcPath)
+	if err := writeFileAtomic(resolvedDest, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write destination: %w", err)
+	}
+
+	return resolvedDest, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path
+// and renames it into place, so readers see either the previous file or the
+// complete new one, never a partial write from a killed process. The file
+// gets perm regardless of the umask; the temporary file is removed on error.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		return "", fmt.Errorf("failed to read source: %w", err)
+		return err
 	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }() // no-op once renamed
 
-	if err := os.WriteFile(resolvedDest, data, 0644); err != nil {
-		return "", fmt.Errorf("failed to write destination: %w", err)
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
 	}
+	return os.Rename(tmpPath, path)
+}
 
-	return resolvedDest, nil
+// scanSummary is the compact JSON document written by writeSummaryJSON.
+// It carries only aggregate counts and scan metadata, not individual matches.
+type scanSummary struct {
+	GrypeVersion string         `json:"grypeVersion"`
+	DBDate       string         `json:"dbDate"`
+	ScanMode     string         `json:"scanMode"`
+	Total        int            `json:"total"`
+	Distinct     int            `json:"distinct"`
+	Critical     int            `json:"critical"`
+	High         int            `json:"high"`
+	Medium       int            `json:"medium"`
+	Low          int            `json:"low"`
+	Negligible   int            `json:"negligible"`
+	Other        int            `json:"other"`
+	Fixable      int            `json:"fixable"`
+	NotFixed     int            `json:"notFixed"`
+	BySeverity   map[string]int `json:"bySeverity"`
+}
+
+// writeSummaryJSON writes a small JSON summary of the scan for dashboards that
+// do not want to download the full (often multi-megabyte) Grype JSON.
+//
+// output provides the Grype version and DB build date, stats the aggregated
+// counts from calculateStats, and scanMode the mode from determineScanMode.
+// path is resolved and validated like every other file output.
+//
+// Returns an error if the summary cannot be marshaled or written. Called from
+// processResults when the summary-file input is set.
+func writeSummaryJSON(output *GrypeOutput, stats VulnerabilityStats, scanMode, path string) error {
+	data, err := json.MarshalIndent(buildScanSummary(output, stats, scanMode), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+
+	if _, err := writeWorkspaceFile(path, append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// buildScanSummary collects the aggregate counts and scan metadata shared by
+// the summary-file export and the webhook payload.
+func buildScanSummary(output *GrypeOutput, stats VulnerabilityStats, scanMode string) scanSummary {
+	return scanSummary{
+		GrypeVersion: output.Descriptor.Version,
+		DBDate:       extractDBDate(output.DBBuilt()),
+		ScanMode:     scanMode,
+		Total:        stats.Total,
+		Distinct:     stats.Distinct,
+		Critical:     stats.Critical,
+		High:         stats.High,
+		Medium:       stats.Medium,
+		Low:          stats.Low,
+		Negligible:   stats.Negligible,
+		Other:        stats.Other,
+		Fixable:      stats.Fixable,
+		NotFixed:     stats.NotFixed,
+		BySeverity:   stats.BySeverity(),
+	}
+}
+
+// csvHeader lists the columns written by writeCSV, in order.
+var csvHeader = []string{"CVE", "Severity", "Package", "Version", "Type", "FixState", "FixVersions", "DataSource"}
+
+// writeCSV exports the vulnerability matches as a CSV file for spreadsheet pipelines.
+//
+// output is the parsed scan; its matches are written most-severe first using
+// sortMatches, with one row per match and FixVersions joined by semicolons.
+// path is the user-supplied destination and goes through the same workspace
+// resolution and traversal checks as output-file. includeHash appends a
+// FindingHash column (see findingHash) for downstream deduplication.
+//
+// Returns an error if the CSV cannot be encoded or the destination is invalid
+// or unwritable. Called from processResults when the csv-file input is set.
+func writeCSV(output *GrypeOutput, path string, includeHash bool) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := csvHeader
+	if includeHash {
+		header = append(append([]string{}, csvHeader...), "FindingHash")
+	}
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, m := range sortMatches(output.Matches) {
+		row := []string{
+			m.Vulnerability.ID,
+			m.Vulnerability.Severity,
+			m.Artifact.Name,
+			m.Artifact.Version,
+			m.Artifact.Type,
+			m.Vulnerability.Fix.State,
+			strings.Join(m.Vulnerability.Fix.Versions, ";"),
+			m.Vulnerability.DataSource,
+		}
+		if includeHash {
+			row = append(row, findingHash(m))
+		}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for %s: %w", m.Vulnerability.ID, err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to encode CSV: %w", err)
+	}
+
+	if _, err := writeWorkspaceFile(path, buf.Bytes()); err != nil {
+		return err
+	}
+	return nil
 }
 
 // resolveDestinationPath converts a relative path to an absolute path.
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,10 +31,14 @@ func TestSetOutputsIncludesRuntimePrivilegeInfo(t *testing.T) {
 	err := setOutputs(
 		VulnerabilityStats{Total: 1, High: 1},
 		output,
+		scanRunInfo{},
 		"",
 		"release",
+		badgeOptions{Host: defaultBadgeHost},
+		defaultMatchesJSONLimit,
 		"https://gist.github.com/user/id#file-report-md",
 		"https://img.shields.io/endpoint?url=https://example.invalid/badge.json",
+		map[string]string{"db-age-badge-url": "https://img.shields.io/badge/db"},
 	)
 	if err != nil {
 		t.Fatalf("setOutputs() error = %v", err)
@@ -46,6 +55,7 @@ func TestSetOutputsIncludesRuntimePrivilegeInfo(t *testing.T) {
 		"runtime-privilege-detail=cannot chown /github/file_commands/set_output_x",
 		"grype-version=0.106.0",
 		"report-url=https://gist.github.com/user/id#file-report-md",
+		"db-age-badge-url=https://img.shields.io/badge/db",
 	}
 	for _, want := range checks {
 		if !strings.Contains(text, want) {
@@ -54,6 +64,222 @@ func TestSetOutputsIncludesRuntimePrivilegeInfo(t *testing.T) {
 	}
 }
 
+// TestSetOutputsGrypeExitCode verifies that downstream steps can branch on
+// grype's own exit code, while dry runs without a grype run omit it.
+//
+// This test covers the grype-exit-code output of setOutputs in output.go,
+// fed by the exit code executeScan returns in its scanRunInfo.
+//
+// It writes outputs for a scan that exited with 1 and asserts
+// "grype-exit-code=1", then for a run without a scan and asserts the output
+// is absent.
+func TestSetOutputsGrypeExitCode(t *testing.T) {
+	for info, want := range map[scanRunInfo]string{{Scanned: true, ExitCode: 1}: "grype-exit-code=1\n", {}: ""} {
+		outFile := filepath.Join(t.TempDir(), "github_output.txt")
+		t.Setenv("GITHUB_OUTPUT", outFile)
+
+		if err := setOutputs(VulnerabilityStats{}, &GrypeOutput{}, info, "", "image", badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil); err != nil {
+			t.Fatalf("setOutputs() error = %v", err)
+		}
+		content, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("failed to read output file: %v", err)
+		}
+		if got := strings.Contains(string(content), "grype-exit-code="); got != (want != "") || !strings.Contains(string(content), want) {
+			t.Errorf("%+v: outputs = %q, want grype-exit-code line %q", info, content, want)
+		}
+	}
+}
+
+// TestSetOutputsDurations verifies that scan and database update durations
+// are available as step outputs, and absent when the step did not run.
+//
+// This test covers the scan-duration-seconds and db-update-duration-seconds
+// outputs of setOutputs in output.go.
+//
+// It sets only the scan duration and asserts "scan-duration-seconds=4.2"
+// without a db-update-duration-seconds line.
+func TestSetOutputsDurations(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+	if err := setOutputs(VulnerabilityStats{}, &GrypeOutput{}, scanRunInfo{ScanDuration: 4200 * time.Millisecond}, "", "image", badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil); err != nil {
+		t.Fatalf("setOutputs() error = %v", err)
+	}
+	content, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(content), "scan-duration-seconds=4.2\n") {
+		t.Errorf("outputs = %q, want scan-duration-seconds=4.2", content)
+	}
+	if strings.Contains(string(content), "db-update-duration-seconds=") {
+		t.Errorf("outputs = %q, want no db-update-duration-seconds without db update", content)
+	}
+}
+
+// TestSetOutputsScanTarget verifies that a run reports what it actually
+// scanned, so "what did it scan?" is answered by the step outputs.
+//
+// This test covers the scan-target and scan-mode outputs of setOutputs in
+// output.go, fed by determineScanTarget and determineScanMode.
+//
+// It resolves an image and a path config and asserts scan-target
+// "alpine:latest" with scan-mode "image", and "dir:<path>" with scan-mode
+// "path".
+func TestSetOutputsScanTarget(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		config     Config
+		wantTarget string
+		wantMode   string
+	}{
+		{"image", Config{Image: "alpine:latest"}, "alpine:latest", "image"},
+		{"path", Config{Path: dir}, "dir:" + dir, "path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, _, err := determineScanTarget(tt.config)
+			if err != nil {
+				t.Fatalf("determineScanTarget() error = %v", err)
+			}
+
+			outFile := filepath.Join(t.TempDir(), "github_output.txt")
+			t.Setenv("GITHUB_OUTPUT", outFile)
+			if err := setOutputs(VulnerabilityStats{}, &GrypeOutput{}, scanRunInfo{Target: target}, "", determineScanMode(tt.config), badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil); err != nil {
+				t.Fatalf("setOutputs() error = %v", err)
+			}
+			content, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("failed to read output file: %v", err)
+			}
+			for _, want := range []string{"scan-target=" + tt.wantTarget + "\n", "scan-mode=" + tt.wantMode + "\n"} {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("outputs = %q, want %q", content, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSetOutputsMatchesJSON verifies that downstream steps can read the most
+// severe findings from a compact step output instead of the full grype JSON.
+//
+// This test covers the matches-json output of setOutputs and
+// compactMatchesJSON and parseMatchesJSONLimit in output.go.
+//
+// It writes outputs for three matches with a limit of 2 and asserts a single
+// JSON line holding the critical and high findings in that order with their
+// fixed flags, then asserts "[]" for a clean scan, that limit 0 keeps every
+// match, and that negative or non-numeric limits are rejected.
+func TestSetOutputsMatchesJSON(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", []string{"3.0.1"}, "", ""),
+		makeMatch("CVE-HIGH", "High", "curl", "8.0.0", nil, "", ""),
+	}}
+
+	t.Run("writes the most severe matches up to the limit", func(t *testing.T) {
+		outFile := filepath.Join(t.TempDir(), "github_output.txt")
+		t.Setenv("GITHUB_OUTPUT", outFile)
+
+		if err := setOutputs(calculateStats(output, "", false), output, scanRunInfo{}, "", "image", badgeOptions{Host: defaultBadgeHost}, 2, "", "", nil); err != nil {
+			t.Fatalf("setOutputs() error = %v", err)
+		}
+		content, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("failed to read output file: %v", err)
+		}
+		want := `matches-json=[{"id":"CVE-CRIT","severity":"Critical","package":"openssl","version":"3.0.0","fixed":true},` +
+			`{"id":"CVE-HIGH","severity":"High","package":"curl","version":"8.0.0","fixed":false}]` + "\n"
+		if !strings.Contains(string(content), want) {
+			t.Errorf("outputs = %q, want line %q", content, want)
+		}
+	})
+
+	t.Run("writes an empty array for a clean scan", func(t *testing.T) {
+		if got, err := compactMatchesJSON(nil, defaultMatchesJSONLimit); err != nil || got != "[]" {
+			t.Errorf("compactMatchesJSON(nil) = %q, %v, want []", got, err)
+		}
+	})
+
+	t.Run("keeps every match with limit 0", func(t *testing.T) {
+		got, err := compactMatchesJSON(output.Matches, 0)
+		if err != nil || strings.Count(got, `"id"`) != 3 {
+			t.Errorf("compactMatchesJSON(limit 0) = %q, %v, want all 3 matches", got, err)
+		}
+	})
+
+	t.Run("parses the limit input", func(t *testing.T) {
+		tests := []struct {
+			value   string
+			want    int
+			wantErr bool
+		}{
+			{"", defaultMatchesJSONLimit, false},
+			{"0", 0, false},
+			{"10", 10, false},
+			{"-1", 0, true},
+			{"many", 0, true},
+		}
+		for _, tt := range tests {
+			got, err := parseMatchesJSONLimit(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseMatchesJSONLimit(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		}
+	})
+}
+
+// TestSetOutputsAffectedPackages verifies that triage steps get a short list
+// of the vulnerable packages without parsing the grype JSON.
+//
+// This test covers the affected-packages output of setOutputs and
+// affectedPackages in output.go.
+//
+// It writes outputs for matches with duplicate packages and asserts a sorted,
+// deduplicated list, then asserts that a tight length cap ends the list with
+// a "+N more" suffix, that a list exactly at the cap is kept whole, and that
+// a clean scan yields "".
+func TestSetOutputsAffectedPackages(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-2", "Low", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-3", "Critical", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-4", "Medium", "curl", "8.0.0", nil, "", ""),
+		makeMatch("CVE-5", "Medium", "zlib", "1.2.12", nil, "", ""),
+	}}
+
+	outFile := filepath.Join(t.TempDir(), "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+	if err := setOutputs(calculateStats(output, "", false), output, scanRunInfo{}, "", "image", badgeOptions{Host: defaultBadgeHost}, 0, "", "", nil); err != nil {
+		t.Fatalf("setOutputs() error = %v", err)
+	}
+	content, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if want := "affected-packages=curl,openssl,zlib\n"; !strings.Contains(string(content), want) {
+		t.Errorf("outputs = %q, want line %q", content, want)
+	}
+
+	t.Run("caps the list with a +N more suffix", func(t *testing.T) {
+		if got := affectedPackages(output.Matches, 16); got != "curl +2 more" {
+			t.Errorf("affectedPackages(cap 16) = %q, want %q", got, "curl +2 more")
+		}
+		if got := affectedPackages(output.Matches, 17); got != "curl,openssl,zlib" {
+			t.Errorf("affectedPackages(cap 17) = %q, want the full list that just fits", got)
+		}
+	})
+
+	t.Run("is empty for a clean scan", func(t *testing.T) {
+		if got := affectedPackages(nil, maxAffectedPackagesLen); got != "" {
+			t.Errorf("affectedPackages(nil) = %q, want empty", got)
+		}
+	})
+}
+
 func TestSetOutputsWithoutGithubOutputIsNonFatal(t *testing.T) {
 	t.Setenv("GITHUB_OUTPUT", "")
 
@@ -61,12 +287,113 @@ func TestSetOutputsWithoutGithubOutputIsNonFatal(t *testing.T) {
 	output.Descriptor.Version = "0.106.0"
 	output.Descriptor.DB.Status.Built = "2026-03-08T08:00:00Z"
 
-	err := setOutputs(VulnerabilityStats{}, output, "", "head", "", "")
+	err := setOutputs(VulnerabilityStats{}, output, scanRunInfo{}, "", "head", badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil)
 	if err != nil {
 		t.Fatalf("setOutputs() should be non-fatal without GITHUB_OUTPUT, got %v", err)
 	}
 }
 
+// TestSaveOutputFileByExtension verifies that output-file writes the format
+// its name suggests, so results.csv really contains CSV.
+//
+// This test covers saveOutputFile in output.go, called from executeScan.
+//
+// It saves one scan to .json, .JSON, .txt, .csv, and .sarif destinations and
+// asserts that the first three receive grype's raw JSON unchanged, the CSV
+// starts with the csvHeader row, and the SARIF file declares version 2.1.0.
+func TestSaveOutputFileByExtension(t *testing.T) {
+	dir := t.TempDir()
+	raw := `{"matches":[],"descriptor":{"version":"0.106.0"}}`
+	srcPath := filepath.Join(dir, "grype.json")
+	if err := os.WriteFile(srcPath, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output := &GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", "")}}
+
+	tests := []struct {
+		name       string
+		file       string
+		wantPrefix string
+	}{
+		{"copies raw json for .json", "results.json", raw},
+		{"matches extensions case-insensitively", "results.JSON", raw},
+		{"copies raw json for unknown extensions", "results.txt", raw},
+		{"writes csv for .csv", "results.csv", strings.Join(csvHeader, ",")},
+		{"writes sarif for .sarif", "results.sarif", "{\n  \"$schema\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(dir, tt.file)
+			written, err := saveOutputFile(srcPath, dest, output, false, 0)
+			if err != nil {
+				t.Fatalf("saveOutputFile() error = %v", err)
+			}
+			if written != dest {
+				t.Errorf("saveOutputFile() = %q, want %q", written, dest)
+			}
+			data, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if !strings.HasPrefix(string(data), tt.wantPrefix) {
+				t.Errorf("%s starts with %q, want %q", tt.file, truncate(string(data), 60), tt.wantPrefix)
+			}
+		})
+	}
+}
+
+// TestSaveOutputFiles verifies that one scan can be archived as raw JSON and
+// handed to a spreadsheet as CSV at the same time.
+//
+// This test covers saveOutputFiles in output.go, called from executeScan and
+// scanTags with the output-file list, and parseListOrLinesEnv in config.go.
+//
+// It parses a comma- and newline-separated output-file, saves one scan to
+// both entries, and asserts raw JSON and CSV in input order. It also asserts
+// that an entry escaping the workspace fails before any file is written.
+func TestSaveOutputFiles(t *testing.T) {
+	t.Setenv("INPUT_OUTPUT-FILE", "results.json,\n results.csv\n")
+	destPaths := parseListOrLinesEnv("INPUT_OUTPUT-FILE")
+	if strings.Join(destPaths, "|") != "results.json|results.csv" {
+		t.Fatalf("parseListOrLinesEnv() = %q, want results.json and results.csv", destPaths)
+	}
+
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	raw := `{"matches":[]}`
+	srcPath := filepath.Join(t.TempDir(), "grype.json")
+	if err := os.WriteFile(srcPath, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output := &GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", "")}}
+
+	saved, err := saveOutputFiles(srcPath, destPaths, output, false, 0)
+	if err != nil {
+		t.Fatalf("saveOutputFiles() error = %v", err)
+	}
+	want := []string{filepath.Join(workspace, "results.json"), filepath.Join(workspace, "results.csv")}
+	if strings.Join(saved, "|") != strings.Join(want, "|") {
+		t.Errorf("saveOutputFiles() = %q, want %q", saved, want)
+	}
+	for path, wantPrefix := range map[string]string{want[0]: raw, want[1]: strings.Join(csvHeader, ",")} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if !strings.HasPrefix(string(data), wantPrefix) {
+			t.Errorf("%s starts with %q, want %q", path, truncate(string(data), 60), wantPrefix)
+		}
+	}
+
+	if _, err := saveOutputFiles(srcPath, []string{"first.json", "../escape.csv"}, output, false, 0); err == nil {
+		t.Error("saveOutputFiles() with a path outside the workspace should fail")
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "first.json")); !os.IsNotExist(err) {
+		t.Errorf("first.json was written before the invalid entry was rejected (stat error = %v)", err)
+	}
+}
+
 func TestCopyOutputFile(t *testing.T) {
 	srcDir := t.TempDir()
 	srcFile := filepath.Join(srcDir, "source.json")
@@ -78,7 +405,7 @@ func TestCopyOutputFile(t *testing.T) {
 	dstDir := t.TempDir()
 	dstFile := filepath.Join(dstDir, "dest.json")
 
-	result, err := copyOutputFile(srcFile, dstFile)
+	result, err := copyOutputFile(srcFile, dstFile, 0)
 	if err != nil {
 		t.Fatalf("copyOutputFile() error = %v", err)
 	}
@@ -92,6 +419,96 @@ func TestCopyOutputFile(t *testing.T) {
 	}
 }
 
+// TestCopyOutputFileAtomic verifies that downstream steps never read a
+// truncated results file, even if the action is killed mid-write.
+//
+// This test covers writeFileAtomic in output.go, used by copyOutputFile via
+// writeWorkspaceFile.
+//
+// It overwrites an existing destination and asserts the new content, the
+// 0644 mode, the returned path, and that no temporary file remains next to
+// the destination.
+func TestCopyOutputFileAtomic(t *testing.T) {
+	srcFile := filepath.Join(t.TempDir(), "grype.json")
+	if err := os.WriteFile(srcFile, []byte(`{"matches":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	destDir := t.TempDir()
+	dstFile := filepath.Join(destDir, "results.json")
+	if err := os.WriteFile(dstFile, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := copyOutputFile(srcFile, dstFile, 0)
+	if err != nil {
+		t.Fatalf("copyOutputFile() error = %v", err)
+	}
+	if result != dstFile {
+		t.Errorf("copyOutputFile() = %q, want %q", result, dstFile)
+	}
+	info, err := os.Stat(dstFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(dstFile); string(data) != `{"matches":[]}` {
+		t.Errorf("content = %q, want the copied results", data)
+	}
+
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("destination directory holds %v, want only results.json", names)
+	}
+}
+
+// TestCopyOutputFileMaxSize verifies that a runaway scan cannot fill the
+// runner disk when its results are copied to output-file.
+//
+// This test covers the max-output-size guard of copyOutputFile and
+// parseMaxOutputSize in output.go.
+//
+// It asserts that a file over a 10-byte limit is refused with an error
+// naming max-output-size and nothing is written, that a file within the limit
+// is copied, and that human-readable sizes parse while malformed ones fail.
+func TestCopyOutputFileMaxSize(t *testing.T) {
+	srcFile := filepath.Join(t.TempDir(), "source.json")
+	if err := os.WriteFile(srcFile, []byte(`{"matches":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dstFile := filepath.Join(t.TempDir(), "dest.json")
+
+	if _, err := copyOutputFile(srcFile, dstFile, 10); err == nil || !strings.Contains(err.Error(), "max-output-size") {
+		t.Errorf("copyOutputFile() error = %v, want max-output-size error", err)
+	}
+	if _, err := os.Stat(dstFile); !os.IsNotExist(err) {
+		t.Errorf("oversized results were written to %s", dstFile)
+	}
+	if _, err := copyOutputFile(srcFile, dstFile, 14); err != nil {
+		t.Errorf("copyOutputFile() within limit error = %v", err)
+	}
+
+	sizes := map[string]int64{"": 0, "0": 0, "1024": 1024, "50MB": 50 << 20, "512 kb": 512 << 10, "1GB": 1 << 30, "10B": 10}
+	for value, want := range sizes {
+		if got, err := parseMaxOutputSize(value); err != nil || got != want {
+			t.Errorf("parseMaxOutputSize(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"-1MB", "50TB", "MB", "lots"} {
+		if _, err := parseMaxOutputSize(value); err == nil || !strings.Contains(err.Error(), "max-output-size") {
+			t.Errorf("parseMaxOutputSize(%q) error = %v, want max-output-size error", value, err)
+		}
+	}
+}
+
 func TestValidatePathInWorkspace(t *testing.T) {
 	workspace := "/workspace"
 
@@ -169,7 +586,7 @@ func TestGenerateBadgeURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := generateBadgeURL(tt.stats, tt.label, tt.dbBuilt, tt.scanMode)
+			got := generateBadgeURL(badgeOptions{Host: defaultBadgeHost}, tt.stats, tt.label, tt.dbBuilt, tt.scanMode)
 			for _, substr := range tt.contains {
 				if !strings.Contains(got, substr) {
 					t.Errorf("generateBadgeURL() = %v, want to contain %q", got, substr)
@@ -191,6 +608,8 @@ func TestFormatBadgeMessage(t *testing.T) {
 		{"critical and high", VulnerabilityStats{Total: 5, Critical: 2, High: 3}, "2 critical | 3 high"},
 		{"all severities", VulnerabilityStats{Total: 10, Critical: 1, High: 2, Medium: 3, Low: 4}, "1 critical | 2 high | 3 medium | 4 low"},
 		{"only other", VulnerabilityStats{Total: 5, Other: 5}, "5 other"},
+		{"negligible and other", VulnerabilityStats{Total: 3, Negligible: 2, Other: 1}, "2 negligible | 1 other"},
+		{"negligible hidden behind low", VulnerabilityStats{Total: 3, Low: 1, Negligible: 2}, "1 low"},
 	}
 
 	for _, tt := range tests {
@@ -214,7 +633,9 @@ func TestDetermineBadgeColor(t *testing.T) {
 		{"high", VulnerabilityStats{High: 1}, "orange"},
 		{"medium", VulnerabilityStats{Medium: 1}, "yellow"},
 		{"low", VulnerabilityStats{Low: 1}, "yellowgreen"},
-		{"other", VulnerabilityStats{Other: 1}, "yellowgreen"},
+		{"negligible only", VulnerabilityStats{Negligible: 1}, "lightgrey"},
+		{"unknown only", VulnerabilityStats{Other: 1}, "lightgrey"},
+		{"low takes precedence over negligible", VulnerabilityStats{Low: 1, Negligible: 5}, "yellowgreen"},
 		{"critical takes precedence", VulnerabilityStats{Critical: 1, High: 2, Medium: 3, Low: 4}, "critical"},
 		{"high takes precedence over medium", VulnerabilityStats{High: 1, Medium: 2, Low: 3}, "orange"},
 	}
@@ -229,6 +650,225 @@ func TestDetermineBadgeColor(t *testing.T) {
 	}
 }
 
+// TestBadgeStyleAndColor verifies that README authors can render a neutral,
+// consistently styled badge regardless of the findings.
+//
+// This test covers the Style and Color of badgeOptions in generateBadgeURL,
+// generateBadgeJSON, and generateDBAgeBadgeURL in output.go.
+//
+// It asserts that a forced color replaces the severity color and the style is
+// appended as ?style= to static URLs and as a "style" field to endpoint JSON,
+// that the DB-age badge takes the style but keeps its age color, and that
+// without options the URL carries no query.
+func TestBadgeStyleAndColor(t *testing.T) {
+	stats := VulnerabilityStats{Total: 1, Critical: 1}
+	label := "✊ grype 0.87.0"
+	opts := badgeOptions{Host: defaultBadgeHost, Style: "flat-square", Color: "blue"}
+
+	if got := generateBadgeURL(opts, stats, label, "", "image"); !strings.HasSuffix(got, "-blue?style=flat-square") {
+		t.Errorf("generateBadgeURL() = %q, want forced color and style", got)
+	}
+	if got := generateBadgeURL(badgeOptions{Host: defaultBadgeHost}, stats, label, "", "image"); !strings.HasSuffix(got, "-critical") {
+		t.Errorf("generateBadgeURL() without options = %q, want severity color and no query", got)
+	}
+
+	badgeJSON := generateBadgeJSON(opts, stats, nil, "0.87.0", "", "image", -1)
+	for _, want := range []string{`"color":"blue"`, `"style":"flat-square"`} {
+		if !strings.Contains(badgeJSON, want) {
+			t.Errorf("generateBadgeJSON() = %s, want to contain %s", badgeJSON, want)
+		}
+	}
+
+	if got := generateDBAgeBadgeURL(opts, label, "", time.Now()); !strings.HasSuffix(got, "-lightgrey?style=flat-square") {
+		t.Errorf("generateDBAgeBadgeURL() = %q, want age color with style", got)
+	}
+}
+
+// TestBadgeLabelOverride verifies that README authors can replace the
+// generated badge label with their own wording such as "Security".
+//
+// This test covers the Label of badgeOptions in generateBadgeURL and
+// generateBadgeJSON in output.go, fed from the badge-label input.
+//
+// It asserts that the override replaces the generated label in the static
+// URL, URL-escaped and with shields.io dash and underscore escaping, and
+// verbatim in the endpoint JSON, and that an empty override keeps the
+// generated "✊ grype <version>" label.
+func TestBadgeLabelOverride(t *testing.T) {
+	stats := VulnerabilityStats{Total: 1, High: 1}
+	generated := buildBadgeLabel("0.87.0")
+
+	tests := []struct {
+		name      string
+		label     string
+		wantURL   string
+		wantLabel string
+	}{
+		{"replaces label with override", "Security", defaultBadgeHost + "/badge/Security-", `"label":"Security"`},
+		{"escapes override for static urls", "my-app CVEs_x", defaultBadgeHost + "/badge/my--app%20CVEs__x-", `"label":"my-app CVEs_x"`},
+		{"keeps generated label without override", "", defaultBadgeHost + "/badge/" + url.PathEscape(generated) + "-", `"label":"✊ grype 0.87.0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := badgeOptions{Host: defaultBadgeHost, Label: tt.label}
+			if got := generateBadgeURL(opts, stats, generated, "", "image"); !strings.HasPrefix(got, tt.wantURL) {
+				t.Errorf("generateBadgeURL() = %q, want prefix %q", got, tt.wantURL)
+			}
+			if got := generateBadgeJSON(opts, stats, nil, "0.87.0", "", "image", -1); !strings.Contains(got, tt.wantLabel) {
+				t.Errorf("generateBadgeJSON() = %s, want to contain %s", got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+// TestGenerateBadgeURLCustomHost verifies that air-gapped users with a
+// self-hosted shields instance get badge URLs pointing at their server.
+//
+// This test covers the badge host of generateBadgeURL and
+// generateDBAgeBadgeURL in output.go, fed from the badge-host input.
+//
+// It asserts that the default host yields the exact historical URL and that
+// a custom host only replaces the scheme and host part.
+func TestGenerateBadgeURLCustomHost(t *testing.T) {
+	stats := VulnerabilityStats{Total: 1, High: 1}
+	label := "✊ grype 0.87.0"
+
+	got := generateBadgeURL(badgeOptions{Host: defaultBadgeHost}, stats, label, "2026-01-30", "image")
+	want := "https://img.shields.io/badge/%E2%9C%8A%20grype%200.87.0-db%202026--01--30:%201%20high%20CVEs%20in%20image-orange"
+	if got != want {
+		t.Errorf("generateBadgeURL() with default host = %q, want %q", got, want)
+	}
+
+	custom := "https://badges.internal.example"
+	if got := generateBadgeURL(badgeOptions{Host: custom}, stats, label, "2026-01-30", "image"); got != strings.Replace(want, defaultBadgeHost, custom, 1) {
+		t.Errorf("generateBadgeURL() with custom host = %q", got)
+	}
+	if got := generateDBAgeBadgeURL(badgeOptions{Host: custom}, label, "", time.Now()); !strings.HasPrefix(got, custom+"/badge/") {
+		t.Errorf("generateDBAgeBadgeURL() with custom host = %q", got)
+	}
+}
+
+// TestGenerateDBAgeBadgeURL verifies that a README badge shows at a glance
+// whether results come from a fresh or stale vulnerability database.
+//
+// This test covers generateDBAgeBadgeURL, dbAge, and formatDBAge in output.go,
+// which produce the db-age-badge-url output when db-age-badge is enabled.
+//
+// It fixes "now" and asserts the message ("db 5h old", "db 2d old", ...) and
+// the green/yellow/red color for fresh, aging, and stale databases, plus a
+// lightgrey "unknown" badge when the build time is missing.
+func TestGenerateDBAgeBadgeURL(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	label := "✊ grype 0.106.0"
+
+	tests := []struct {
+		name        string
+		dbBuilt     string
+		wantMessage string
+		wantColor   string
+	}{
+		{"fresh db shows hours in green", "2026-03-10T07:00:00Z", "db%205h%20old", "brightgreen"},
+		{"two day old db is still green", "2026-03-08T12:00:00Z", "db%202d%20old", "brightgreen"},
+		{"aging db is yellow", "2026-03-05T12:00:00Z", "db%205d%20old", "yellow"},
+		{"stale db is red", "2026-02-20T12:00:00Z", "db%2018d%20old", "critical"},
+		{"plain date is accepted", "2026-03-01", "db%209d%20old", "critical"},
+		{"future build time counts as fresh", "2026-03-11T00:00:00Z", "db%200h%20old", "brightgreen"},
+		{"missing build time is unknown", "", "db%20age%20unknown", "lightgrey"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateDBAgeBadgeURL(badgeOptions{Host: defaultBadgeHost}, label, tt.dbBuilt, now)
+			if !strings.HasPrefix(got, "https://img.shields.io/badge/") {
+				t.Errorf("generateDBAgeBadgeURL() = %q, want shields.io static badge", got)
+			}
+			if !strings.HasSuffix(got, "-"+tt.wantMessage+"-"+tt.wantColor) {
+				t.Errorf("generateDBAgeBadgeURL() = %q, want message %q and color %q", got, tt.wantMessage, tt.wantColor)
+			}
+		})
+	}
+}
+
+// TestDBFreshnessOutputs verifies that downstream steps can tell when scan
+// results come from a vulnerability database too old to trust.
+//
+// This test covers dbFreshnessOutputs and parseDBStaleAge in output.go,
+// which back the db-stale and db-age-days outputs.
+//
+// It fixes "now" and asserts db-stale "false" for a fresh database and
+// "true" for one older than the 7d default, no outputs for a missing build
+// time, and that "7d" and Go durations parse while malformed values fail.
+func TestDBFreshnessOutputs(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		dbBuilt   string
+		wantStale string
+		wantDays  string
+	}{
+		{"fresh db", "2026-03-08T06:00:00Z", "false", "2"},
+		{"db at the threshold is fresh", "2026-03-03T12:00:00Z", "false", "7"},
+		{"stale db", "2026-02-20T12:00:00Z", "true", "18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stale := dbFreshnessOutputs(tt.dbBuilt, dbAgeStale, now)
+			if got["db-stale"] != tt.wantStale || got["db-age-days"] != tt.wantDays || stale != (tt.wantStale == "true") {
+				t.Errorf("dbFreshnessOutputs() = %v, %v, want db-stale=%s db-age-days=%s", got, stale, tt.wantStale, tt.wantDays)
+			}
+		})
+	}
+
+	if got, stale := dbFreshnessOutputs("", dbAgeStale, now); got != nil || stale {
+		t.Errorf("dbFreshnessOutputs() without build time = %v, %v, want no outputs", got, stale)
+	}
+
+	for value, want := range map[string]time.Duration{"": dbAgeStale, "3d": 72 * time.Hour, "36h": 36 * time.Hour} {
+		if got, err := parseDBStaleAge(value); err != nil || got != want {
+			t.Errorf("parseDBStaleAge(%q) = %s, %v, want %s", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"0d", "-1h", "week", "1.5d"} {
+		if _, err := parseDBStaleAge(value); err == nil || !strings.Contains(err.Error(), "db-stale-age") {
+			t.Errorf("parseDBStaleAge(%q) error = %v, want db-stale-age error", value, err)
+		}
+	}
+}
+
+// TestStaleDBError verifies that orgs mandating a fresh vulnerability
+// database can fail the run on a stale one, whatever the findings.
+//
+// This test covers staleDBError in output.go, used by processResults when
+// fail-on-stale-db is set.
+//
+// It fixes "now" and asserts no error for a fresh database, a policyError
+// naming the DB date and the allowed age for a stale one, and an error for a
+// database without a build time.
+func TestStaleDBError(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if err := staleDBError("2026-03-08T06:00:00Z", dbAgeStale, now); err != nil {
+		t.Errorf("staleDBError() for fresh db = %v, want nil", err)
+	}
+
+	err := staleDBError("2026-02-20T12:00:00Z", dbAgeStale, now)
+	var policyErr *policyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("staleDBError() for stale db = %v, want policyError", err)
+	}
+	for _, want := range []string{"2026-02-20", "18d old", "db-stale-age 7d"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("staleDBError() = %q, want it to contain %q", err, want)
+		}
+	}
+
+	if err := staleDBError("", dbAgeStale, now); err == nil || !strings.Contains(err.Error(), "no build time") {
+		t.Errorf("staleDBError() without build time = %v, want no build time error", err)
+	}
+}
+
 func TestBuildBadgeLabel(t *testing.T) {
 	tests := []struct {
 		grypeVersion string
@@ -307,7 +947,7 @@ func TestGenerateBadgeJSON(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := generateBadgeJSON(tt.stats, tt.version, tt.dbBuilt, tt.scanMode)
+			got := generateBadgeJSON(badgeOptions{}, tt.stats, nil, tt.version, tt.dbBuilt, tt.scanMode, -1)
 			for _, substr := range tt.contains {
 				if !strings.Contains(got, substr) {
 					t.Errorf("generateBadgeJSON() = %v, want to contain %q", got, substr)
@@ -317,6 +957,59 @@ func TestGenerateBadgeJSON(t *testing.T) {
 	}
 }
 
+// TestBadgeTrend verifies that the gist badge shows whether findings went up
+// or down since the previous published scan.
+//
+// This test covers formatBadgeMessageWithDelta, formatDelta,
+// parseBadgeCounts, and the "counts" field of generateBadgeJSON in output.go.
+//
+// It asserts ▲ and ▼ arrows for increased and decreased counts, no arrow for
+// unchanged counts or without a previous scan, "0 (▼n)" for a clean scan
+// after findings, and that the stored counts parse back to the same stats
+// while badges without counts yield no previous stats.
+func TestBadgeTrend(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    VulnerabilityStats
+		previous *VulnerabilityStats
+		want     string
+	}{
+		{"up arrow for more findings", VulnerabilityStats{Total: 3, Critical: 3}, &VulnerabilityStats{Total: 2, Critical: 2}, "3 critical (▲1)"},
+		{"down arrow for fewer findings", VulnerabilityStats{Total: 1, High: 1}, &VulnerabilityStats{Total: 3, High: 3}, "1 high (▼2)"},
+		{"no arrow when unchanged", VulnerabilityStats{Total: 2, Critical: 1, Low: 1}, &VulnerabilityStats{Total: 2, Critical: 1, Low: 1}, "1 critical | 1 low"},
+		{"mixed arrows per severity", VulnerabilityStats{Total: 3, Critical: 1, High: 2}, &VulnerabilityStats{Total: 5, High: 5}, "1 critical (▲1) | 2 high (▼3)"},
+		{"clean scan after findings", VulnerabilityStats{}, &VulnerabilityStats{Total: 5, Medium: 5}, "0 (▼5)"},
+		{"no arrow without previous scan", VulnerabilityStats{Total: 3, Critical: 3}, nil, "3 critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatBadgeMessageWithDelta(tt.stats, tt.previous); got != tt.want {
+				t.Errorf("formatBadgeMessageWithDelta() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("stores counts for the next run", func(t *testing.T) {
+		stats := VulnerabilityStats{Total: 6, Critical: 1, High: 1, Medium: 1, Low: 1, Negligible: 1, Other: 1}
+		badgeJSON := generateBadgeJSON(badgeOptions{}, stats, &VulnerabilityStats{Total: 5, Critical: 0}, "0.87.0", "", "image", -1)
+		if !strings.Contains(badgeJSON, "1 critical (▲1)") {
+			t.Errorf("generateBadgeJSON() = %s, want trend in message", badgeJSON)
+		}
+		got, ok := parseBadgeCounts(badgeJSON)
+		if !ok || got != stats {
+			t.Errorf("parseBadgeCounts() = %+v, %v, want %+v", got, ok, stats)
+		}
+	})
+
+	t.Run("ignores badges without counts", func(t *testing.T) {
+		for _, badgeJSON := range []string{`{"schemaVersion":1,"message":"0 CVEs"}`, "not json"} {
+			if _, ok := parseBadgeCounts(badgeJSON); ok {
+				t.Errorf("parseBadgeCounts(%q) ok = true, want false", badgeJSON)
+			}
+		}
+	})
+}
+
 func TestGenerateReport(t *testing.T) {
 	output := &GrypeOutput{
 		Matches: []GrypeMatch{
@@ -328,10 +1021,10 @@ func TestGenerateReport(t *testing.T) {
 	output.Descriptor.Version = "0.87.0"
 	output.Descriptor.DB.Status.Built = "2026-02-15T08:00:00Z"
 
-	stats := VulnerabilityStats{Total: 3, Critical: 1, High: 1, Low: 1}
+	stats := VulnerabilityStats{Total: 3, Critical: 1, High: 1, Low: 1, Fixable: 2}
 	fixedTime := time.Date(2026, 2, 15, 10, 30, 0, 0, time.UTC)
 	description := "Nightly release scan for **core services**"
-	report := generateReportAt(output, stats, "release", description, fixedTime)
+	report := generateReportAt(output, stats, "release", reportOptions{Description: description}, fixedTime)
 
 	checks := []struct {
 		desc string
@@ -345,6 +1038,7 @@ func TestGenerateReport(t *testing.T) {
 		{"scan timestamp", "2026-02-15 10:30 UTC"},
 		{"critical count", "| Critical | 1 |"},
 		{"total count", "| **Total** | **3** |"},
+		{"fixable count", "| Fixable (fix available, within total) | 2 |"},
 		{"CVE ID", "CVE-2024-0001"},
 		{"package name", "openssl"},
 		{"fix version", "1.1.2"},
@@ -367,6 +1061,219 @@ func TestGenerateReport(t *testing.T) {
 	}
 }
 
+// TestGenerateReportScanTimestamp verifies that archived or re-rendered
+// reports show when grype actually scanned, not when the report was built.
+//
+// This test covers scanTime and the "Scanned:" line of generateReportAt in
+// output.go.
+//
+// It asserts that an RFC3339 descriptor timestamp with an offset is shown in
+// UTC instead of the injected time, and that a missing or malformed timestamp
+// falls back to the injected time.
+func TestGenerateReportScanTimestamp(t *testing.T) {
+	injected := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("prefers descriptor timestamp", func(t *testing.T) {
+		output := &GrypeOutput{}
+		output.Descriptor.Timestamp = "2026-02-15T10:30:45.123456789+02:00"
+		report := generateReportAt(output, VulnerabilityStats{}, "image", reportOptions{}, injected)
+		if !strings.Contains(report, "**Scanned:** 2026-02-15 08:30 UTC") {
+			t.Errorf("report does not show descriptor timestamp:\n%s", report)
+		}
+	})
+
+	for name, timestamp := range map[string]string{
+		"falls back without timestamp":      "",
+		"falls back on malformed timestamp": "yesterday",
+	} {
+		t.Run(name, func(t *testing.T) {
+			output := &GrypeOutput{}
+			output.Descriptor.Timestamp = timestamp
+			report := generateReportAt(output, VulnerabilityStats{}, "image", reportOptions{}, injected)
+			if !strings.Contains(report, "**Scanned:** 2026-03-01 12:00 UTC") {
+				t.Errorf("report does not show injected time:\n%s", report)
+			}
+		})
+	}
+}
+
+// TestGenerateReportDescription verifies that the description input shows up
+// as written in the report, without stray whitespace from YAML block scalars.
+//
+// This test covers the Description handling of generateReportAt in output.go.
+//
+// It asserts that a description padded with newlines is rendered trimmed as
+// its own Markdown line directly above the scan mode, and that an empty or
+// whitespace-only description adds no Description line at all.
+func TestGenerateReportDescription(t *testing.T) {
+	output := &GrypeOutput{}
+	fixedTime := time.Date(2026, 2, 15, 10, 30, 0, 0, time.UTC)
+
+	t.Run("renders trimmed description above scan mode", func(t *testing.T) {
+		report := generateReportAt(output, VulnerabilityStats{}, "head", reportOptions{Description: "\n  Nightly *core* scan \n\n"}, fixedTime)
+		want := "**Description:** Nightly *core* scan  \n**Scan mode:** head"
+		if !strings.Contains(report, want) {
+			t.Errorf("report = %q, want to contain %q", report, want)
+		}
+	})
+
+	for name, description := range map[string]string{
+		"omits section for empty description":           "",
+		"omits section for whitespace-only description": " \n\t ",
+	} {
+		t.Run(name, func(t *testing.T) {
+			report := generateReportAt(output, VulnerabilityStats{}, "head", reportOptions{Description: description}, fixedTime)
+			if strings.Contains(report, "Description:") {
+				t.Errorf("report = %q, want no Description line", report)
+			}
+		})
+	}
+}
+
+// TestGenerateReportMinSeverity verifies that a linked report can list only
+// the findings worth reading while the badge and summary still count all.
+//
+// This test covers the MinSeverity option of generateReportAt in output.go
+// and filterMatchesByMinSeverity in scanner.go, set by report-min-severity.
+//
+// It renders a report with critical, medium, and low matches at min severity
+// medium and asserts that the low row is omitted with a note naming one
+// hidden finding placed before the table so the rows stay part of it, while
+// the summary still shows all three and the low count.
+func TestGenerateReportMinSeverity(t *testing.T) {
+	fixedTime := time.Date(2026, 2, 15, 10, 30, 0, 0, time.UTC)
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-MED", "Medium", "curl", "8.0.0", nil, "", ""),
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "", ""),
+	}}
+
+	report := generateReportAt(output, calculateStats(output, "", false), "image", reportOptions{MinSeverity: "medium"}, fixedTime)
+
+	for _, want := range []string{
+		"| CVE-CRIT | Critical |",
+		"| CVE-MED | Medium |",
+		"| Low | 1 |",
+		"| **Total** | **3** |",
+		"Showing medium severity and above; 1 lower-severity findings are only counted in the summary.",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "CVE-LOW") {
+		t.Errorf("report lists CVE-LOW below the minimum severity:\n%s", report)
+	}
+	delimiter := "|-----|----------|-----:|---------|-----------|-------|-------------|--------|\n"
+	if i := strings.Index(report, delimiter); i < 0 || !strings.HasPrefix(report[i+len(delimiter):], "| CVE-") {
+		t.Errorf("vulnerability table delimiter row must be followed directly by a data row:\n%s", report)
+	}
+	if note, table := strings.Index(report, "Showing medium"), strings.Index(report, "| CVE | Severity |"); note > table {
+		t.Errorf("min-severity note should precede the vulnerability table:\n%s", report)
+	}
+
+	if full := generateReportAt(output, calculateStats(output, "", false), "image", reportOptions{}, fixedTime); !strings.Contains(full, "CVE-LOW") || strings.Contains(full, "Showing ") {
+		t.Errorf("report without min severity should list every finding without a note:\n%s", full)
+	}
+}
+
+// TestGenerateReportCVELinkTemplate verifies that teams mirroring NVD in an
+// internal tracker can link each report row there instead.
+//
+// This test covers the CVELinkTemplate option of generateReportAt and
+// vulnerabilityLink and validateCVELinkTemplate in output.go, set by
+// cve-link-template.
+//
+// It renders a report with and without a data source and asserts that the
+// template replaces {id} for both rows, that without a template grype's data
+// source is linked and a row without one has no link, and that templates
+// without {id} or an http(s) URL are rejected.
+func TestGenerateReportCVELinkTemplate(t *testing.T) {
+	fixedTime := time.Date(2026, 2, 15, 10, 30, 0, 0, time.UTC)
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-2024-1", "Critical", "openssl", "3.0.0", nil, "", "https://nvd.nist.gov/vuln/detail/CVE-2024-1"),
+		makeMatch("GHSA-abcd-efgh-ijkl", "High", "curl", "8.0.0", nil, "", ""),
+	}}
+	stats := calculateStats(output, "", false)
+
+	report := generateReportAt(output, stats, "image", reportOptions{CVELinkTemplate: "https://intra/vuln/{id}"}, fixedTime)
+	for _, want := range []string{"[link](https://intra/vuln/CVE-2024-1)", "[link](https://intra/vuln/GHSA-abcd-efgh-ijkl)"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "nvd.nist.gov") {
+		t.Errorf("report links grype's data source despite a template:\n%s", report)
+	}
+
+	report = generateReportAt(output, stats, "image", reportOptions{}, fixedTime)
+	if !strings.Contains(report, "[link](https://nvd.nist.gov/vuln/detail/CVE-2024-1)") || strings.Count(report, "[link](") != 1 {
+		t.Errorf("report without template should link only grype's data source:\n%s", report)
+	}
+
+	for template, wantErr := range map[string]bool{
+		"":                         false,
+		"https://intra/vuln/{id}":  false,
+		"https://intra/vuln/":      true,
+		"javascript:alert('{id}')": true,
+		"intra/vuln/{id}":          true,
+	} {
+		if err := validateCVELinkTemplate(template); (err != nil) != wantErr {
+			t.Errorf("validateCVELinkTemplate(%q) error = %v, want error = %v", template, err, wantErr)
+		}
+	}
+}
+
+// TestGenerateReportNotFixedSection verifies that security teams see the
+// findings that need mitigation rather than an upgrade in their own section.
+//
+// This test covers writeNotFixedSection and the "Without fix" summary row of
+// generateReportAt in output.go.
+//
+// It asserts a collapsed <details> section listing the wont-fix and not-fixed
+// matches with their fix state, most severe first, while fixable and
+// unknown-state matches stay out of it, and that reports without such
+// matches have no section.
+func TestGenerateReportNotFixedSection(t *testing.T) {
+	fixedTime := time.Date(2026, 2, 15, 10, 30, 0, 0, time.UTC)
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		withFixState(makeMatch("CVE-LOW", "Low", "bash", "5.1", nil, "", ""), "wont-fix"),
+		makeMatch("CVE-FIX", "Critical", "openssl", "3.0.0", []string{"3.0.1"}, "", ""),
+		withFixState(makeMatch("CVE-HIGH", "High", "glibc", "2.36", nil, "", ""), "not-fixed"),
+		withFixState(makeMatch("CVE-UNK", "Medium", "curl", "8.0.0", nil, "", ""), "unknown"),
+	}}
+
+	report := generateReportAt(output, calculateStats(output, "", false), "image", reportOptions{}, fixedTime)
+	start := strings.Index(report, "<details>\n<summary>Without a fix (2)")
+	end := strings.Index(report, "</details>")
+	if start < 0 || end < start {
+		t.Fatalf("report has no not-fixed section:\n%s", report)
+	}
+	section := report[start:end]
+
+	if !strings.Contains(report, "| Without fix (not-fixed/wont-fix, within total) | 2 |") {
+		t.Errorf("report summary missing without-fix row:\n%s", report)
+	}
+	for _, want := range []string{"| CVE-HIGH | High | glibc | 2.36 | not-fixed |", "| CVE-LOW | Low | bash | 5.1 | wont-fix |"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("not-fixed section missing %q:\n%s", want, section)
+		}
+	}
+	if strings.Index(section, "CVE-HIGH") > strings.Index(section, "CVE-LOW") {
+		t.Errorf("not-fixed section should list CVE-HIGH before CVE-LOW:\n%s", section)
+	}
+	for _, unwanted := range []string{"CVE-FIX", "CVE-UNK"} {
+		if strings.Contains(section, unwanted) {
+			t.Errorf("not-fixed section should not list %s:\n%s", unwanted, section)
+		}
+	}
+
+	fixable := &GrypeOutput{Matches: []GrypeMatch{output.Matches[1]}}
+	if report := generateReportAt(fixable, calculateStats(fixable, "", false), "image", reportOptions{}, fixedTime); strings.Contains(report, "<details>") {
+		t.Errorf("report without not-fixed matches should have no section:\n%s", report)
+	}
+}
+
 func TestGenerateReport_NoVulnerabilities(t *testing.T) {
 	output := &GrypeOutput{Matches: []GrypeMatch{}}
 	output.Descriptor.Version = "0.87.0"
@@ -374,7 +1281,7 @@ func TestGenerateReport_NoVulnerabilities(t *testing.T) {
 
 	stats := VulnerabilityStats{Total: 0}
 	fixedTime := time.Date(2026, 2, 15, 10, 30, 0, 0, time.UTC)
-	report := generateReportAt(output, stats, "head", "", fixedTime)
+	report := generateReportAt(output, stats, "head", reportOptions{}, fixedTime)
 
 	if !strings.Contains(report, "No vulnerabilities found") {
 		t.Error("report should indicate no vulnerabilities")
@@ -405,6 +1312,264 @@ func TestSortMatches(t *testing.T) {
 	}
 }
 
+// TestSortMatchesByEPSS verifies that among equally severe findings the ones
+// most likely to be exploited are listed first, so triage starts there.
+//
+// This test covers the EPSS tie-break in sortMatches in output.go, used by
+// the Markdown report and the per-finding exports.
+//
+// It sorts High matches with different, missing, and multiple EPSS scores and
+// asserts descending EPSS order (using the highest score per match), with
+// unscored matches last ordered by CVE ID, and severity still taking precedence.
+func TestSortMatchesByEPSS(t *testing.T) {
+	withEPSS := func(m GrypeMatch, scores ...float64) GrypeMatch {
+		for _, s := range scores {
+			m.Vulnerability.EPSS = append(m.Vulnerability.EPSS, EPSSScore{EPSS: s, Percentile: s})
+		}
+		return m
+	}
+
+	matches := []GrypeMatch{
+		makeMatch("CVE-0005", "High", "pkg", "1.0", nil, "", ""),
+		withEPSS(makeMatch("CVE-0004", "High", "pkg", "1.0", nil, "", ""), 0.10),
+		withEPSS(makeMatch("CVE-0003", "High", "pkg", "1.0", nil, "", ""), 0.02, 0.90),
+		makeMatch("CVE-0002", "High", "pkg", "1.0", nil, "", ""),
+		withEPSS(makeMatch("CVE-0001", "Medium", "pkg", "1.0", nil, "", ""), 0.99),
+	}
+
+	var ids []string
+	for _, m := range sortMatches(matches) {
+		ids = append(ids, m.Vulnerability.ID)
+	}
+
+	want := "CVE-0003,CVE-0004,CVE-0002,CVE-0005,CVE-0001"
+	if got := strings.Join(ids, ","); got != want {
+		t.Errorf("sortMatches() order = %s, want %s", got, want)
+	}
+}
+
+// TestSortMatchesByPackage verifies that remediation-focused reports list all
+// CVEs of one package together, most severe first.
+//
+// This test covers sortMatchesBy in output.go and its use by buildReportRows
+// when report-sort is "package".
+//
+// It sorts matches of two packages with mixed severities and asserts that
+// they are grouped by package name with severity order inside each group,
+// that the default key keeps severity-first order, and that the rendered
+// report follows the package grouping.
+func TestSortMatchesByPackage(t *testing.T) {
+	matches := []GrypeMatch{
+		makeMatch("CVE-0001", "Low", "zlib", "1.0", nil, "", ""),
+		makeMatch("CVE-0002", "Critical", "zlib", "1.0", nil, "", ""),
+		makeMatch("CVE-0003", "High", "openssl", "3.0", nil, "", ""),
+		makeMatch("CVE-0004", "Medium", "openssl", "3.0", nil, "", ""),
+	}
+	ids := func(sorted []GrypeMatch) string {
+		var out []string
+		for _, m := range sorted {
+			out = append(out, m.Vulnerability.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got, want := ids(sortMatchesBy(matches, "package")), "CVE-0003,CVE-0004,CVE-0002,CVE-0001"; got != want {
+		t.Errorf("sortMatchesBy(package) = %s, want %s", got, want)
+	}
+	if got, want := ids(sortMatchesBy(matches, "severity")), "CVE-0002,CVE-0003,CVE-0004,CVE-0001"; got != want {
+		t.Errorf("sortMatchesBy(severity) = %s, want %s", got, want)
+	}
+
+	output := &GrypeOutput{Matches: matches}
+	report := generateReportAt(output, calculateStats(output, "", false), "image", reportOptions{Sort: "package"}, time.Now())
+	if strings.Index(report, "| CVE-0004 |") > strings.Index(report, "| CVE-0002 |") {
+		t.Errorf("package-sorted report should list all openssl rows before zlib:\n%s", report)
+	}
+}
+
+// TestTopCVEOutputs verifies that dashboards can show a headline "worst CVE"
+// straight from the step outputs, and get empty values on a clean scan.
+//
+// This test covers topMatch and the top-cve-* outputs of setOutputs in
+// output.go.
+//
+// It writes outputs for a scan without matches and asserts the three top-cve
+// outputs are present but empty, then for a mixed scan and asserts they name
+// the Critical finding with the highest EPSS score and its package.
+func TestTopCVEOutputs(t *testing.T) {
+	withEPSS := func(m GrypeMatch, score float64) GrypeMatch {
+		m.Vulnerability.EPSS = []EPSSScore{{EPSS: score, Percentile: score}}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		matches []GrypeMatch
+		want    []string
+	}{
+		{"emits empty values without matches", nil, []string{"top-cve-id=\n", "top-cve-severity=\n", "top-cve-package=\n"}},
+		{"names the most severe match with the highest EPSS", []GrypeMatch{
+			makeMatch("CVE-0001", "High", "libfoo", "1.0", nil, "", ""),
+			withEPSS(makeMatch("CVE-0002", "Critical", "libbar", "2.0", nil, "", ""), 0.10),
+			withEPSS(makeMatch("CVE-0003", "Critical", "openssl", "3.0", nil, "", ""), 0.90),
+		}, []string{"top-cve-id=CVE-0003\n", "top-cve-severity=Critical\n", "top-cve-package=openssl\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outFile := filepath.Join(t.TempDir(), "github_output.txt")
+			t.Setenv("GITHUB_OUTPUT", outFile)
+
+			output := &GrypeOutput{Matches: tt.matches}
+			if err := setOutputs(calculateStats(output, "", false), output, scanRunInfo{}, "", "image", badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil); err != nil {
+				t.Fatalf("setOutputs() error = %v", err)
+			}
+
+			content, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("failed to read output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("outputs missing %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateReportEPSSColumn verifies that report readers see each
+// finding's exploit prediction score next to its severity.
+//
+// This test covers the EPSS column of generateReportAt and formatEPSS in
+// output.go, with EPSS data parsed from Grype JSON into types.go structures.
+//
+// It parses a Grype match carrying an epss array and asserts the table header
+// has an EPSS column, the scored row shows score and percentile, and an
+// unscored row shows "—".
+func TestGenerateReportEPSSColumn(t *testing.T) {
+	var scored GrypeMatch
+	raw := `{"vulnerability":{"id":"CVE-2026-1111","severity":"High","epss":[{"cve":"CVE-2026-1111","epss":0.97341,"percentile":0.99812,"date":"2026-03-01"}]},"artifact":{"name":"openssl","version":"3.0.0"}}`
+	if err := json.Unmarshal([]byte(raw), &scored); err != nil {
+		t.Fatalf("failed to parse match: %v", err)
+	}
+
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		scored,
+		makeMatch("CVE-2026-2222", "Low", "zlib", "1.2.11", nil, "", ""),
+	}}
+	stats := calculateStats(output, "", false)
+	report := generateReportAt(output, stats, "image", reportOptions{}, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC))
+
+	checks := []string{
+		"| CVE | Severity | EPSS | Package |",
+		"| CVE-2026-1111 | High | 0.973 (p99.8) | openssl |",
+		"| CVE-2026-2222 | Low | — | zlib |",
+	}
+	for _, want := range checks {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+// TestGenerateReportCollapseVersions verifies that images bundling one
+// library at two versions show a CVE once, listing both versions, when
+// collapse-versions is enabled.
+//
+// This test covers buildReportRows and collapseVersions in output.go, the
+// data-shaping stage of generateReportAt.
+//
+// It reports one CVE on one package at versions 1.0 and 1.1 plus an unrelated
+// CVE and asserts the collapsed report has a single row listing "1.0, 1.1"
+// with merged fix versions, while the default report keeps two rows.
+func TestGenerateReportCollapseVersions(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-2026-0001", "High", "libxml2", "1.0", []string{"1.2"}, "", ""),
+		makeMatch("CVE-2026-0001", "High", "libxml2", "1.1", []string{"1.2", "1.1.5"}, "", ""),
+		makeMatch("CVE-2026-0002", "Low", "libxml2", "1.0", nil, "", ""),
+	}}
+	stats := calculateStats(output, "", false)
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	collapsed := generateReportAt(output, stats, "image", reportOptions{CollapseVersions: true}, now)
+	if got := strings.Count(collapsed, "| CVE-2026-0001 |"); got != 1 {
+		t.Errorf("collapsed report has %d CVE-2026-0001 rows, want 1:\n%s", got, collapsed)
+	}
+	if !strings.Contains(collapsed, "| libxml2 | 1.0, 1.1 | 1.2, 1.1.5 |") {
+		t.Errorf("collapsed row should list both versions and merged fixes:\n%s", collapsed)
+	}
+	if !strings.Contains(collapsed, "| CVE-2026-0002 |") {
+		t.Errorf("unrelated CVE should still be reported:\n%s", collapsed)
+	}
+
+	expanded := generateReportAt(output, stats, "image", reportOptions{}, now)
+	if got := strings.Count(expanded, "| CVE-2026-0001 |"); got != 2 {
+		t.Errorf("default report has %d CVE-2026-0001 rows, want 2", got)
+	}
+	if len(output.Matches[0].Vulnerability.Fix.Versions) != 1 {
+		t.Error("collapsing should not modify the input matches")
+	}
+}
+
+// TestGenerateReportMaxRows verifies that scans with thousands of findings
+// still produce a report small enough for a gist, while the summary keeps
+// the full totals.
+//
+// This test covers the report-max-rows truncation in generateReportAt,
+// parseReportMaxRows, and formatThousands in output.go.
+//
+// It reports 1,240 Low matches plus one Critical with a limit of 5 and
+// asserts only 5 rows are rendered with the Critical first, that the omitted
+// rows are announced as "… and 1,236 more", and that the summary still shows
+// the full total. It also asserts the input parsing defaults and rejections.
+func TestGenerateReportMaxRows(t *testing.T) {
+	matches := []GrypeMatch{makeMatch("CVE-2026-9999", "Critical", "openssl", "3.0", nil, "", "")}
+	for i := 0; i < 1240; i++ {
+		matches = append(matches, makeMatch(fmt.Sprintf("CVE-2026-%04d", i), "Low", "pkg", "1.0", nil, "", ""))
+	}
+	output := &GrypeOutput{Matches: matches}
+	stats := calculateStats(output, "", false)
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	report := generateReportAt(output, stats, "image", reportOptions{MaxRows: 5}, now)
+	if got := strings.Count(report, "| CVE-2026-"); got != 5 {
+		t.Errorf("report has %d CVE rows, want 5", got)
+	}
+	if !strings.Contains(report, "| CVE-2026-9999 | Critical |") {
+		t.Errorf("most severe finding should survive truncation:\n%s", report)
+	}
+	if !strings.Contains(report, "… and 1,236 more (see full JSON)") {
+		t.Errorf("report should announce omitted rows:\n%s", report)
+	}
+	if !strings.Contains(report, "| **Total** | **1241** |") {
+		t.Errorf("summary should keep the full total:\n%s", report)
+	}
+
+	unlimited := generateReportAt(output, stats, "image", reportOptions{}, now)
+	if strings.Contains(unlimited, "more (see full JSON)") {
+		t.Error("report without a limit should not be truncated")
+	}
+
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"", defaultReportMaxRows, false},
+		{"0", 0, false},
+		{"50", 50, false},
+		{"-1", 0, true},
+		{"many", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseReportMaxRows(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseReportMaxRows(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -464,3 +1629,195 @@ func makeMatch(id, severity, pkg, version string, fixVersions []string, descript
 	m.Artifact.Version = version
 	return m
 }
+
+// withFixState returns m with its fix state replaced by state.
+func withFixState(m GrypeMatch, state string) GrypeMatch {
+	m.Vulnerability.Fix.State = state
+	return m
+}
+
+// TestFindingHash verifies that every finding gets an identifier that stays
+// the same across runs, so downstream ticketing systems can deduplicate
+// findings instead of opening a new ticket on every scan.
+//
+// This test covers findingHash in output.go, used by per-finding exports when
+// include-finding-hash is enabled.
+//
+// It asserts that identical findings hash identically (even if severity or
+// description change), that changing any identifying field changes the hash,
+// and that the result is a 64-character hex SHA-256 digest.
+func TestFindingHash(t *testing.T) {
+	base := makeMatch("CVE-2024-0001", "High", "openssl", "1.1.1", nil, "desc", "")
+	base.Artifact.Type = "deb"
+
+	same := makeMatch("CVE-2024-0001", "Critical", "openssl", "1.1.1", []string{"1.1.2"}, "updated desc", "")
+	same.Artifact.Type = "deb"
+
+	if got, want := findingHash(same), findingHash(base); got != want {
+		t.Errorf("findingHash() for the same finding = %q, want %q", got, want)
+	}
+	if got := findingHash(base); len(got) != 64 || strings.Trim(got, "0123456789abcdef") != "" {
+		t.Errorf("findingHash() = %q, want 64 lower-case hex characters", got)
+	}
+
+	variants := map[string]func(m *GrypeMatch){
+		"different CVE":     func(m *GrypeMatch) { m.Vulnerability.ID = "CVE-2024-0002" },
+		"different package": func(m *GrypeMatch) { m.Artifact.Name = "libssl" },
+		"different version": func(m *GrypeMatch) { m.Artifact.Version = "1.1.2" },
+		"different type":    func(m *GrypeMatch) { m.Artifact.Type = "apk" },
+	}
+	for name, mutate := range variants {
+		t.Run(name, func(t *testing.T) {
+			other := base
+			mutate(&other)
+			if findingHash(other) == findingHash(base) {
+				t.Errorf("findingHash() should differ for %s", name)
+			}
+		})
+	}
+}
+
+// TestWriteCSV verifies that users feeding a spreadsheet pipeline get a CSV
+// export with one row per vulnerability, most severe first.
+//
+// This test covers writeCSV in output.go, which backs the csv-file input.
+//
+// It writes a CSV for unsorted matches and asserts the header, the
+// severity-first row order, semicolon-joined fix versions, and that the
+// optional FindingHash column carries findingHash values.
+func TestWriteCSV(t *testing.T) {
+	low := makeMatch("CVE-2024-0003", "Low", "zlib", "1.2.11", []string{"1.2.12", "1.2.13"}, "", "https://nvd.nist.gov/vuln/detail/CVE-2024-0003")
+	low.Artifact.Type = "deb"
+	crit := makeMatch("CVE-2024-0001", "Critical", "openssl", "1.1.1", nil, "", "")
+	crit.Artifact.Type = "deb"
+	output := &GrypeOutput{Matches: []GrypeMatch{low, crit}}
+
+	t.Run("writes sorted rows", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out", "vulns.csv")
+		if err := writeCSV(output, path, false); err != nil {
+			t.Fatalf("writeCSV() error = %v", err)
+		}
+
+		records := readCSVFile(t, path)
+		if len(records) != 3 {
+			t.Fatalf("expected header + 2 rows, got %d records", len(records))
+		}
+		if got := strings.Join(records[0], ","); got != "CVE,Severity,Package,Version,Type,FixState,FixVersions,DataSource" {
+			t.Errorf("header = %q", got)
+		}
+		if records[1][0] != "CVE-2024-0001" || records[2][0] != "CVE-2024-0003" {
+			t.Errorf("rows not sorted by severity: %v", records[1:])
+		}
+		if records[2][5] != "fixed" || records[2][6] != "1.2.12;1.2.13" {
+			t.Errorf("fix columns = %q/%q, want fixed/1.2.12;1.2.13", records[2][5], records[2][6])
+		}
+	})
+
+	t.Run("appends finding hash column", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "vulns.csv")
+		if err := writeCSV(output, path, true); err != nil {
+			t.Fatalf("writeCSV() error = %v", err)
+		}
+
+		records := readCSVFile(t, path)
+		if records[0][len(records[0])-1] != "FindingHash" {
+			t.Errorf("header = %v, want trailing FindingHash column", records[0])
+		}
+		if got, want := records[1][len(records[1])-1], findingHash(crit); got != want {
+			t.Errorf("FindingHash = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("rejects path outside workspace", func(t *testing.T) {
+		t.Setenv("GITHUB_WORKSPACE", t.TempDir())
+		if _, err := os.Stat("/github/workspace"); err == nil {
+			t.Skip("/github/workspace exists on this host")
+		}
+		if err := writeCSV(output, "../escape.csv", false); err == nil {
+			t.Fatal("writeCSV() should reject a path outside the workspace")
+		}
+	})
+}
+
+// readCSVFile is a test helper that parses a CSV file into records.
+func readCSVFile(t *testing.T, path string) [][]string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open CSV: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	return records
+}
+
+// TestWriteSummaryJSON verifies that dashboards can read the scan's headline
+// numbers from a small JSON file instead of the full Grype output.
+//
+// This test covers writeSummaryJSON in output.go, which backs the
+// summary-file input.
+//
+// It writes a summary and asserts the file is valid JSON with the Grype
+// version, DB date, scan mode, every VulnerabilityStats count including the
+// distinct and fix-state counts, and a matching bySeverity map.
+func TestWriteSummaryJSON(t *testing.T) {
+	output := &GrypeOutput{}
+	output.Descriptor.Version = "0.106.0"
+	output.Descriptor.DB.Status.Built = "2026-03-08T08:00:00Z"
+	stats := VulnerabilityStats{Total: 9, Distinct: 7, Critical: 1, High: 2, Medium: 3, Low: 2, Other: 1, Fixable: 4, NotFixed: 3}
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := writeSummaryJSON(output, stats, "release", path); err != nil {
+		t.Fatalf("writeSummaryJSON() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	var got scanSummary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("summary is not valid JSON: %v\n%s", err, data)
+	}
+
+	if got.GrypeVersion != "0.106.0" || got.DBDate != "2026-03-08" || got.ScanMode != "release" {
+		t.Errorf("metadata = %q/%q/%q, want 0.106.0/2026-03-08/release", got.GrypeVersion, got.DBDate, got.ScanMode)
+	}
+	if got.Total != 9 || got.Distinct != 7 || got.Critical != 1 || got.High != 2 || got.Medium != 3 || got.Low != 2 || got.Other != 1 || got.Fixable != 4 || got.NotFixed != 3 {
+		t.Errorf("counts = %+v, want %+v", got, stats)
+	}
+	for severity, want := range stats.BySeverity() {
+		if got.BySeverity[severity] != want {
+			t.Errorf("bySeverity[%s] = %d, want %d", severity, got.BySeverity[severity], want)
+		}
+	}
+}
+
+// TestValidateSeverityName verifies that severity inputs with typos are
+// rejected with a message listing the accepted values.
+//
+// This test covers validateSeverityName in output.go, used for inputs that
+// name a rung of the severity ladder (such as max-severity).
+//
+// It accepts every ladder entry case-insensitively and rejects unknown names.
+func TestValidateSeverityName(t *testing.T) {
+	for _, valid := range []string{"critical", "High", "MEDIUM", "low", "negligible", "unknown"} {
+		if err := validateSeverityName(valid); err != nil {
+			t.Errorf("validateSeverityName(%q) error = %v, want nil", valid, err)
+		}
+	}
+
+	err := validateSeverityName("higj")
+	if err == nil {
+		t.Fatal("validateSeverityName(\"higj\") should return an error")
+	}
+	if !strings.Contains(err.Error(), "critical, high, medium, low, negligible, unknown") {
+		t.Errorf("error = %v, want list of allowed severities", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Package main provides an auto-updating pull request comment for the Grype GitHub Action.
+// On pull_request events the scan report is posted as a PR comment; later runs
+// find the comment by a hidden HTML marker and update it in place.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCommentPages bounds how many pages of PR comments are searched for the marker.
+const maxCommentPages = 10
+
+// pullRequestRefPattern extracts the PR number from refs like "refs/pull/42/merge".
+var pullRequestRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// repositoryPattern validates GITHUB_REPOSITORY values of the form "owner/repo".
+var repositoryPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// PRCommentClient handles communication with the GitHub issue comments API.
+type PRCommentClient struct {
+	Token      string       // GitHub token with pull-requests (or issues) write permission
+	HTTPClient *http.Client // HTTP client (injectable for testing)
+	BaseURL    string       // API base URL (default: GITHUB_API_URL or https://api.github.com)
+}
+
+// NewPRCommentClient creates a PRCommentClient with the given token and sensible defaults.
+// The API base URL honors GITHUB_API_URL so GitHub Enterprise Server works unchanged.
+func NewPRCommentClient(token string) *PRCommentClient {
+	return &PRCommentClient{
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		BaseURL:    getEnv("GITHUB_API_URL", "https://api.github.com"),
+	}
+}
+
+// issueComment is a minimal representation of a GitHub issue comment.
+type issueComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// commentRequest is the request body for creating or updating a comment.
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+// UpsertComment creates or updates the action's comment on a pull request.
+//
+// Parameters:
+//   - repo: Repository in "owner/repo" form (from GITHUB_REPOSITORY)
+//   - prNumber: Pull request number
+//   - marker: Hidden HTML comment identifying this action's comment
+//   - body: Markdown body; the marker is prepended automatically
+//
+// Returns the HTML URL of the created or updated comment. An existing comment
+// containing marker is PATCHed; otherwise a new comment is POSTed.
+func (c *PRCommentClient) UpsertComment(repo string, prNumber int, marker, body string) (string, error) {
+	existing, err := c.findComment(repo, prNumber, marker)
+	if err != nil {
+		return "", err
+	}
+
+	payload := commentRequest{Body: marker + "\n" + body}
+	if existing != nil {
+		apiURL := fmt.Sprintf("%s/repos/%s/issues/comments/%d", c.BaseURL, repo, existing.ID)
+		return c.send(http.MethodPatch, apiURL, payload)
+	}
+
+	apiURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.BaseURL, repo, prNumber)
+	return c.send(http.MethodPost, apiURL, payload)
+}
+
+// findComment pages through the PR's comments looking for one containing marker.
+// Returns nil without error when no such comment exists.
+func (c *PRCommentClient) findComment(repo string, prNumber int, marker string) (*issueComment, error) {
+	for page := 1; page <= maxCommentPages; page++ {
+		apiURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=100&page=%d", c.BaseURL, repo, prNumber, page)
+		req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		respBody, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var comments []issueComment
+		if err := json.Unmarshal(respBody, &comments); err != nil {
+			return nil, fmt.Errorf("failed to parse comments response: %w", err)
+		}
+
+		for i := range comments {
+			if strings.Contains(comments[i].Body, marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < 100 {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// send issues a create/update request and returns the comment's HTML URL.
+func (c *PRCommentClient) send(method, apiURL string, payload commentRequest) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal comment request: %w", err)
+	}
+
+	req, err := http.NewRequest(method, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	respBody, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+
+	var comment issueComment
+	if err := json.Unmarshal(respBody, &comment); err != nil {
+		return "", fmt.Errorf("failed to parse comment response: %w", err)
+	}
+	return comment.HTMLURL, nil
+}
+
+// do sets the common GitHub API headers, executes req, and returns the body of a 2xx response.
+func (c *PRCommentClient) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Authorization", "token "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("comments API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments API response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("comments API returned %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+	return respBody, nil
+}
+
+// prCommentMarker returns the hidden HTML marker for a scan mode, so separate
+// grype_me steps in one workflow (e.g., image and release) keep separate comments.
+func prCommentMarker(scanMode string) string {
+	return fmt.Sprintf("<!-- grype_me:pr-comment:%s -->", scanMode)
+}
+
+// isPullRequestEvent reports whether the workflow was triggered by a pull request.
+func isPullRequestEvent(eventName string) bool {
+	return eventName == "pull_request" || eventName == "pull_request_target"
+}
+
+// resolvePRNumber determines the pull request number for the current run.
+//
+// eventPath is GITHUB_EVENT_PATH; its JSON payload is consulted first
+// (pull_request.number, then the top-level number). ref is GITHUB_REF and is
+// used as a fallback when the payload is unreadable (e.g., after the privilege
+// drop), matching refs of the form "refs/pull/<n>/merge".
+//
+// Returns the PR number, or an error when neither source yields one.
+func resolvePRNumber(eventPath, ref string) (int, error) {
+	if eventPath != "" {
+		if data, err := os.ReadFile(eventPath); err == nil { // #nosec G304 -- path provided by the Actions runner
+			var event struct {
+				Number      int `json:"number"`
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(data, &event) == nil {
+				if event.PullRequest.Number > 0 {
+					return event.PullRequest.Number, nil
+				}
+				if event.Number > 0 {
+					return event.Number, nil
+				}
+			}
+		}
+	}
+
+	if m := pullRequestRefPattern.FindStringSubmatch(ref); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not determine pull request number from event payload or GITHUB_REF %q", ref)
+}
+
+// postPRComment posts or updates the scan report as a pull request comment.
+//
+// config must have PRComment enabled and a GitHubToken; report is the
+// Markdown from generateReport and scanMode selects the comment marker.
+//
+// Returns nil without doing anything when the run was not triggered by a pull
+// request. Returns an error when the token, repository, or PR number is
+// missing, or the GitHub API rejects the request. Called from
+// publishIntegrations, which only warns on error.
+func postPRComment(config Config, report, scanMode string) error {
+	if !isPullRequestEvent(os.Getenv("GITHUB_EVENT_NAME")) {
+		logInfof("pr-comment: not a pull_request event, skipping comment")
+		return nil
+	}
+
+	if config.GitHubToken == "" {
+		return fmt.Errorf("pr-comment requires the github-token input")
+	}
+
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	if !repositoryPattern.MatchString(repo) {
+		return fmt.Errorf("invalid or missing GITHUB_REPOSITORY %q", repo)
+	}
+
+	prNumber, err := resolvePRNumber(os.Getenv("GITHUB_EVENT_PATH"), os.Getenv("GITHUB_REF"))
+	if err != nil {
+		return err
+	}
+
+	commentURL, err := NewPRCommentClient(config.GitHubToken).UpsertComment(repo, prNumber, prCommentMarker(scanMode), report)
+	if err != nil {
+		return err
+	}
+
+	logInfof("Pull request comment updated: %s", commentURL)
+	return nil
+}
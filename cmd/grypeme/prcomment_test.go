@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestUpsertComment verifies that reviewers see exactly one grype_me comment
+// per scan mode on a pull request, refreshed on every push.
+//
+// This test covers PRCommentClient.UpsertComment in prcomment.go, called via
+// postPRComment from publishIntegrations when pr-comment is enabled.
+//
+// It runs an httptest GitHub API and asserts that a PR without the marker
+// gets a new comment via POST, while a PR that already carries the marker
+// has that comment updated via PATCH. Both bodies must start with the marker.
+func TestUpsertComment(t *testing.T) {
+	marker := prCommentMarker("image")
+
+	tests := []struct {
+		name       string
+		existing   string
+		wantMethod string
+		wantPath   string
+	}{
+		{"creates comment when none carries the marker", `[{"id":1,"body":"LGTM"}]`, http.MethodPost, "/repos/owner/repo/issues/7/comments"},
+		{"updates comment that carries the marker", fmt.Sprintf(`[{"id":1,"body":"LGTM"},{"id":42,"body":%q}]`, marker+"\nold report"), http.MethodPatch, "/repos/owner/repo/issues/comments/42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotPath, gotBody string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "token gh-token" {
+					t.Errorf("Authorization = %q, want %q", got, "token gh-token")
+				}
+				if r.Method == http.MethodGet {
+					_, _ = fmt.Fprint(w, tt.existing)
+					return
+				}
+
+				gotMethod, gotPath = r.Method, r.URL.Path
+				var req commentRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				gotBody = req.Body
+				_, _ = fmt.Fprint(w, `{"id":42,"html_url":"https://github.com/owner/repo/pull/7#issuecomment-42"}`)
+			}))
+			defer server.Close()
+
+			client := &PRCommentClient{Token: "gh-token", HTTPClient: server.Client(), BaseURL: server.URL}
+			commentURL, err := client.UpsertComment("owner/repo", 7, marker, "# Report")
+			if err != nil {
+				t.Fatalf("UpsertComment() error = %v", err)
+			}
+
+			if gotMethod != tt.wantMethod || gotPath != tt.wantPath {
+				t.Errorf("request = %s %s, want %s %s", gotMethod, gotPath, tt.wantMethod, tt.wantPath)
+			}
+			if !strings.HasPrefix(gotBody, marker+"\n") || !strings.Contains(gotBody, "# Report") {
+				t.Errorf("body = %q, want marker followed by report", gotBody)
+			}
+			if commentURL != "https://github.com/owner/repo/pull/7#issuecomment-42" {
+				t.Errorf("commentURL = %q", commentURL)
+			}
+		})
+	}
+}
+
+// TestUpsertCommentAPIError verifies that a token without write access is
+// reported instead of silently leaving the PR without a comment.
+//
+// This test covers PRCommentClient.UpsertComment in prcomment.go.
+//
+// It asserts that a 403 from the comments API yields an error containing the
+// status code.
+func TestUpsertCommentAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	}))
+	defer server.Close()
+
+	client := &PRCommentClient{Token: "gh-token", HTTPClient: server.Client(), BaseURL: server.URL}
+	_, err := client.UpsertComment("owner/repo", 7, prCommentMarker("image"), "# Report")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("UpsertComment() error = %v, want 403 error", err)
+	}
+}
+
+// TestResolvePRNumber verifies that the action finds the pull request it runs
+// for, both from the event payload and from the merge ref.
+//
+// This test covers resolvePRNumber in prcomment.go, which reads
+// GITHUB_EVENT_PATH and GITHUB_REF for postPRComment.
+//
+// It writes event payloads to a temp dir and asserts the PR number is taken
+// from pull_request.number, then the top-level number, then GITHUB_REF, and
+// that an error is returned when none of them identifies a PR.
+func TestResolvePRNumber(t *testing.T) {
+	dir := t.TempDir()
+	writeEvent := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write event: %v", err)
+		}
+		return path
+	}
+
+	prEvent := writeEvent("pr.json", `{"number":12,"pull_request":{"number":12}}`)
+	numberOnly := writeEvent("number.json", `{"number":34}`)
+	pushEvent := writeEvent("push.json", `{"ref":"refs/heads/main"}`)
+
+	tests := []struct {
+		name      string
+		eventPath string
+		ref       string
+		want      int
+		wantErr   bool
+	}{
+		{"reads number from pull_request payload", prEvent, "", 12, false},
+		{"reads top-level number from payload", numberOnly, "", 34, false},
+		{"falls back to merge ref", pushEvent, "refs/pull/56/merge", 56, false},
+		{"falls back to ref when payload is missing", filepath.Join(dir, "missing.json"), "refs/pull/78/head", 78, false},
+		{"returns error when no PR can be identified", pushEvent, "refs/heads/main", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePRNumber(tt.eventPath, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePRNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePRNumber() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPostPRCommentSkipsAndValidates verifies that enabling pr-comment is safe
+// on every trigger: non-PR runs are skipped and misconfiguration is reported.
+//
+// This test covers postPRComment in prcomment.go.
+//
+// It asserts that a push event returns nil without contacting GitHub, and
+// that a pull_request event without github-token returns an error naming
+// the missing input.
+func TestPostPRCommentSkipsAndValidates(t *testing.T) {
+	t.Run("skips non pull_request events", func(t *testing.T) {
+		t.Setenv("GITHUB_EVENT_NAME", "push")
+		if err := postPRComment(Config{PRComment: true}, "# Report", "image"); err != nil {
+			t.Errorf("postPRComment() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("requires github-token on pull_request events", func(t *testing.T) {
+		t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+		err := postPRComment(Config{PRComment: true}, "# Report", "image")
+		if err == nil || !strings.Contains(err.Error(), "github-token") {
+			t.Errorf("postPRComment() error = %v, want github-token error", err)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Package main provides the preflight mode: a cheap check that grype and its
+// vulnerability database are usable, run before large matrix jobs instead of
+// a scan.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Run modes selected by the mode input.
+const (
+	modeScan      = "scan"
+	modePreflight = "preflight"
+)
+
+// validateMode rejects unknown mode inputs; empty selects modeScan.
+func validateMode(mode string) error {
+	if mode == "" || mode == modeScan || mode == modePreflight {
+		return nil
+	}
+	return fmt.Errorf("invalid mode %q (allowed: %s, %s)", mode, modeScan, modePreflight)
+}
+
+// grypeDBStatus is the part of `grype db status -o json` the preflight uses.
+type grypeDBStatus struct {
+	Built string `json:"built"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error"`
+}
+
+// grypeVersion returns the version reported by `grype --version`, e.g.
+// "0.87.0" from "grype 0.87.0".
+func grypeVersion(config Config) (string, error) {
+	out, err := grypeCommand(config, "--version").Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", &GrypeError{Category: GrypeNotFound, ExitCode: -1, Err: err}
+	}
+	if err != nil {
+		return "", fmt.Errorf("grype --version failed: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("grype --version printed no version")
+	}
+	return fields[len(fields)-1], nil
+}
+
+// grypeDBStatusOf runs `grype db status -o json` and parses its result.
+// grype exits non-zero for a missing or invalid database, so its output is
+// parsed even then, and the exit error is only returned without a status.
+func grypeDBStatusOf(config Config) (grypeDBStatus, error) {
+	var status grypeDBStatus
+	out, runErr := grypeCommand(config, "db", "status", "-o", "json").Output()
+	if err := json.Unmarshal(out, &status); err != nil {
+		if runErr != nil {
+			return status, fmt.Errorf("grype db status failed: %w", runErr)
+		}
+		return status, fmt.Errorf("failed to parse grype db status: %w", err)
+	}
+	return status, nil
+}
+
+// runPreflight checks that grype is installed and its vulnerability database
+// is present, valid, and not older than db-stale-age, without scanning.
+//
+// It prints the grype version and database age, and writes the grype-version,
+// db-version, db-age-days, and db-stale outputs (see dbFreshnessOutputs) so
+// later jobs can reuse them. now is the reference time for the age.
+//
+// Returns an error (failing the action) if grype is missing, the database is
+// missing, invalid, or has no build time, or it is stale. Called from run
+// instead of the scan when the mode input is "preflight".
+func runPreflight(config Config, now time.Time) error {
+	version, err := grypeVersion(config)
+	if err != nil {
+		return err
+	}
+	logResultf("✊ preflight: grype %s", version)
+
+	status, err := grypeDBStatusOf(config)
+	if err != nil {
+		return err
+	}
+	if !status.Valid {
+		reason := status.Error
+		if reason == "" {
+			reason = "no database found"
+		}
+		return fmt.Errorf("preflight failed: vulnerability database is not usable: %s (enable db-update or check db-cache-dir)", reason)
+	}
+
+	// An invalid db-stale-age was already rejected by validateConfig
+	staleAge, _ := parseDBStaleAge(config.DBStaleAge)
+	outputs, stale := dbFreshnessOutputs(status.Built, staleAge, now)
+	if outputs == nil {
+		return fmt.Errorf("preflight failed: vulnerability database reports no build time")
+	}
+	age, _ := dbAge(status.Built, now)
+	logResultf("  db: built %s (%s old)", extractDBDate(status.Built), formatDBAge(age))
+
+	outputs["grype-version"] = version
+	outputs["db-version"] = status.Built
+	if err := writeOutputs(outputs); err != nil {
+		return err
+	}
+
+	if stale {
+		return fmt.Errorf("preflight failed: vulnerability database is %s old, older than db-stale-age %s (enable db-update)", formatDBAge(age), formatDBAge(staleAge))
+	}
+	logResultf("Preflight passed")
+	return nil
+}
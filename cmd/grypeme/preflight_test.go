@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunPreflight verifies that mode: preflight tells a matrix job up front
+// whether grype and a fresh vulnerability database are available, without
+// spending time on a scan.
+//
+// This test covers runPreflight, grypeVersion, and grypeDBStatusOf in
+// preflight.go, using a stub grype on PATH.
+//
+// It asserts that a valid, recent database passes and writes the
+// grype-version, db-version, db-age-days, and db-stale outputs, and that a
+// stale database, an invalid database, and a missing grype binary each fail
+// with an error saying why.
+func TestRunPreflight(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	stubGrype := func(t *testing.T, status string) {
+		t.Helper()
+		installFakeGrype(t, `if [ "$1" = "--version" ]; then echo "grype 0.87.0"; exit 0; fi
+if [ "$1" = "db" ] && [ "$2" = "status" ]; then echo '`+status+`'; exit 0; fi
+exit 1`)
+	}
+
+	t.Run("fresh database passes", func(t *testing.T) {
+		stubGrype(t, `{"built":"2025-06-08T04:10:00Z","valid":true}`)
+		outFile := filepath.Join(t.TempDir(), "output")
+		t.Setenv("GITHUB_OUTPUT", outFile)
+
+		var err error
+		out := captureStdout(t, func() { err = runPreflight(Config{}, now) })
+		if err != nil {
+			t.Fatalf("runPreflight() error = %v", err)
+		}
+		if !strings.Contains(out, "grype 0.87.0") || !strings.Contains(out, "Preflight passed") {
+			t.Errorf("stdout = %q, want grype version and pass message", out)
+		}
+
+		data, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"grype-version=0.87.0", "db-version=2025-06-08T04:10:00Z", "db-age-days=2", "db-stale=false"} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("outputs = %q, want %q", data, want)
+			}
+		}
+	})
+
+	t.Run("stale database fails", func(t *testing.T) {
+		stubGrype(t, `{"built":"2025-05-01T00:00:00Z","valid":true}`)
+		t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "output"))
+
+		var err error
+		captureStdout(t, func() { err = runPreflight(Config{}, now) })
+		if err == nil || !strings.Contains(err.Error(), "db-stale-age") {
+			t.Errorf("runPreflight() error = %v, want db-stale-age error", err)
+		}
+	})
+
+	t.Run("invalid database fails", func(t *testing.T) {
+		stubGrype(t, `{"valid":false,"error":"database does not exist"}`)
+
+		var err error
+		captureStdout(t, func() { err = runPreflight(Config{}, now) })
+		if err == nil || !strings.Contains(err.Error(), "database does not exist") {
+			t.Errorf("runPreflight() error = %v, want database error", err)
+		}
+	})
+
+	t.Run("missing grype fails", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+
+		err := runPreflight(Config{}, now)
+		var grypeErr *GrypeError
+		if !errors.As(err, &grypeErr) || grypeErr.Category != GrypeNotFound {
+			t.Errorf("runPreflight() error = %v, want GrypeNotFound", err)
+		}
+	})
+}
@@ -48,7 +48,7 @@ func dropPrivileges() error {
 		return nil
 	}
 
-	fmt.Printf("Running as root (UID 0), preparing to drop privileges to UID %d\n", NonPrivilegedUID)
+	logInfof("Running as root (UID 0), preparing to drop privileges to UID %d", NonPrivilegedUID)
 
 	strictPrivilegeDrop := parseBoolEnvVar(getenvFn("INPUT_STRICT-PRIVILEGE-DROP")) ||
 		parseBoolEnvVar(getenvFn("GRYPE_STRICT_PRIVILEGE_DROP"))
@@ -60,10 +60,7 @@ func dropPrivileges() error {
 			return fmt.Errorf("strict privilege drop enabled; cannot drop privileges safely: %s", reason)
 		}
 		runtimePrivilegeMode = "root-fallback"
-		fmt.Printf("Warning: Could not pre-open GITHUB_OUTPUT for post-drop writes. Continuing as root to preserve GitHub Actions outputs.\n")
-		if reason != "" {
-			fmt.Printf("Warning: privilege fallback reason: %s\n", reason)
-		}
+		logWarnf("could not pre-open GITHUB_OUTPUT for post-drop writes, continuing as root to preserve GitHub Actions outputs: %s", reason)
 		return nil
 	}
 
@@ -75,7 +72,7 @@ func dropPrivileges() error {
 		// Fix ownership of workspace directory if it exists
 		if err := chownFn(workspaceDir, NonPrivilegedUID, NonPrivilegedGID); err != nil {
 			// Non-fatal: log warning and continue
-			fmt.Printf("Warning: Could not chown %s: %v\n", workspaceDir, err)
+			logWarnf("could not chown %s: %v", workspaceDir, err)
 		}
 	}
 
@@ -95,7 +92,7 @@ func dropPrivileges() error {
 	}
 
 	runtimePrivilegeMode = "dropped"
-	fmt.Printf("Successfully dropped privileges to UID %d, GID %d\n", NonPrivilegedUID, NonPrivilegedGID)
+	logInfof("Successfully dropped privileges to UID %d, GID %d", NonPrivilegedUID, NonPrivilegedGID)
 	return nil
 }
 
@@ -0,0 +1,160 @@
+// Package main provides SARIF export of scan results for code scanning tools.
+// The export is a pure transform over GrypeMatch values; see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// sarifSchema and sarifVersion identify the SARIF dialect of the export.
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifLog is the root SARIF document with a single grype run.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun describes the tool and the results of one scan.
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool names grype as the analysis tool.
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver carries the tool identity and one rule per vulnerability ID.
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule describes one vulnerability; results reference it by ID.
+type sarifRule struct {
+	ID               string            `json:"id"`
+	ShortDescription sarifMessage      `json:"shortDescription"`
+	FullDescription  *sarifMessage     `json:"fullDescription,omitempty"`
+	HelpURI          string            `json:"helpUri,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+// sarifResult is one vulnerable package.
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifMessage is a plain-text SARIF message.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation points at the file the vulnerable package was found in.
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// sarifLevel maps a Grype severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// convertToSARIF transforms Grype matches into a SARIF log.
+//
+// Results are ordered with sortMatches; matches sharing a vulnerability ID
+// share one rule. Each result is located at the first path grype reported for
+// the package, relative to the scan root, or at the package name when grype
+// reported none (e.g., for SBOM scans). version is the grype version.
+func convertToSARIF(matches []GrypeMatch, version string) sarifLog {
+	driver := sarifDriver{
+		Name:           "grype",
+		Version:        version,
+		InformationURI: "https://github.com/anchore/grype",
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	seen := map[string]bool{}
+
+	for _, m := range sortMatches(matches) {
+		id := m.Vulnerability.ID
+		if !seen[id] {
+			seen[id] = true
+			rule := sarifRule{
+				ID:               id,
+				ShortDescription: sarifMessage{Text: id},
+				HelpURI:          m.Vulnerability.DataSource,
+			}
+			if m.Vulnerability.Description != "" {
+				rule.FullDescription = &sarifMessage{Text: m.Vulnerability.Description}
+			}
+			if m.Vulnerability.Severity != "" {
+				rule.Properties = map[string]string{"severity": strings.ToLower(m.Vulnerability.Severity)}
+			}
+			driver.Rules = append(driver.Rules, rule)
+		}
+
+		uri := m.Artifact.Name
+		if len(m.Artifact.Locations) > 0 && m.Artifact.Locations[0].Path != "" {
+			uri = strings.TrimPrefix(m.Artifact.Locations[0].Path, "/")
+		}
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = uri
+
+		results = append(results, sarifResult{
+			RuleID:    id,
+			Level:     sarifLevel(m.Vulnerability.Severity),
+			Message:   sarifMessage{Text: fmt.Sprintf("%s %s is affected by %s (%s)", m.Artifact.Name, m.Artifact.Version, id, m.Vulnerability.Severity)},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
+
+// writeSARIF writes the scan's matches as a SARIF 2.1.0 log.
+//
+// output is the parsed scan; its grype version becomes the driver version.
+// path is resolved and validated like every other file output.
+//
+// Returns an error if the log cannot be marshaled or written. Called from
+// saveOutputFile when output-file ends in .sarif.
+func writeSARIF(output *GrypeOutput, path string) error {
+	data, err := json.MarshalIndent(convertToSARIF(output.Matches, output.Descriptor.Version), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal SARIF export: %w", err)
+	}
+
+	if _, err := writeWorkspaceFile(path, append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
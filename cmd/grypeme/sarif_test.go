@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestWriteSARIF verifies that code scanning tools ingesting SARIF list each
+// vulnerable package with a severity-appropriate level and a location.
+//
+// This test covers writeSARIF, convertToSARIF, and sarifLevel in sarif.go,
+// called from saveOutputFile when output-file ends in .sarif.
+//
+// It writes a scan with a critical CVE on two packages and a low CVE and
+// asserts a SARIF 2.1.0 log with grype as driver, one rule per CVE, results
+// ordered most severe first with levels "error" and "note", and locations
+// taken from the package path or, without one, from the package name.
+func TestWriteSARIF(t *testing.T) {
+	located := makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", nil, "remote code execution", "https://nvd.example/CVE-CRIT")
+	located.Artifact.Locations = append(located.Artifact.Locations, struct {
+		Path string `json:"path"`
+	}{Path: "/usr/lib/libssl.so"})
+
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "", ""),
+		located,
+		makeMatch("CVE-CRIT", "Critical", "libssl", "3.0.0", nil, "remote code execution", "https://nvd.example/CVE-CRIT"),
+	}}
+	output.Descriptor.Version = "0.106.0"
+
+	path := filepath.Join(t.TempDir(), "grype.sarif")
+	if err := writeSARIF(output, path); err != nil {
+		t.Fatalf("writeSARIF() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = version %q with %d runs, want 2.1.0 with 1 run", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "grype" || run.Tool.Driver.Version != "0.106.0" {
+		t.Errorf("driver = %+v, want grype 0.106.0", run.Tool.Driver)
+	}
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("got %d rules, want one per CVE (2)", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("got %d results, want 3", len(run.Results))
+	}
+
+	var got []string
+	for _, r := range run.Results {
+		got = append(got, r.RuleID+":"+r.Level+":"+r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	if run.Results[2].RuleID != "CVE-LOW" {
+		t.Errorf("last result = %s, want the low finding last", run.Results[2].RuleID)
+	}
+	sort.Strings(got)
+	want := "CVE-CRIT:error:libssl,CVE-CRIT:error:usr/lib/libssl.so,CVE-LOW:note:zlib"
+	if strings.Join(got, ",") != want {
+		t.Errorf("results = %s, want %s", strings.Join(got, ","), want)
+	}
+}
@@ -2,11 +2,22 @@
 package main
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // determineScanTarget figures out what to scan based on the configuration inputs.
@@ -15,7 +26,7 @@ import (
 //
 // Returns:
 //   - target: The Grype scan target (e.g., "alpine:latest", "dir:/path", "sbom:file.json")
-//   - tempDir: Path to temporary worktree (empty if none created, caller must clean up)
+//   - tempDir: Path to a temporary worktree or inline SBOM directory (empty if none created, caller must clean up)
 //   - error: Any error encountered during target determination
 func determineScanTarget(config Config) (string, string, error) {
 	// Validate mutually exclusive artifact modes
@@ -23,6 +34,11 @@ func determineScanTarget(config Config) (string, string, error) {
 		return "", "", err
 	}
 
+	// Inline SBOMs are written to a temp dir that the caller cleans up
+	if config.SBOMStdin || config.SBOMContent != "" {
+		return writeInlineSBOM(config, os.Stdin)
+	}
+
 	// Handle artifact-based scanning (image, path, sbom)
 	target, err := getArtifactTarget(config)
 	if err != nil {
@@ -38,20 +54,82 @@ func determineScanTarget(config Config) (string, string, error) {
 		scanMode = "latest_release"
 	}
 
-	return handleRepoScan(scanMode)
+	var tempDir string
+	if config.RepoURL != "" {
+		target, tempDir, err = handleRemoteRepoScan(config.RepoURL, scanMode)
+	} else {
+		target, tempDir, err = handleRepoScan(scanMode)
+	}
+	if err != nil || config.ScanSubdir == "" {
+		return target, tempDir, err
+	}
+
+	scanDir, err := scopeScanDir(strings.TrimPrefix(target, "dir:"), config.ScanSubdir)
+	if err != nil {
+		cleanupWorktree(tempDir)
+		return "", "", err
+	}
+	return "dir:" + scanDir, tempDir, nil
+}
+
+// scopeScanDir returns subdir within the checked-out root of a repository
+// scan (the working directory, a worktree, or a clone) for scan-subdir, so
+// monorepos can scan a single service.
+//
+// subdir must be relative and stay within root (checked with
+// validatePathInWorkspace) and must be an existing directory at the scanned
+// ref. Returns the scoped directory or an error naming scan-subdir.
+func scopeScanDir(root, subdir string) (string, error) {
+	if filepath.IsAbs(subdir) {
+		return "", fmt.Errorf("invalid scan-subdir %q: must be relative to the repository root", subdir)
+	}
+	scanDir := filepath.Join(root, subdir)
+	if err := validatePathInWorkspace(scanDir, root); err != nil {
+		return "", fmt.Errorf("invalid scan-subdir %q: %w", subdir, err)
+	}
+	info, err := os.Stat(scanDir)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("invalid scan-subdir %q: not a directory of the scanned repository", subdir)
+	}
+	logInfof("Scoping scan to subdirectory %s", subdir)
+	return scanDir, nil
 }
 
 // validateArtifactModes checks that only one artifact mode is specified
 // and that artifact modes are not combined with repository scan mode.
 func validateArtifactModes(config Config) error {
-	artifactModeCount := countNonEmpty(config.Image, config.Path, config.SBOM)
+	if config.SBOMStdin && config.SBOMContent != "" {
+		return fmt.Errorf("only one of sbom-stdin or sbom-content can be specified")
+	}
+	inlineSBOM := config.SBOMStdin || config.SBOMContent != ""
+	if inlineSBOM && config.SBOM != "" {
+		return fmt.Errorf("sbom-stdin and sbom-content cannot be used together with sbom")
+	}
+
+	sbom := config.SBOM
+	if inlineSBOM {
+		sbom = "inline"
+	}
+	artifactModeCount := countNonEmpty(config.Image, config.ImageArchive, config.Path, sbom)
 
 	if artifactModeCount > 1 {
-		return fmt.Errorf("only one of image, path, or sbom can be specified")
+		return fmt.Errorf("only one of image, image-archive, path, or sbom can be specified")
 	}
 
 	if artifactModeCount > 0 && config.Scan != "" {
-		return fmt.Errorf("scan cannot be used together with image, path, or sbom")
+		return fmt.Errorf("scan cannot be used together with image, image-archive, path, or sbom")
+	}
+
+	if artifactModeCount > 0 && config.RepoURL != "" {
+		return fmt.Errorf("repo-url cannot be used together with image, image-archive, path, or sbom")
+	}
+
+	if artifactModeCount > 0 && config.ScanSubdir != "" {
+		return fmt.Errorf("scan-subdir cannot be used together with image, image-archive, path, or sbom")
+	}
+
+	if config.ScanTagsGlob != "" && (artifactModeCount > 0 || config.Scan != "" || config.RepoURL != "") {
+		return fmt.Errorf("scan-tags-glob cannot be used together with scan, repo-url, image, image-archive, path, or sbom")
 	}
 
 	return nil
@@ -78,6 +156,10 @@ func getArtifactTarget(config Config) (string, error) {
 		return config.Image, nil
 	}
 
+	if config.ImageArchive != "" {
+		return buildImageArchiveTarget(config.ImageArchive)
+	}
+
 	if config.Path != "" {
 		target, err := buildPathTarget(config.Path)
 		if err != nil {
@@ -93,8 +175,93 @@ func getArtifactTarget(config Config) (string, error) {
 	return "", nil
 }
 
+// writeInlineSBOM writes an SBOM passed via sbom-content, or read from stdin
+// when sbom-stdin is set, to a temporary file and returns its "sbom:" target
+// and the temporary directory, which the caller removes via cleanupWorktree.
+// Returns an error if the SBOM is empty or cannot be written.
+func writeInlineSBOM(config Config, stdin io.Reader) (string, string, error) {
+	content := []byte(config.SBOMContent)
+	if config.SBOMStdin {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read SBOM from stdin: %w", err)
+		}
+		content = data
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return "", "", fmt.Errorf("inline SBOM is empty")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "grype-sbom-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp dir for SBOM: %w", err)
+	}
+	sbomPath := filepath.Join(tmpDir, "sbom.json")
+	if err := os.WriteFile(sbomPath, content, 0o600); err != nil {
+		cleanupWorktree(tmpDir)
+		return "", "", fmt.Errorf("failed to write inline SBOM: %w", err)
+	}
+	return "sbom:" + sbomPath, tmpDir, nil
+}
+
+// buildImageArchiveTarget creates the Grype target for an image tarball,
+// choosing "docker-archive:" or "oci-archive:" from the archive contents.
+//
+// The tar (optionally gzip-compressed) is scanned for a top-level
+// manifest.json, written by "docker save", and an oci-layout file, written by
+// OCI tools. Docker 25+ writes both; such archives are scanned as
+// docker-archive. Returns an error if the file does not exist or contains
+// neither marker.
+func buildImageArchiveTarget(path string) (string, error) {
+	f, err := os.Open(path) // #nosec G304 -- user-provided archive path
+	if err != nil {
+		return "", fmt.Errorf("image-archive %q not found: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	reader := bufio.NewReader(f)
+	var archive io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", fmt.Errorf("image-archive %q: %w", path, err)
+		}
+		defer func() { _ = gz.Close() }()
+		archive = gz
+	}
+
+	hasManifest, hasOCILayout := false, false
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("image-archive %q is not a readable tar archive: %w", path, err)
+		}
+		switch strings.TrimPrefix(header.Name, "./") {
+		case "manifest.json":
+			hasManifest = true
+		case "oci-layout":
+			hasOCILayout = true
+		}
+	}
+
+	switch {
+	case hasManifest:
+		return "docker-archive:" + path, nil
+	case hasOCILayout:
+		return "oci-archive:" + path, nil
+	default:
+		return "", fmt.Errorf("image-archive %q is neither a docker-archive (manifest.json) nor an oci-archive (oci-layout)", path)
+	}
+}
+
 // buildPathTarget creates the appropriate Grype target string for a path.
-// Grype uses "dir:" prefix for directories and "file:" for files.
+// Grype uses "dir:" prefix for directories and "file:" for files. A
+// directory holding an "oci-layout" marker file is an OCI image layout, as
+// written by image build tools, and is scanned as an image via "oci-dir:".
 // Returns an error if the path does not exist.
 func buildPathTarget(path string) (string, error) {
 	info, err := os.Stat(path)
@@ -103,67 +270,600 @@ func buildPathTarget(path string) (string, error) {
 	}
 
 	if info.IsDir() {
+		if marker, err := os.Stat(filepath.Join(path, "oci-layout")); err == nil && !marker.IsDir() {
+			return "oci-dir:" + path, nil
+		}
 		return "dir:" + path, nil
 	}
 	return "file:" + path, nil
 }
 
+// grypeCommand returns a grype invocation with args in the environment of
+// grypeEnv, shared by the auxiliary grype commands (db update, preflight).
+func grypeCommand(config Config, args ...string) *exec.Cmd {
+	cmd := exec.Command("grype", args...)
+	cmd.Env = grypeEnv(config)
+	return cmd
+}
+
 // updateGrypeDB updates the Grype vulnerability database.
 // This ensures the scan uses the latest vulnerability data.
-func updateGrypeDB() error {
-	fmt.Println("Updating Grype vulnerability database...")
+func updateGrypeDB(config Config) error {
+	logInfof("Updating Grype vulnerability database...")
 
-	cmd := exec.Command("grype", "db", "update")
-	cmd.Stdout = os.Stdout
+	cmd := grypeCommand(config, "db", "update")
+	cmd.Stdout = subprocessStdout()
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("grype db update failed: %w", err)
 	}
 
-	fmt.Println("Database update complete")
+	logInfof("Database update complete")
 	return nil
 }
 
+// generateSBOM writes the CycloneDX document of the cataloged packages to
+// config.SBOMOutput by running grype a second time with cyclonedx-json output.
+//
+// target is the same grype target as the vulnerability scan, so the SBOM
+// describes exactly what was scanned. The destination is resolved and checked
+// like every other file output. The pass is bounded by scan-timeout like the
+// scan itself. Returns nil without running grype when sbom-output is empty.
+// Called from executeScan after a successful scan.
+func generateSBOM(config Config, target string) error {
+	if config.SBOMOutput == "" {
+		return nil
+	}
+
+	tmpFile, err := os.CreateTemp("", "grype-sbom-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+	_ = tmpFile.Close()
+	defer func() { _ = os.Remove(tmpFilePath) }()
+
+	args, err := buildGrypeArgsWithFormat(target, "cyclonedx-json", tmpFilePath, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, timeout, err := scanPassContext(config)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	logInfof("Generating CycloneDX SBOM...")
+	cmd := grypeScanCommand(ctx, config, args...)
+	cmd.Stdout = subprocessStdout()
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("grype SBOM generation timed out after %s (scan-timeout); it was aborted", timeout)
+	}
+
+	data, err := os.ReadFile(tmpFilePath) // #nosec G304 -- temp file created above
+	if err != nil || len(data) == 0 {
+		if runErr != nil {
+			return fmt.Errorf("grype SBOM generation failed: %w", runErr)
+		}
+		return fmt.Errorf("grype SBOM generation produced no output")
+	}
+
+	sbomPath, err := writeWorkspaceFile(config.SBOMOutput, data)
+	if err != nil {
+		return fmt.Errorf("failed to write sbom-output: %w", err)
+	}
+	logInfof("SBOM saved to: %s", sbomPath)
+	return nil
+}
+
+// validateTemplateFile checks the template-file input: it needs output-file
+// as its destination and must name an existing file, so a typo fails before
+// the scan instead of after it.
+func validateTemplateFile(config Config) error {
+	if config.TemplateFile == "" {
+		return nil
+	}
+	if len(config.OutputFiles) != 1 {
+		return fmt.Errorf("template-file requires exactly one output-file as the destination of the rendered template")
+	}
+	if config.ScanTagsGlob != "" {
+		return fmt.Errorf("template-file cannot be used together with scan-tags-glob")
+	}
+	info, err := os.Stat(config.TemplateFile)
+	if err != nil {
+		return fmt.Errorf("template-file %q not found: %w", config.TemplateFile, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("template-file %q is a directory, expected a file", config.TemplateFile)
+	}
+	return nil
+}
+
+// generateTemplateOutput renders config.TemplateFile with grype's template
+// output (-o template -t <file>) into the single output-file entry.
+//
+// grype runs a second time against the same target as the vulnerability
+// scan; the rendered output is copied verbatim and never parsed, since its
+// shape is up to the template. Stats, badge, and outputs come from the JSON
+// scan. The pass is bounded by scan-timeout like the scan itself. Returns nil
+// without running grype when template-file is empty. Called from executeScan
+// after a successful scan, in place of the output-file copy.
+func generateTemplateOutput(config Config, target string) error {
+	if config.TemplateFile == "" {
+		return nil
+	}
+
+	tmpFile, err := os.CreateTemp("", "grype-template-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+	_ = tmpFile.Close()
+	defer func() { _ = os.Remove(tmpFilePath) }()
+
+	args, err := buildGrypeArgsWithFormat(target, "template", tmpFilePath, config)
+	if err != nil {
+		return err
+	}
+	args = append(args, "-t", config.TemplateFile)
+
+	ctx, cancel, timeout, err := scanPassContext(config)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	logInfof("Rendering grype template %s...", config.TemplateFile)
+	cmd := grypeScanCommand(ctx, config, args...)
+	cmd.Stdout = subprocessStdout()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("grype template rendering timed out after %s (scan-timeout); it was aborted", timeout)
+		}
+		return fmt.Errorf("grype template rendering failed: %w", err)
+	}
+
+	data, err := os.ReadFile(tmpFilePath) // #nosec G304 -- temp file created above
+	if err != nil {
+		return fmt.Errorf("failed to read rendered template: %w", err)
+	}
+	outputPath, err := writeWorkspaceFile(config.OutputFiles[0], data)
+	if err != nil {
+		return fmt.Errorf("failed to write output-file: %w", err)
+	}
+	logInfof("Template output saved to: %s", outputPath)
+	return nil
+}
+
+// defaultDBMaxAge is how old a cached database may be before db-update
+// downloads a new one when db-cache-dir is set.
+const defaultDBMaxAge = 24 * time.Hour
+
+// grypeEnv returns the environment for grype subprocesses; db-cache-dir
+// overrides the image's GRYPE_DB_CACHE_DIR so a persisted cache is used, and
+// registry credentials are passed as GRYPE_REGISTRY_AUTH_* so grype can pull
+// private images. The credentials only ever live in this environment.
+func grypeEnv(config Config) []string {
+	env := os.Environ()
+	if config.DBCacheDir != "" {
+		env = append(env, "GRYPE_DB_CACHE_DIR="+config.DBCacheDir)
+	}
+	if config.RegistryUsername != "" {
+		if config.RegistryServer != "" {
+			env = append(env, "GRYPE_REGISTRY_AUTH_AUTHORITY="+config.RegistryServer)
+		}
+		env = append(env,
+			"GRYPE_REGISTRY_AUTH_USERNAME="+config.RegistryUsername,
+			"GRYPE_REGISTRY_AUTH_PASSWORD="+config.RegistryPassword)
+	}
+	return env
+}
+
+// parseDBMaxAge parses the db-max-age input; empty selects defaultDBMaxAge.
+func parseDBMaxAge(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultDBMaxAge, nil
+	}
+	maxAge, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid db-max-age %q: %w", value, err)
+	}
+	if maxAge <= 0 {
+		return 0, fmt.Errorf("invalid db-max-age %q: must be positive", value)
+	}
+	return maxAge, nil
+}
+
+// cachedDBBuilt returns the newest build timestamp recorded in the database
+// metadata under cacheDir. Grype keeps one directory per schema version,
+// holding import.json (schema v6) or metadata.json (schema v5) with a "built"
+// field. Returns "" when no readable metadata exists.
+func cachedDBBuilt(cacheDir string) string {
+	var candidates []string
+	for _, name := range []string{"import.json", "metadata.json"} {
+		matches, _ := filepath.Glob(filepath.Join(cacheDir, "*", name))
+		candidates = append(candidates, matches...)
+	}
+
+	newest := ""
+	var newestTime time.Time
+	for _, path := range candidates {
+		data, err := os.ReadFile(path) // #nosec G304 -- path is inside the user-configured db-cache-dir
+		if err != nil {
+			continue
+		}
+		var metadata struct {
+			Built string `json:"built"`
+		}
+		if json.Unmarshal(data, &metadata) != nil {
+			continue
+		}
+		built, err := time.Parse(time.RFC3339, metadata.Built)
+		if err != nil {
+			continue
+		}
+		if newest == "" || built.After(newestTime) {
+			newest, newestTime = metadata.Built, built
+		}
+	}
+	return newest
+}
+
+// isCachedDBFresh reports whether db-update can be skipped because the
+// database in config.DBCacheDir was built less than db-max-age before now.
+//
+// Returns false without error when no cache directory is configured or the
+// cache holds no readable database metadata. Returns an error for an invalid
+// db-max-age. Called from run before updateGrypeDB.
+func isCachedDBFresh(config Config, now time.Time) (bool, error) {
+	if config.DBCacheDir == "" {
+		return false, nil
+	}
+
+	maxAge, err := parseDBMaxAge(config.DBMaxAge)
+	if err != nil {
+		return false, err
+	}
+
+	built := cachedDBBuilt(config.DBCacheDir)
+	age, ok := dbAge(built, now)
+	if !ok {
+		logInfof("No cached vulnerability database found in %s", config.DBCacheDir)
+		return false, nil
+	}
+	if age >= maxAge {
+		logInfof("Cached vulnerability database is %s old (db-max-age %s)", formatDBAge(age), maxAge)
+		return false, nil
+	}
+
+	logInfof("Cached vulnerability database is %s old (db-max-age %s), skipping update", formatDBAge(age), maxAge)
+	return true, nil
+}
+
+// grypeDBLockedMessage is the stderr message of grype when its vulnerability
+// database is briefly locked by another grype process, e.g. in parallel CI.
+const grypeDBLockedMessage = "database is locked"
+
+// grypeDBLockRetries is how often a grype scan is retried after failing on a
+// locked vulnerability database.
+const grypeDBLockRetries = 2
+
+// grypeDBLockRetryDelay is the pause before each retry of a scan that failed
+// on a locked vulnerability database. A variable so tests can shorten it.
+var grypeDBLockRetryDelay = 2 * time.Second
+
+// scanRunInfo describes how run() produced the scan results. setOutputs
+// reports it as the grype-exit-code, scan-target, scan-duration-seconds, and
+// db-update-duration-seconds outputs, and printSummary prints the durations.
+// The zero value describes a run in which no step ran (e.g., a dry run).
+type scanRunInfo struct {
+	// Target is the target passed to grype (e.g. "dir:/tmp/grype-scan-1",
+	// "alpine:latest"); empty when no single target was scanned (dry runs,
+	// scan-tags-glob).
+	Target string
+	// Scanned reports whether the main grype scan ran; ExitCode is its exit
+	// code and only meaningful then.
+	Scanned  bool
+	ExitCode int
+	// ScanDuration and DBUpdateDuration are the wall-clock durations of the
+	// main grype scan and of the database update; zero when that step did
+	// not run.
+	ScanDuration     time.Duration
+	DBUpdateDuration time.Duration
+}
+
+// timed runs fn and returns its wall-clock duration along with its error.
+func timed(fn func() error) (time.Duration, error) {
+	start := time.Now()
+	err := fn()
+	return time.Since(start), err
+}
+
+// formatSeconds renders d as seconds with one decimal, e.g. "12.3".
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 1, 64)
+}
+
 // runGrypeScan executes the Grype vulnerability scan and writes results to outputPath.
 // The scan is configured based on the provided Config options.
-func runGrypeScan(config Config, target, outputPath string) error {
-	fmt.Printf("Running grype scan...\n")
+//
+// grype always runs in its own process group (see grypeScanCommand). When
+// config.ScanTimeout is set, the whole group is killed once the timeout
+// expires, so helper processes spawned by grype (e.g., for image pulls)
+// cannot keep the action alive. A timeout is reported as a distinct error
+// rather than as a grype failure.
+//
+// grype's stderr is streamed and captured; a scan that fails without results
+// because the vulnerability database is locked (grypeDBLockedMessage) is
+// retried up to grypeDBLockRetries times. Other failures are not retried.
+//
+// Returns grype's exit code (0 when clean, 1 when vulnerabilities were found,
+// or the failing code), or -1 when grype did not run to completion. A non-zero
+// exit with written results is not an error.
+func runGrypeScan(config Config, target, outputPath string) (int, error) {
+	ctx, cancel, timeout, err := scanPassContext(config)
+	if err != nil {
+		return -1, err
+	}
+	defer cancel()
 
-	args := buildGrypeArgs(target, outputPath, config)
+	logInfof("Running grype scan...")
 
-	cmd := exec.Command("grype", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	args, err := buildGrypeArgs(target, outputPath, config)
+	if err != nil {
+		return -1, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			logInfof("Vulnerability database is locked, retrying grype scan in %s (retry %d/%d)", grypeDBLockRetryDelay, attempt, grypeDBLockRetries)
+			time.Sleep(grypeDBLockRetryDelay)
+		}
+
+		var stderr bytes.Buffer
+		cmd := grypeScanCommand(ctx, config, args...)
+		cmd.Stdout = subprocessStdout()
+		cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+
+		err = cmd.Run()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return -1, fmt.Errorf("grype scan timed out after %s (scan-timeout); the scan was aborted", timeout)
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return -1, &GrypeError{Category: GrypeNotFound, ExitCode: -1, Err: err}
+		}
+		if err == nil || attempt >= grypeDBLockRetries || !strings.Contains(stderr.String(), grypeDBLockedMessage) {
+			break
+		}
+		if info, statErr := os.Stat(outputPath); statErr == nil && info.Size() > 0 {
+			break
+		}
+	}
+
+	// The output file is pre-created by the caller, so only a non-empty file
+	// proves that grype wrote results.
+	info, statErr := os.Stat(outputPath)
+	hasOutput := statErr == nil && info.Size() > 0
 
-	err := cmd.Run()
 	if err != nil {
+		exitCode := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
 		// Grype returns non-zero exit code when vulnerabilities are found.
-		// Check if output file was created to distinguish from actual errors.
-		if _, statErr := os.Stat(outputPath); statErr == nil {
-			fmt.Println("Grype scan completed (vulnerabilities found)")
-			return nil
+		// Check if output was written to distinguish from actual errors.
+		if hasOutput {
+			logInfof("Grype scan completed (vulnerabilities found)")
+			return exitCode, nil
 		}
-		return err
+		return exitCode, &GrypeError{Category: GrypeExecFailure, ExitCode: exitCode, Err: err}
+	}
+	if !hasOutput {
+		return 0, &GrypeError{Category: GrypeNoOutput, ExitCode: 0, Err: fmt.Errorf("no results written to %s", outputPath)}
 	}
 
-	fmt.Println("Grype scan completed")
-	return nil
+	logInfof("Grype scan completed")
+	return 0, nil
+}
+
+// GrypeErrorCategory classifies why a grype invocation failed.
+type GrypeErrorCategory int
+
+const (
+	GrypeNotFound    GrypeErrorCategory = iota // grype binary is not on PATH
+	GrypeExecFailure                           // grype exited non-zero without writing results
+	GrypeNoOutput                              // grype exited successfully but wrote no results
+)
+
+// String returns a short human-readable name of the category.
+func (c GrypeErrorCategory) String() string {
+	switch c {
+	case GrypeNotFound:
+		return "grype not found"
+	case GrypeExecFailure:
+		return "grype execution failed"
+	case GrypeNoOutput:
+		return "grype produced no output"
+	default:
+		return "grype error"
+	}
+}
+
+// GrypeError is returned by runGrypeScan when grype could not produce results.
+// Callers use errors.As to inspect Category and offer targeted guidance.
+type GrypeError struct {
+	Category GrypeErrorCategory // What went wrong
+	ExitCode int                // grype's exit code, or -1 when it did not run to completion
+	Err      error              // Underlying error
+}
+
+// Error implements the error interface, including the exit code when known.
+func (e *GrypeError) Error() string {
+	if e.ExitCode > 0 {
+		return fmt.Sprintf("%s (exit code %d): %v", e.Category, e.ExitCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Category, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *GrypeError) Unwrap() error {
+	return e.Err
 }
 
+// Hint returns actionable guidance for the failure category, shown by run.
+func (e *GrypeError) Hint() string {
+	switch e.Category {
+	case GrypeNotFound:
+		return "install grype (https://github.com/anchore/grype#installation) or run the action's container image, which ships with grype"
+	case GrypeExecFailure:
+		return "check the grype output above; an unreachable image, invalid scan target, or unreadable grype-config are common causes"
+	case GrypeNoOutput:
+		return "grype exited without results; verify the scan target and grype version"
+	default:
+		return ""
+	}
+}
+
+// scanPassContext returns the context bounding one grype pass over the scan
+// target (the vulnerability scan, the SBOM, or the template output): it ends
+// after scan-timeout, or only when cancelled if the timeout is disabled. The
+// parsed timeout is returned for error messages.
+func scanPassContext(config Config) (context.Context, context.CancelFunc, time.Duration, error) {
+	timeout, err := parseScanTimeout(config.ScanTimeout)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if timeout == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		return ctx, cancel, 0, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return ctx, cancel, timeout, nil
+}
+
+// grypeScanCommand is grypeCommand bound to ctx (see scanPassContext).
+// grype runs in its own process group, and the whole group is killed when
+// ctx ends, so the helpers grype spawns cannot outlive the pass either.
+func grypeScanCommand(ctx context.Context, config Config, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "grype", args...)
+	cmd.Env = grypeEnv(config)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// Negative PID signals the whole process group started above.
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
+
+// parseScanTimeout parses the scan-timeout input. An empty value or "0"
+// disables the timeout; negative or malformed durations are rejected.
+func parseScanTimeout(value string) (time.Duration, error) {
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scan-timeout %q: %w", value, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid scan-timeout %q: must not be negative", value)
+	}
+	return timeout, nil
+}
+
+// gitDirExclude keeps grype from cataloging the repository's .git folder in
+// directory scans, where packed objects and stray manifests only add noise.
+const gitDirExclude = "./.git/**"
+
 // buildGrypeArgs constructs the command-line arguments for the Grype scan.
-func buildGrypeArgs(target, outputPath string, config Config) []string {
-	args := []string{target, "-o", "json", "--file", outputPath}
+// Returns an error if config.GrypeConfig names a file that does not exist, so
+// a typo surfaces clearly instead of grype silently ignoring its ignore rules.
+func buildGrypeArgs(target, outputPath string, config Config) ([]string, error) {
+	return buildGrypeArgsWithFormat(target, "json", outputPath, config)
+}
+
+// buildGrypeArgsWithFormat is buildGrypeArgs for an arbitrary grype output
+// format, e.g. "cyclonedx-json" for the sbom-output file.
+func buildGrypeArgsWithFormat(target, format, outputPath string, config Config) ([]string, error) {
+	args := []string{target, "-o", format, "--file", outputPath}
+
+	if config.GrypeConfig != "" {
+		info, err := os.Stat(config.GrypeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("grype-config file %q not found: %w", config.GrypeConfig, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("grype-config %q is a directory, expected a file", config.GrypeConfig)
+		}
+		args = append(args, "-c", config.GrypeConfig)
+	}
+
+	for _, pattern := range config.Exclude {
+		args = append(args, "--exclude", pattern)
+	}
+	if strings.HasPrefix(target, "dir:") && !config.IncludeGitDir && !containsString(config.Exclude, gitDirExclude) {
+		args = append(args, "--exclude", gitDirExclude)
+	}
 
 	if config.Image != "" && config.ImageSource != "" && config.ImageSource != "auto" {
 		args = append(args, "--from", config.ImageSource)
 	}
 
+	if config.Platform != "" {
+		if isImageTarget(target) {
+			args = append(args, "--platform", config.Platform)
+		} else {
+			logWarnf("platform %q ignored for non-image scan target %s", config.Platform, target)
+		}
+	}
+
+	// all-layers also reports packages deleted by a later layer, which can
+	// still be extracted from the image but are not part of the running
+	// filesystem.
+	if config.ScanScope != "" {
+		if isImageTarget(target) {
+			args = append(args, "--scope", config.ScanScope)
+		} else {
+			logWarnf("scan-scope %q ignored for non-image scan target %s", config.ScanScope, target)
+		}
+	}
+
 	if config.OnlyFixed {
 		args = append(args, "--only-fixed")
 	}
 
-	return args
+	// Generated CPEs let grype match Go binaries and other artifacts that carry
+	// none, at the cost of more false positives from loose vendor/product guesses.
+	if config.AddCPEs {
+		args = append(args, "--add-cpes-if-none")
+	}
+
+	// --by-cve reports matches under their CVE ID rather than the originating
+	// advisory (e.g., a GHSA ID). Matches keep their one-per-package shape, so
+	// calculateStats and the exports work unchanged.
+	if config.ByCVE {
+		args = append(args, "--by-cve")
+	}
+
+	return args, nil
+}
+
+// isImageTarget reports whether target names a container image rather than a
+// directory, file, or SBOM, since grype rejects --platform for those.
+func isImageTarget(target string) bool {
+	for _, prefix := range []string{"dir:", "file:", "sbom:"} {
+		if strings.HasPrefix(target, prefix) {
+			return false
+		}
+	}
+	return true
 }
 
 // validateImageSource checks if the configured image source is supported.
@@ -186,29 +886,336 @@ func validateImageSource(source string) error {
 	return nil
 }
 
+// validateScanScope checks the scan-scope input against the layer scopes
+// grype supports. Empty leaves grype's default (squashed).
+func validateScanScope(scope string) error {
+	switch scope {
+	case "", "squashed", "all-layers":
+		return nil
+	}
+	return fmt.Errorf("invalid scan-scope %q (allowed: squashed, all-layers)", scope)
+}
+
+// scanRef checks out ref into a temporary worktree, scans it with the grype
+// options from config, and returns the parsed output and the raw grype JSON.
+// The worktree and the temporary output file are always removed. Used for
+// scans that cover several refs in one run, such as the CVE changelog and
+// scan-tags-glob.
+func scanRef(config Config, ref string) (*GrypeOutput, []byte, error) {
+	worktreeDir, err := checkoutToWorktree(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to checkout %s: %w", ref, err)
+	}
+	defer cleanupWorktree(worktreeDir)
+
+	scanDir := worktreeDir
+	if config.ScanSubdir != "" {
+		if scanDir, err = scopeScanDir(worktreeDir, config.ScanSubdir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tmpFile, err := os.CreateTemp("", "grype-output-*.json")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpFilePath := tmpFile.Name()
+	if err := tmpFile.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to close temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFilePath) }()
+
+	if _, err := runGrypeScan(config, "dir:"+scanDir, tmpFilePath); err != nil {
+		return nil, nil, fmt.Errorf("grype scan failed: %w", err)
+	}
+
+	output, err := parseGrypeOutput(tmpFilePath, config.AllowPartial)
+	if err != nil {
+		return nil, nil, err
+	}
+	rawJSON, err := os.ReadFile(tmpFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read grype output: %w", err)
+	}
+	return output, rawJSON, nil
+}
+
+// validateInputJSON checks that the input-json file for a dry run exists and
+// contains valid JSON, so wiring mistakes surface with a clear message
+// instead of a parse error deep in the pipeline.
+func validateInputJSON(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("input-json file %q cannot be read: %w", path, err)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("input-json file %q does not contain valid JSON", path)
+	}
+	return nil
+}
+
 // parseGrypeOutput reads and parses the JSON output file from a Grype scan.
-func parseGrypeOutput(filePath string) (*GrypeOutput, error) {
+// An empty file is reported separately from malformed JSON, since it means
+// grype failed before writing any results.
+//
+// With allowPartial (the allow-partial input), malformed JSON such as a file
+// truncated by an OOM kill or a full disk is not fatal: the complete matches
+// written before the truncation are recovered by recoverPartialGrypeOutput
+// and returned with a warning.
+func parseGrypeOutput(filePath string, allowPartial bool) (*GrypeOutput, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read output file: %w", err)
 	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("grype produced no output in %s (scan may have failed); check the grype logs above", filePath)
+	}
 
 	var output GrypeOutput
 	if err := json.Unmarshal(data, &output); err != nil {
+		if allowPartial {
+			if partial, ok := recoverPartialGrypeOutput(data); ok {
+				logWarnf("grype output in %s is incomplete (%v); continuing with the %d complete matches recovered, results may be missing findings", filePath, err, len(partial.Matches))
+				return partial, nil
+			}
+		}
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
 	return &output, nil
 }
 
+// recoverPartialGrypeOutput streams a damaged grype document token by token
+// and keeps every top-level field and every match that was written
+// completely. Decoding stops at the first incomplete value.
+//
+// Returns false when nothing trustworthy was recovered: the data is not a
+// JSON object or its matches array was never reached, since reporting zero
+// findings from such a file would look like a clean scan.
+func recoverPartialGrypeOutput(data []byte) (*GrypeOutput, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	var output GrypeOutput
+	sawMatches := false
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := tok.(string)
+
+		var matches *[]GrypeMatch
+		switch key {
+		case "matches":
+			matches = &output.Matches
+			sawMatches = true
+		case "ignoredMatches":
+			matches = &output.IgnoredMatches
+		case "descriptor":
+			if err := dec.Decode(&output.Descriptor); err != nil {
+				return &output, sawMatches
+			}
+			continue
+		default:
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return &output, sawMatches
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return &output, sawMatches
+		}
+		for dec.More() {
+			var m GrypeMatch
+			if err := dec.Decode(&m); err != nil {
+				return &output, sawMatches
+			}
+			*matches = append(*matches, m)
+		}
+		if _, err := dec.Token(); err != nil {
+			return &output, sawMatches
+		}
+	}
+	return &output, sawMatches
+}
+
+// checkGrypeVersionRange guards against silent Grype upgrades by comparing the
+// version reported in the scan output against config.ExpectedGrypeRange.
+//
+// config supplies the range expression (empty disables the check) and
+// StrictGrypeRange, which decides whether a mismatch is fatal. grypeVersion is
+// output.Descriptor.Version from the parsed scan.
+//
+// Returns nil when the check is disabled or the version is in range. On a
+// mismatch it returns an error in strict mode and only prints a warning
+// otherwise. A malformed range expression is always an error, because it is a
+// configuration mistake rather than tool drift.
+//
+// Called from run() in main.go right after the scan output has been parsed, so
+// pinned environments notice tool drift before badges or reports are published.
+func checkGrypeVersionRange(config Config, grypeVersion string) error {
+	if config.ExpectedGrypeRange == "" {
+		return nil
+	}
+
+	inRange, err := versionInRange(grypeVersion, config.ExpectedGrypeRange)
+	if err != nil {
+		return fmt.Errorf("invalid expected-grype-range %q: %w", config.ExpectedGrypeRange, err)
+	}
+	if inRange {
+		return nil
+	}
+
+	msg := fmt.Sprintf("grype version %q is outside expected range %q", grypeVersion, config.ExpectedGrypeRange)
+	if config.StrictGrypeRange {
+		return errors.New(msg)
+	}
+	logWarnf("%s", msg)
+	return nil
+}
+
+// versionInRange reports whether version satisfies every constraint in rangeExpr.
+// Constraints are separated by spaces or commas and use one of the operators
+// >=, <=, >, <, = (or no operator for an exact match), e.g. ">=0.100.0 <1.0.0".
+// Versions are compared with the same semver-aware ordering used for release tags.
+func versionInRange(version, rangeExpr string) (bool, error) {
+	constraints := strings.FieldsFunc(rangeExpr, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(constraints) == 0 {
+		return false, fmt.Errorf("no constraints given")
+	}
+
+	if _, ok := parseTagVersion(version); !ok {
+		return false, nil
+	}
+
+	for _, constraint := range constraints {
+		op, bound := splitVersionConstraint(constraint)
+		if _, ok := parseTagVersion(bound); !ok {
+			return false, fmt.Errorf("constraint %q does not contain a valid version", constraint)
+		}
+
+		// compareTagsDesc sorts descending, so negate it for a natural a-vs-b comparison.
+		cmp := -compareTagsDesc(version, bound)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// splitVersionConstraint separates a constraint like ">=0.100.0" into its operator and version.
+func splitVersionConstraint(constraint string) (string, string) {
+	for _, op := range []string{">=", "<=", "==", ">", "<", "="} {
+		if strings.HasPrefix(constraint, op) {
+			return op, strings.TrimSpace(strings.TrimPrefix(constraint, op))
+		}
+	}
+	return "=", constraint
+}
+
+// filterMatchesByMaxSeverity returns the matches whose severity is at or below
+// maxSeverity on the severity ladder (e.g., "low" keeps low, negligible, and
+// unknown). It lets teams focus on the long tail once the severe findings have
+// been triaged. The input slice is not modified.
+func filterMatchesByMaxSeverity(matches []GrypeMatch, maxSeverity string) []GrypeMatch {
+	limit := severityOrder(maxSeverity)
+
+	filtered := make([]GrypeMatch, 0, len(matches))
+	for _, m := range matches {
+		if severityOrder(m.Vulnerability.Severity) >= limit {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// filterIgnoredPackages drops matches whose package is listed in ignored.
+// Each entry is a package name, matching every version, or name@version for
+// a single version; the version separator is the last "@" so scoped npm
+// names such as "@scope/pkg@1.0.0" work. Names are compared exactly. Returns
+// the remaining matches and how many were suppressed; the input slice is not
+// modified.
+func filterIgnoredPackages(matches []GrypeMatch, ignored []string) ([]GrypeMatch, int) {
+	names := make(map[string]bool)
+	versions := make(map[[2]string]bool)
+	for _, entry := range ignored {
+		if at := strings.LastIndex(entry, "@"); at > 0 {
+			versions[[2]string{entry[:at], entry[at+1:]}] = true
+		} else {
+			names[entry] = true
+		}
+	}
+
+	filtered := make([]GrypeMatch, 0, len(matches))
+	for _, m := range matches {
+		if names[m.Artifact.Name] || versions[[2]string{m.Artifact.Name, m.Artifact.Version}] {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered, len(matches) - len(filtered)
+}
+
+// filterMatchesByMinSeverity returns the matches whose severity is at or above
+// minSeverity on the severity ladder (e.g., "medium" keeps critical, high, and
+// medium). The report uses it to keep its detailed tables readable while the
+// counts stay complete. The input slice is not modified.
+func filterMatchesByMinSeverity(matches []GrypeMatch, minSeverity string) []GrypeMatch {
+	limit := severityOrder(minSeverity)
+
+	filtered := make([]GrypeMatch, 0, len(matches))
+	for _, m := range matches {
+		if severityOrder(m.Vulnerability.Severity) <= limit {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
 // calculateStats aggregates vulnerability counts by severity level from scan output.
-func calculateStats(output *GrypeOutput) VulnerabilityStats {
+// unknownAs, the unknown-severity-as input, counts matches with an empty or
+// "unknown" severity in that bucket instead of Other; "" keeps them in Other.
+// With dedupe (the dedupe-cves input), a vulnerability ID found in several
+// packages is counted once, by its most severe match; Distinct is always set.
+func calculateStats(output *GrypeOutput, unknownAs string, dedupe bool) VulnerabilityStats {
 	stats := VulnerabilityStats{}
-
+	seen := make(map[string]bool)
 	for _, match := range output.Matches {
+		if !seen[match.Vulnerability.ID] {
+			seen[match.Vulnerability.ID] = true
+			stats.Distinct++
+		}
+	}
+
+	counted := output.Matches
+	if dedupe {
+		counted = mostSevereMatchPerID(output.Matches, unknownAs)
+	}
+	for _, match := range counted {
 		stats.Total++
 
-		switch strings.ToLower(match.Vulnerability.Severity) {
+		switch matchSeverity(match, unknownAs) {
 		case "critical":
 			stats.Critical++
 		case "high":
@@ -217,30 +1224,228 @@ func calculateStats(output *GrypeOutput) VulnerabilityStats {
 			stats.Medium++
 		case "low":
 			stats.Low++
+		case "negligible":
+			stats.Negligible++
 		default:
 			stats.Other++
 		}
+
+		if strings.EqualFold(match.Vulnerability.Fix.State, "fixed") {
+			stats.Fixable++
+		}
+		if isNotFixed(match) {
+			stats.NotFixed++
+		}
 	}
 
 	return stats
 }
 
-// shouldFail determines if the build should fail based on vulnerability stats and severity cutoff.
-// Returns true if any vulnerabilities at or above the cutoff severity are found.
-func shouldFail(stats VulnerabilityStats, cutoff string) bool {
+// matchSeverity returns the lower-case severity match is counted under:
+// its own, or unknownAs for an empty or "unknown" severity when set.
+func matchSeverity(match GrypeMatch, unknownAs string) string {
+	severity := strings.ToLower(match.Vulnerability.Severity)
+	if unknownAs != "" && (severity == "" || severity == "unknown") {
+		return unknownAs
+	}
+	return severity
+}
+
+// mostSevereMatchPerID returns one match per vulnerability ID, in order of
+// first appearance: the most severe one (see matchSeverity), or the first of
+// equally severe ones. The result thus does not depend on the order grype
+// reports the packages of one vulnerability in.
+func mostSevereMatchPerID(matches []GrypeMatch, unknownAs string) []GrypeMatch {
+	index := make(map[string]int)
+	var result []GrypeMatch
+	for _, m := range matches {
+		i, ok := index[m.Vulnerability.ID]
+		if !ok {
+			index[m.Vulnerability.ID] = len(result)
+			result = append(result, m)
+			continue
+		}
+		if severityOrder(matchSeverity(m, unknownAs)) < severityOrder(matchSeverity(result[i], unknownAs)) {
+			result[i] = m
+		}
+	}
+	return result
+}
+
+// isNotFixed reports whether grype says no fix is or will be available for
+// the match (fix state "not-fixed" or "wont-fix"), so it needs mitigation
+// rather than an upgrade. The "unknown" state is deliberately excluded.
+func isNotFixed(match GrypeMatch) bool {
+	switch strings.ToLower(match.Vulnerability.Fix.State) {
+	case "not-fixed", "wont-fix":
+		return true
+	default:
+		return false
+	}
+}
+
+// filterMatchesByTypes returns the matches whose package type (Artifact.Type)
+// is one of types, compared case-insensitively. It scopes the fail-build
+// decision for fail-on-types; the input slice is not modified.
+func filterMatchesByTypes(matches []GrypeMatch, types []string) []GrypeMatch {
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(t)] = true
+	}
+
+	filtered := make([]GrypeMatch, 0, len(matches))
+	for _, m := range matches {
+		if allowed[strings.ToLower(m.Artifact.Type)] {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// countByType groups matches by package type (Artifact.Type) and returns the
+// number of matches per type. Matches without a type are counted as "unknown".
+func countByType(matches []GrypeMatch) map[string]int {
+	counts := map[string]int{}
+	for _, m := range matches {
+		pkgType := m.Artifact.Type
+		if pkgType == "" {
+			pkgType = "unknown"
+		}
+		counts[pkgType]++
+	}
+	return counts
+}
+
+// typeOutputKey returns the GitHub Actions output name for a package type's
+// count, e.g. "cve-count-go-module". Characters other than lower-case
+// letters, digits, and hyphens are replaced by hyphens so every type yields a
+// valid, predictable output key.
+func typeOutputKey(pkgType string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(pkgType) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return "cve-count-" + b.String()
+}
+
+// severityCutoffs lists the severity-cutoff values shouldFail understands.
+var severityCutoffs = []string{"critical", "high", "medium", "low", "negligible"}
+
+// cutoffSynonyms lists the additional severity-cutoff values known from other
+// scanners: "all" and "any" fail on any finding, "none" never fails.
+var cutoffSynonyms = []string{"all", "any", "none"}
+
+// countAtOrAboveCutoff returns the number of vulnerabilities at or above the
+// cutoff severity; "negligible", "all", and "any" count every finding and
+// "none" counts none.
+func countAtOrAboveCutoff(stats VulnerabilityStats, cutoff string) int {
 	switch strings.ToLower(cutoff) {
 	case "critical":
-		return stats.Critical > 0
+		return stats.Critical
 	case "high":
-		return stats.Critical > 0 || stats.High > 0
+		return stats.Critical + stats.High
 	case "medium":
-		return stats.Critical > 0 || stats.High > 0 || stats.Medium > 0
+		return stats.Critical + stats.High + stats.Medium
 	case "low":
-		return stats.Critical > 0 || stats.High > 0 || stats.Medium > 0 || stats.Low > 0
-	case "negligible":
-		return stats.Total > 0
+		return stats.Critical + stats.High + stats.Medium + stats.Low
+	case "negligible", "all", "any":
+		return stats.Total
+	case "none":
+		return 0
 	default:
 		// Default to medium if cutoff is unknown
-		return stats.Critical > 0 || stats.High > 0 || stats.Medium > 0
+		return stats.Critical + stats.High + stats.Medium
+	}
+}
+
+// shouldFail determines if the build should fail based on vulnerability stats and severity cutoff.
+// Returns true if more than maxAllowed vulnerabilities at or above the cutoff
+// severity are found; with maxAllowed 0 (the default) any such finding fails.
+func shouldFail(stats VulnerabilityStats, cutoff string, maxAllowed int) bool {
+	return countAtOrAboveCutoff(stats, cutoff) > maxAllowed
+}
+
+// parseSeverityBudget parses the severity-budget input, e.g.
+// "critical=0,high=2,medium=5", into the maximum count allowed per severity.
+// Severities absent from the budget are unlimited; a value without entries
+// yields a nil budget, which leaves fail-build to severity-cutoff.
+func parseSeverityBudget(value string) (map[string]int, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	budget := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		severity, limit, ok := strings.Cut(entry, "=")
+		severity = strings.ToLower(strings.TrimSpace(severity))
+		if !ok || !containsString(severityCutoffs, severity) {
+			return nil, fmt.Errorf("invalid severity-budget entry %q: want <severity>=<count> with severity one of %s", entry, strings.Join(severityCutoffs, ", "))
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid severity-budget entry %q: count must be a non-negative integer", entry)
+		}
+		budget[severity] = count
+	}
+	if len(budget) == 0 {
+		return nil, nil
+	}
+	return budget, nil
+}
+
+// severityBudgetViolations lists each severity whose count exceeds its
+// budget, as "high: 3 > 2", in severityCutoffs order.
+func severityBudgetViolations(stats VulnerabilityStats, budget map[string]int) []string {
+	counts := map[string]int{
+		"critical":   stats.Critical,
+		"high":       stats.High,
+		"medium":     stats.Medium,
+		"low":        stats.Low,
+		"negligible": stats.Negligible,
+	}
+	var violations []string
+	for _, severity := range severityCutoffs {
+		if limit, ok := budget[severity]; ok && counts[severity] > limit {
+			violations = append(violations, fmt.Sprintf("%s: %d > %d", severity, counts[severity], limit))
+		}
+	}
+	return violations
+}
+
+// shouldFailBudget reports whether any severity's count exceeds its budget.
+// Severities absent from the budget never fail.
+func shouldFailBudget(stats VulnerabilityStats, budget map[string]int) bool {
+	return len(severityBudgetViolations(stats, budget)) > 0
+}
+
+// parseMaxAllowed parses the max-allowed input. An empty value selects 0,
+// which fails on any finding at or above the cutoff.
+func parseMaxAllowed(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	maxAllowed, err := strconv.Atoi(value)
+	if err != nil || maxAllowed < 0 {
+		return 0, fmt.Errorf("invalid max-allowed %q: must be a non-negative integer", value)
+	}
+	return maxAllowed, nil
+}
+
+// wouldFailOutputs evaluates shouldFail at every severity cutoff and returns
+// the would-fail-<severity> outputs ("true"/"false"), so downstream steps can
+// apply their own policy without the action failing the job. maxAllowed is
+// the max-allowed grace count applied at every cutoff.
+func wouldFailOutputs(stats VulnerabilityStats, maxAllowed int) map[string]string {
+	outputs := make(map[string]string, len(severityCutoffs))
+	for _, cutoff := range severityCutoffs {
+		outputs["would-fail-"+cutoff] = strconv.FormatBool(shouldFail(stats, cutoff, maxAllowed))
 	}
+	return outputs
 }
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDetermineScanTarget(t *testing.T) {
@@ -14,6 +22,22 @@ func TestDetermineScanTarget(t *testing.T) {
 	if err := os.WriteFile(tmpFile, []byte("test"), 0644); err != nil {
 		t.Fatal(err)
 	}
+	dockerArchive := writeTestTar(t, tmpDir, "docker.tar", false, "manifest.json", "repositories")
+	ociArchive := writeTestTar(t, tmpDir, "oci.tar.gz", true, "oci-layout", "index.json")
+	dualArchive := writeTestTar(t, tmpDir, "docker25.tar", false, "oci-layout", "index.json", "manifest.json")
+	plainArchive := writeTestTar(t, tmpDir, "plain.tar", false, "README.md")
+	ociDir := filepath.Join(tmpDir, "oci-image")
+	if err := os.MkdirAll(filepath.Join(ociDir, "blobs", "sha256"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"oci-layout": `{"imageLayoutVersion":"1.0.0"}`,
+		"index.json": `{"schemaVersion":2,"manifests":[]}`,
+	} {
+		if err := os.WriteFile(filepath.Join(ociDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	tests := []struct {
 		name       string
@@ -26,15 +50,31 @@ func TestDetermineScanTarget(t *testing.T) {
 		{"image mode invalid source", Config{Image: "alpine:latest", ImageSource: "invalid"}, "", true, "invalid image-source"},
 		{"path mode directory", Config{Path: tmpDir}, "dir:", false, ""},
 		{"path mode file", Config{Path: tmpFile}, "file:", false, ""},
+		{"path mode oci layout directory", Config{Path: ociDir}, "oci-dir:", false, ""},
 		{"sbom mode", Config{SBOM: "sbom.json"}, "sbom:", false, ""},
-		{"multiple artifact modes", Config{Image: "alpine", Path: tmpDir}, "", true, "only one of image, path, or sbom"},
+		{"image-archive from docker save", Config{ImageArchive: dockerArchive}, "docker-archive:", false, ""},
+		{"image-archive in oci layout", Config{ImageArchive: ociArchive}, "oci-archive:", false, ""},
+		{"image-archive with both markers", Config{ImageArchive: dualArchive}, "docker-archive:", false, ""},
+		{"image-archive without image markers", Config{ImageArchive: plainArchive}, "", true, "neither a docker-archive"},
+		{"image-archive that is not a tar", Config{ImageArchive: tmpFile}, "", true, "not a readable tar"},
+		{"image-archive not found", Config{ImageArchive: filepath.Join(tmpDir, "missing.tar")}, "", true, "not found"},
+		{"multiple artifact modes", Config{Image: "alpine", Path: tmpDir}, "", true, "only one of image, image-archive, path, or sbom"},
+		{"image with image-archive", Config{Image: "alpine", ImageArchive: dockerArchive}, "", true, "only one of"},
 		{"scan with artifact mode", Config{Scan: "head", Image: "alpine"}, "", true, "scan cannot be used together"},
+		{"repo-url with artifact mode", Config{RepoURL: "https://github.com/anchore/grype.git", Image: "alpine"}, "", true, "repo-url cannot be used together"},
+		{"scan-tags-glob with artifact mode", Config{ScanTagsGlob: "v1.*", Path: tmpDir}, "", true, "scan-tags-glob cannot be used together"},
+		{"scan-tags-glob with scan", Config{ScanTagsGlob: "v1.*", Scan: "head"}, "", true, "scan-tags-glob cannot be used together"},
 		{"path not found", Config{Path: "/nonexistent/path"}, "", true, "not found"},
+		{"sbom-content mode", Config{SBOMContent: `{"bomFormat":"CycloneDX"}`}, "sbom:", false, ""},
+		{"sbom-content with sbom", Config{SBOM: "sbom.json", SBOMContent: "{}"}, "", true, "cannot be used together with sbom"},
+		{"sbom-stdin with sbom-content", Config{SBOMStdin: true, SBOMContent: "{}"}, "", true, "only one of sbom-stdin or sbom-content"},
+		{"sbom-content with image", Config{Image: "alpine", SBOMContent: "{}"}, "", true, "only one of"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			target, _, err := determineScanTarget(tt.config)
+			target, tempDir, err := determineScanTarget(tt.config)
+			defer cleanupWorktree(tempDir)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("determineScanTarget() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -49,6 +89,146 @@ func TestDetermineScanTarget(t *testing.T) {
 	}
 }
 
+// TestDetermineScanTargetSubdir verifies that monorepos can scan a single
+// service folder, in head mode and at a checked-out ref, without escaping the
+// repository.
+//
+// This test covers scopeScanDir and the scan-subdir handling of
+// determineScanTarget and validateArtifactModes in scanner.go.
+//
+// It expects "dir:services/api" in head mode, the subdirectory of the
+// temporary worktree for a tag, and errors naming scan-subdir for missing,
+// absolute, and escaping paths and for artifact modes; a rejected subdir must
+// not leave the tag's worktree behind.
+func TestDetermineScanTargetSubdir(t *testing.T) {
+	repoDir := setupTestRepoWithTags(t)
+	oldWD, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(oldWD) })
+	if err := os.Chdir(repoDir); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join("services", "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	t.Run("scopes head mode", func(t *testing.T) {
+		target, _, err := determineScanTarget(Config{Scan: "head", ScanSubdir: "services/api"})
+		if err != nil || target != "dir:services/api" {
+			t.Errorf("determineScanTarget() = %q, %v, want dir:services/api", target, err)
+		}
+	})
+
+	t.Run("scopes a worktree checkout", func(t *testing.T) {
+		target, tempDir, err := determineScanTarget(Config{Scan: "v1.0.0", ScanSubdir: "."})
+		defer cleanupWorktree(tempDir)
+		if err != nil || tempDir == "" || target != "dir:"+tempDir {
+			t.Errorf("determineScanTarget() = %q, %q, %v, want the worktree", target, tempDir, err)
+		}
+	})
+
+	for name, config := range map[string]Config{
+		"rejects subdir missing at the ref": {Scan: "v1.0.0", ScanSubdir: "services/api"},
+		"rejects missing subdir":            {Scan: "head", ScanSubdir: "services/web"},
+		"rejects absolute subdir":           {Scan: "head", ScanSubdir: repoDir},
+		"rejects escaping subdir":           {Scan: "head", ScanSubdir: "../outside"},
+		"rejects artifact modes":            {Image: "alpine", ScanSubdir: "services/api"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := determineScanTarget(config); err == nil || !strings.Contains(err.Error(), "scan-subdir") {
+				t.Errorf("determineScanTarget() error = %v, want scan-subdir error", err)
+			}
+		})
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(tmpDir, "grype-scan-*")); len(leftovers) > 0 {
+		t.Errorf("temporary worktrees left behind: %v", leftovers)
+	}
+}
+
+// TestWriteInlineSBOM verifies that workflows can pipe an in-memory SBOM to
+// the action instead of writing a temp file first.
+//
+// This test covers writeInlineSBOM in scanner.go, used by
+// determineScanTarget for the sbom-stdin and sbom-content inputs.
+//
+// It asserts that SBOMs from stdin and from sbom-content are written verbatim
+// to a file inside the returned temp dir and targeted as "sbom:", that
+// cleanupWorktree removes that dir, and that an empty SBOM is rejected.
+func TestWriteInlineSBOM(t *testing.T) {
+	sbom := `{"bomFormat":"CycloneDX","specVersion":"1.5"}`
+
+	tests := []struct {
+		name   string
+		config Config
+		stdin  string
+	}{
+		{"reads sbom from stdin", Config{SBOMStdin: true}, sbom},
+		{"uses sbom-content", Config{SBOMContent: sbom}, "ignored"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, tempDir, err := writeInlineSBOM(tt.config, strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatalf("writeInlineSBOM() error = %v", err)
+			}
+			path, ok := strings.CutPrefix(target, "sbom:")
+			if !ok || filepath.Dir(path) != tempDir {
+				t.Fatalf("target = %q, want sbom: file inside %q", target, tempDir)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil || string(content) != sbom {
+				t.Errorf("SBOM file = %q, %v, want %q", content, err, sbom)
+			}
+
+			cleanupWorktree(tempDir)
+			if _, err := os.Stat(tempDir); !os.IsNotExist(err) {
+				t.Errorf("temp dir %q should be removed by cleanupWorktree", tempDir)
+			}
+		})
+	}
+
+	t.Run("rejects empty stdin", func(t *testing.T) {
+		_, tempDir, err := writeInlineSBOM(Config{SBOMStdin: true}, strings.NewReader("  \n"))
+		if err == nil || !strings.Contains(err.Error(), "empty") || tempDir != "" {
+			t.Errorf("writeInlineSBOM() = %q, %v, want empty SBOM error", tempDir, err)
+		}
+	})
+}
+
+// writeTestTar writes a tar archive (gzip-compressed if compress) containing
+// empty files with the given names and returns its path.
+func writeTestTar(t *testing.T, dir, name string, compress bool, files ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file, Mode: 0o644, Size: 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
 func TestCalculateStats(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -70,7 +250,7 @@ func TestCalculateStats(t *testing.T) {
 					makeMatch("CVE-4", "Low", "pkg4", "1.0", nil, "", ""),
 				},
 			},
-			want: VulnerabilityStats{Total: 4, Critical: 1, High: 1, Medium: 1, Low: 1},
+			want: VulnerabilityStats{Total: 4, Distinct: 4, Critical: 1, High: 1, Medium: 1, Low: 1},
 		},
 		{
 			name: "case insensitive",
@@ -80,13 +260,50 @@ func TestCalculateStats(t *testing.T) {
 					makeMatch("CVE-2", "high", "pkg2", "1.0", nil, "", ""),
 				},
 			},
-			want: VulnerabilityStats{Total: 2, Critical: 1, High: 1},
+			want: VulnerabilityStats{Total: 2, Distinct: 2, Critical: 1, High: 1},
+		},
+		{
+			name: "negligible apart from unknown",
+			output: &GrypeOutput{
+				Matches: []GrypeMatch{
+					makeMatch("CVE-1", "Negligible", "pkg1", "1.0", nil, "", ""),
+					makeMatch("CVE-2", "Unknown", "pkg2", "1.0", nil, "", ""),
+					makeMatch("CVE-3", "", "pkg3", "1.0", nil, "", ""),
+				},
+			},
+			want: VulnerabilityStats{Total: 3, Distinct: 3, Negligible: 1, Other: 2},
+		},
+		{
+			name: "counts fixable across fix states",
+			output: &GrypeOutput{
+				Matches: []GrypeMatch{
+					makeMatch("CVE-1", "Critical", "pkg1", "1.0", []string{"1.1"}, "", ""),
+					makeMatch("CVE-2", "Low", "pkg2", "1.0", []string{"2.0"}, "", ""),
+					withFixState(makeMatch("CVE-3", "High", "pkg3", "1.0", nil, "", ""), "not-fixed"),
+					withFixState(makeMatch("CVE-4", "High", "pkg4", "1.0", nil, "", ""), "wont-fix"),
+					withFixState(makeMatch("CVE-5", "Medium", "pkg5", "1.0", nil, "", ""), "unknown"),
+					withFixState(makeMatch("CVE-6", "Medium", "pkg6", "1.0", nil, "", ""), "Fixed"),
+				},
+			},
+			want: VulnerabilityStats{Total: 6, Distinct: 6, Critical: 1, High: 2, Medium: 2, Low: 1, Fixable: 3, NotFixed: 2},
+		},
+		{
+			name: "counts not-fixed and wont-fix but not unknown",
+			output: &GrypeOutput{
+				Matches: []GrypeMatch{
+					withFixState(makeMatch("CVE-1", "High", "pkg1", "1.0", nil, "", ""), "not-fixed"),
+					withFixState(makeMatch("CVE-2", "Low", "pkg2", "1.0", nil, "", ""), "Wont-Fix"),
+					withFixState(makeMatch("CVE-3", "Low", "pkg3", "1.0", nil, "", ""), "unknown"),
+					makeMatch("CVE-4", "Medium", "pkg4", "1.0", nil, "", ""),
+				},
+			},
+			want: VulnerabilityStats{Total: 4, Distinct: 4, High: 1, Medium: 1, Low: 2, NotFixed: 2},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calculateStats(tt.output)
+			got := calculateStats(tt.output, "", false)
 			if got != tt.want {
 				t.Errorf("calculateStats() = %+v, want %+v", got, tt.want)
 			}
@@ -94,6 +311,240 @@ func TestCalculateStats(t *testing.T) {
 	}
 }
 
+// TestCalculateStatsDedupeCVEs verifies that a CVE found in several packages
+// can count once, so the badge does not overstate the number of distinct
+// vulnerabilities to non-experts.
+//
+// This test covers the dedupe argument and the Distinct count of
+// calculateStats in scanner.go, fed from the dedupe-cves input, and the
+// distinct-cve-count output of setOutputs.
+//
+// It counts matches where CVE-1 affects three packages and asserts that
+// Distinct is 2 either way, while Total and the severity counts cover every
+// match without dedupe and each ID once with it, and that setOutputs writes
+// both cve-count and distinct-cve-count. A CVE reported with different
+// severities for different packages counts under its highest severity with
+// dedupe, in either match order.
+func TestCalculateStatsDedupeCVEs(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-1", "High", "openssl", "3.0.0", []string{"3.0.1"}, "", ""),
+		makeMatch("CVE-1", "High", "libssl", "3.0.0", []string{"3.0.1"}, "", ""),
+		makeMatch("CVE-2", "Low", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-1", "High", "openssl-dev", "3.0.0", []string{"3.0.1"}, "", ""),
+	}}
+
+	if got, want := calculateStats(output, "", false), (VulnerabilityStats{Total: 4, Distinct: 2, High: 3, Low: 1, Fixable: 3}); got != want {
+		t.Errorf("calculateStats() without dedupe = %+v, want %+v", got, want)
+	}
+	if got, want := calculateStats(output, "", true), (VulnerabilityStats{Total: 2, Distinct: 2, High: 1, Low: 1, Fixable: 1}); got != want {
+		t.Errorf("calculateStats() with dedupe = %+v, want %+v", got, want)
+	}
+
+	medium := makeMatch("CVE-3", "Medium", "curl", "8.0.0", nil, "", "")
+	critical := makeMatch("CVE-3", "Critical", "libcurl", "8.0.0", []string{"8.0.1"}, "", "")
+	unknown := makeMatch("CVE-3", "Unknown", "curl-dev", "8.0.0", nil, "", "")
+	mixed := []struct {
+		name      string
+		matches   []GrypeMatch
+		unknownAs string
+		want      VulnerabilityStats
+	}{
+		{"medium first", []GrypeMatch{medium, critical}, "", VulnerabilityStats{Total: 1, Distinct: 1, Critical: 1, Fixable: 1}},
+		{"critical first", []GrypeMatch{critical, medium}, "", VulnerabilityStats{Total: 1, Distinct: 1, Critical: 1, Fixable: 1}},
+		{"unknown below medium", []GrypeMatch{unknown, medium}, "", VulnerabilityStats{Total: 1, Distinct: 1, Medium: 1}},
+		{"unknown counted as critical", []GrypeMatch{medium, unknown}, "critical", VulnerabilityStats{Total: 1, Distinct: 1, Critical: 1}},
+	}
+	for _, tt := range mixed {
+		if got := calculateStats(&GrypeOutput{Matches: tt.matches}, tt.unknownAs, true); got != tt.want {
+			t.Errorf("%s: calculateStats() with dedupe = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	outFile := filepath.Join(t.TempDir(), "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+	if err := setOutputs(calculateStats(output, "", false), output, scanRunInfo{}, "", "image", badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil); err != nil {
+		t.Fatalf("setOutputs() error = %v", err)
+	}
+	content, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	for _, want := range []string{"cve-count=4\n", "distinct-cve-count=2\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("outputs = %q, want %q", content, want)
+		}
+	}
+}
+
+// TestCalculateStatsUnknownSeverityAs verifies that security-conservative
+// users can have findings without a severity count, and gate, as high.
+//
+// This test covers the unknownAs argument of calculateStats in scanner.go,
+// fed from the unknown-severity-as input.
+//
+// It asserts that empty and "unknown" severities move into the configured
+// bucket while unrecognized severity names stay in Other, and that the
+// remapped counts make fail-build trigger at the high cutoff.
+func TestCalculateStatsUnknownSeverityAs(t *testing.T) {
+	output := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-1", "Unknown", "pkg1", "1.0", nil, "", ""),
+		makeMatch("CVE-2", "", "pkg2", "1.0", nil, "", ""),
+		makeMatch("CVE-3", "Moderate", "pkg3", "1.0", nil, "", ""),
+		makeMatch("CVE-4", "Low", "pkg4", "1.0", nil, "", ""),
+	}}
+
+	if got, want := calculateStats(output, "", false), (VulnerabilityStats{Total: 4, Distinct: 4, Low: 1, Other: 3}); got != want {
+		t.Errorf("calculateStats() without remap = %+v, want %+v", got, want)
+	}
+
+	got := calculateStats(output, "high", false)
+	if want := (VulnerabilityStats{Total: 4, Distinct: 4, High: 2, Low: 1, Other: 1}); got != want {
+		t.Errorf("calculateStats() with unknown as high = %+v, want %+v", got, want)
+	}
+	if !shouldFail(got, "high", 0) {
+		t.Error("shouldFail() = false, want remapped unknowns to fail at the high cutoff")
+	}
+}
+
+// TestShouldFailMaxAllowed verifies that teams can tolerate a known number of
+// findings and still fail once that budget is exceeded.
+//
+// This test covers the maxAllowed argument of shouldFail and parseMaxAllowed
+// in scanner.go.
+//
+// It asserts that a count equal to max-allowed passes while one more fails,
+// that findings below the cutoff never count, that 0 keeps the original
+// behavior, and that negative or non-numeric values are rejected.
+func TestShouldFailMaxAllowed(t *testing.T) {
+	stats := VulnerabilityStats{Total: 5, Critical: 1, High: 2, Low: 2}
+	tests := []struct {
+		name       string
+		cutoff     string
+		maxAllowed int
+		want       bool
+	}{
+		{"zero fails on any finding", "high", 0, true},
+		{"count below threshold passes", "high", 4, false},
+		{"count equal to threshold passes", "high", 3, false},
+		{"count one above threshold fails", "high", 2, true},
+		{"lower severities do not count", "critical", 1, false},
+		{"negligible counts every finding", "negligible", 4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldFail(stats, tt.cutoff, tt.maxAllowed); got != tt.want {
+				t.Errorf("shouldFail(%s, %d) = %v, want %v", tt.cutoff, tt.maxAllowed, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("parses empty as zero", func(t *testing.T) {
+		if got, err := parseMaxAllowed(""); err != nil || got != 0 {
+			t.Errorf("parseMaxAllowed(\"\") = %d, %v, want 0", got, err)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		for _, value := range []string{"-1", "many"} {
+			if _, err := parseMaxAllowed(value); err == nil || !strings.Contains(err.Error(), "max-allowed") {
+				t.Errorf("parseMaxAllowed(%q) error = %v, want max-allowed error", value, err)
+			}
+		}
+	})
+}
+
+// TestShouldFailCutoffSynonyms verifies that users coming from other
+// scanners can write "all" or "any" to fail on every finding and "none" to
+// never fail, instead of silently gating at medium.
+//
+// This test covers shouldFail and countAtOrAboveCutoff in scanner.go.
+//
+// It asserts that all/any fail on a single negligible finding but pass on a
+// clean scan, and that none passes even with critical findings.
+func TestShouldFailCutoffSynonyms(t *testing.T) {
+	negligibleOnly := VulnerabilityStats{Total: 1, Negligible: 1}
+	critical := VulnerabilityStats{Total: 3, Critical: 2, High: 1}
+	tests := []struct {
+		name   string
+		stats  VulnerabilityStats
+		cutoff string
+		want   bool
+	}{
+		{"all fails on negligible finding", negligibleOnly, "all", true},
+		{"any fails on negligible finding", negligibleOnly, "any", true},
+		{"any is case insensitive", negligibleOnly, "ANY", true},
+		{"all passes clean scan", VulnerabilityStats{}, "all", false},
+		{"none passes critical findings", critical, "none", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldFail(tt.stats, tt.cutoff, 0); got != tt.want {
+				t.Errorf("shouldFail(%+v, %s) = %v, want %v", tt.stats, tt.cutoff, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestShouldFailBudget verifies that advanced users can allow a few findings
+// per severity, e.g. up to 2 high but no critical, instead of a single cutoff.
+//
+// This test covers parseSeverityBudget, severityBudgetViolations, and
+// shouldFailBudget in scanner.go.
+//
+// It asserts that counts within budget pass, that any severity over its
+// budget fails and is named, that absent severities are unlimited, and that
+// malformed entries are rejected while a blank input yields no budget.
+func TestShouldFailBudget(t *testing.T) {
+	budget, err := parseSeverityBudget(" critical=0, High=2 ,medium=5")
+	if err != nil {
+		t.Fatalf("parseSeverityBudget() error = %v", err)
+	}
+	if want := map[string]int{"critical": 0, "high": 2, "medium": 5}; !reflect.DeepEqual(budget, want) {
+		t.Fatalf("parseSeverityBudget() = %v, want %v", budget, want)
+	}
+
+	tests := []struct {
+		name  string
+		stats VulnerabilityStats
+		want  []string
+	}{
+		{"within budget", VulnerabilityStats{High: 2, Medium: 5}, nil},
+		{"one critical exceeds zero budget", VulnerabilityStats{Critical: 1}, []string{"critical: 1 > 0"}},
+		{"high one over budget", VulnerabilityStats{High: 3, Medium: 1}, []string{"high: 3 > 2"}},
+		{"several severities over budget", VulnerabilityStats{Critical: 1, Medium: 6}, []string{"critical: 1 > 0", "medium: 6 > 5"}},
+		{"absent severities are unlimited", VulnerabilityStats{Low: 100, Negligible: 50}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := severityBudgetViolations(tt.stats, budget)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("severityBudgetViolations() = %v, want %v", got, tt.want)
+			}
+			if fail := shouldFailBudget(tt.stats, budget); fail != (len(tt.want) > 0) {
+				t.Errorf("shouldFailBudget() = %v, want %v", fail, len(tt.want) > 0)
+			}
+		})
+	}
+
+	t.Run("blank input yields no budget", func(t *testing.T) {
+		for _, value := range []string{"", " , "} {
+			if got, err := parseSeverityBudget(value); err != nil || got != nil {
+				t.Errorf("parseSeverityBudget(%q) = %v, %v, want nil budget", value, got, err)
+			}
+		}
+	})
+
+	t.Run("rejects malformed entries", func(t *testing.T) {
+		for _, value := range []string{"high", "severe=1", "high=-1", "high=two"} {
+			if _, err := parseSeverityBudget(value); err == nil || !strings.Contains(err.Error(), "severity-budget") {
+				t.Errorf("parseSeverityBudget(%q) error = %v, want severity-budget error", value, err)
+			}
+		}
+	})
+}
+
 func TestShouldFail(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -113,7 +564,7 @@ func TestShouldFail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := shouldFail(tt.stats, tt.cutoff)
+			got := shouldFail(tt.stats, tt.cutoff, 0)
 			if got != tt.want {
 				t.Errorf("shouldFail() = %v, want %v", got, tt.want)
 			}
@@ -121,6 +572,35 @@ func TestShouldFail(t *testing.T) {
 	}
 }
 
+// TestWouldFailOutputs verifies that workflows can ask "would this fail at
+// high?" for every cutoff without the action failing the job.
+//
+// This test covers wouldFailOutputs in scanner.go, whose result processResults
+// adds to the step outputs.
+//
+// It evaluates a fixture with one high and two low findings and asserts one
+// would-fail-<severity> output per cutoff: false for critical, true for high
+// and everything below.
+func TestWouldFailOutputs(t *testing.T) {
+	got := wouldFailOutputs(VulnerabilityStats{Total: 3, High: 1, Low: 2}, 0)
+
+	want := map[string]string{
+		"would-fail-critical":   "false",
+		"would-fail-high":       "true",
+		"would-fail-medium":     "true",
+		"would-fail-low":        "true",
+		"would-fail-negligible": "true",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wouldFailOutputs() = %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+}
+
 func TestParseGrypeOutput(t *testing.T) {
 	tmpFile, err := os.CreateTemp("", "grype-output-*.json")
 	if err != nil {
@@ -134,7 +614,7 @@ func TestParseGrypeOutput(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	output, err := parseGrypeOutput(tmpFile.Name())
+	output, err := parseGrypeOutput(tmpFile.Name(), false)
 	if err != nil {
 		t.Fatalf("parseGrypeOutput() error = %v", err)
 	}
@@ -147,6 +627,154 @@ func TestParseGrypeOutput(t *testing.T) {
 	}
 }
 
+// TestParseGrypeOutputEmptyFile verifies that a grype crash before writing
+// results is reported in plain words instead of as a JSON syntax error.
+//
+// This test covers the empty-file check of parseGrypeOutput in scanner.go.
+//
+// It parses a zero-byte and a whitespace-only file and asserts an error that
+// says grype produced no output, then a truncated file and asserts the JSON
+// parse error is kept for malformed content.
+func TestParseGrypeOutputEmptyFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"reports zero-byte file as missing output", "", "grype produced no output"},
+		{"reports whitespace-only file as missing output", " \n", "grype produced no output"},
+		{"reports truncated json as parse error", `{"matches": [`, "failed to parse JSON"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("grype-%d.json", i))
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := parseGrypeOutput(path, false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseGrypeOutput() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParseGrypeOutputAllowPartial verifies that a grype run killed mid-write
+// still yields the findings it did write when users opt in, while strict
+// users keep the hard error.
+//
+// This test covers the allowPartial handling of parseGrypeOutput and
+// recoverPartialGrypeOutput in scanner.go.
+//
+// It truncates a document inside its third match and asserts that the two
+// complete matches are recovered only with allowPartial, that a warning is
+// printed, and that a file cut off before the matches array still fails.
+func TestParseGrypeOutputAllowPartial(t *testing.T) {
+	dir := t.TempDir()
+	truncated := filepath.Join(dir, "truncated.json")
+	content := `{"matches": [
+		{"vulnerability": {"id": "CVE-1", "severity": "Critical"}, "artifact": {"name": "openssl", "version": "3.0.0"}},
+		{"vulnerability": {"id": "CVE-2", "severity": "Low"}, "artifact": {"name": "zlib", "version": "1.2.11"}},
+		{"vulnerability": {"id": "CVE-3", "sever`
+	if err := os.WriteFile(truncated, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("fails without allow-partial", func(t *testing.T) {
+		if _, err := parseGrypeOutput(truncated, false); err == nil || !strings.Contains(err.Error(), "failed to parse JSON") {
+			t.Errorf("parseGrypeOutput() error = %v, want parse error", err)
+		}
+	})
+
+	t.Run("recovers complete matches with allow-partial", func(t *testing.T) {
+		var output *GrypeOutput
+		var err error
+		takeWarnings()
+		captureStdout(t, func() { output, err = parseGrypeOutput(truncated, true) })
+		logged := strings.Join(takeWarnings(), "\n")
+		if err != nil {
+			t.Fatalf("parseGrypeOutput() error = %v", err)
+		}
+		if len(output.Matches) != 2 || output.Matches[0].Vulnerability.ID != "CVE-1" || output.Matches[1].Vulnerability.ID != "CVE-2" {
+			t.Errorf("recovered matches = %+v, want CVE-1 and CVE-2", output.Matches)
+		}
+		if !strings.Contains(logged, "incomplete") {
+			t.Errorf("no warning about incomplete output, got %q", logged)
+		}
+	})
+
+	t.Run("fails when the matches array was never written", func(t *testing.T) {
+		early := filepath.Join(dir, "early.json")
+		if err := os.WriteFile(early, []byte(`{"source": {"type": "dire`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		captureStdout(t, func() { _, err = parseGrypeOutput(early, true) })
+		if err == nil {
+			t.Error("parseGrypeOutput() error = nil, want parse error without any matches")
+		}
+	})
+}
+
+// TestParseGrypeOutputIgnoredMatches verifies that users can reconcile the
+// action's totals with grype's CLI output when grype ignore rules apply.
+//
+// This test covers parsing of GrypeOutput.IgnoredMatches in types.go via
+// parseGrypeOutput in scanner.go, plus the ignored-count output and the
+// Ignored report row in output.go.
+//
+// It parses a document with one match and two ignored matches and asserts
+// that the ignored matches are kept separate from Matches and the counts,
+// reported as ignored-count=2, and listed as an Ignored summary row.
+func TestParseGrypeOutputIgnoredMatches(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "grype.json")
+	grypeJSON := `{
+		"matches": [{"vulnerability": {"id": "CVE-1", "severity": "High"}}],
+		"ignoredMatches": [
+			{"vulnerability": {"id": "CVE-2", "severity": "Critical"}, "appliedIgnoreRules": [{"vulnerability": "CVE-2"}]},
+			{"vulnerability": {"id": "CVE-3", "severity": "Low"}, "appliedIgnoreRules": [{"fix-state": "wont-fix"}]}
+		],
+		"descriptor": {"version": "0.106.0"}
+	}`
+	if err := os.WriteFile(path, []byte(grypeJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := parseGrypeOutput(path, false)
+	if err != nil {
+		t.Fatalf("parseGrypeOutput() error = %v", err)
+	}
+	if len(output.Matches) != 1 || len(output.IgnoredMatches) != 2 {
+		t.Fatalf("parsed %d matches and %d ignored, want 1 and 2", len(output.Matches), len(output.IgnoredMatches))
+	}
+
+	stats := calculateStats(output, "", false)
+	if stats.Total != 1 || stats.Critical != 0 {
+		t.Errorf("stats = %+v, want ignored matches excluded", stats)
+	}
+
+	outFile := filepath.Join(dir, "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+	if err := setOutputs(stats, output, scanRunInfo{}, "", "head", badgeOptions{Host: defaultBadgeHost}, defaultMatchesJSONLimit, "", "", nil); err != nil {
+		t.Fatalf("setOutputs() error = %v", err)
+	}
+	content, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "ignored-count=2") {
+		t.Errorf("outputs = %s, want ignored-count=2", content)
+	}
+
+	report := generateReportAt(output, stats, "head", reportOptions{}, time.Now())
+	if !strings.Contains(report, "| Ignored (grype ignore rules, not in total) | 2 |") {
+		t.Errorf("report missing Ignored row:\n%s", report)
+	}
+}
+
 func TestEndToEndWithPath(t *testing.T) {
 	if _, err := exec.LookPath("grype"); err != nil {
 		t.Skip("grype not installed")
@@ -159,8 +787,7 @@ func TestEndToEndWithPath(t *testing.T) {
 	}
 
 	config := Config{
-		Path:       tmpDir,
-		OutputFile: "",
+		Path: tmpDir,
 	}
 
 	target, _, err := determineScanTarget(config)
@@ -181,7 +808,7 @@ func TestEndToEndWithPath(t *testing.T) {
 		t.Fatalf("failed to close temp file: %v", err)
 	}
 
-	err = runGrypeScan(config, target, tmpFile.Name())
+	_, err = runGrypeScan(config, target, tmpFile.Name())
 	if err != nil {
 		t.Fatalf("runGrypeScan() error = %v", err)
 	}
@@ -190,3 +817,955 @@ func TestEndToEndWithPath(t *testing.T) {
 		t.Error("output file was not created")
 	}
 }
+
+// TestVersionInRange verifies that users can pin the Grype versions they trust
+// with a familiar range expression such as ">=0.100.0 <1.0.0".
+//
+// This test covers versionInRange in scanner.go, which backs the
+// expected-grype-range input.
+//
+// It checks in-range and out-of-range versions, exact matches, comma-separated
+// constraints, and that malformed constraints are rejected with an error.
+func TestVersionInRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		rangeExpr string
+		want      bool
+		wantErr   bool
+	}{
+		{"version inside range", "0.106.0", ">=0.100.0 <1.0.0", true, false},
+		{"version below range", "0.99.3", ">=0.100.0 <1.0.0", false, false},
+		{"version at exclusive upper bound", "1.0.0", ">=0.100.0 <1.0.0", false, false},
+		{"version at inclusive lower bound", "0.100.0", ">=0.100.0", true, false},
+		{"exact match without operator", "0.106.0", "0.106.0", true, false},
+		{"exact mismatch with equals", "0.106.1", "=0.106.0", false, false},
+		{"comma separated constraints", "0.110.0", ">0.100.0,<=0.110.0", true, false},
+		{"v-prefixed bound", "0.106.0", ">=v0.100.0", true, false},
+		{"unparsable grype version is out of range", "dev", ">=0.100.0", false, false},
+		{"returns error for empty range", "0.106.0", "  ", false, true},
+		{"returns error for invalid bound", "0.106.0", ">=latest", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := versionInRange(tt.version, tt.rangeExpr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("versionInRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("versionInRange(%q, %q) = %v, want %v", tt.version, tt.rangeExpr, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckGrypeVersionRange verifies that a Grype upgrade outside the pinned
+// range either warns or fails the run, depending on what the user asked for.
+//
+// This test covers checkGrypeVersionRange in scanner.go, which run() calls
+// after parsing the scan output.
+//
+// It asserts that an unset range and in-range versions pass, that an
+// out-of-range version only warns by default, and that strict mode turns the
+// mismatch into an error naming the offending version.
+func TestCheckGrypeVersionRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		version string
+		wantErr bool
+	}{
+		{"disabled when range is empty", Config{}, "0.50.0", false},
+		{"in range passes", Config{ExpectedGrypeRange: ">=0.100.0 <1.0.0"}, "0.106.0", false},
+		{"out of range only warns by default", Config{ExpectedGrypeRange: ">=0.100.0 <1.0.0"}, "1.2.0", false},
+		{"out of range fails in strict mode", Config{ExpectedGrypeRange: ">=0.100.0 <1.0.0", StrictGrypeRange: true}, "1.2.0", true},
+		{"invalid range always fails", Config{ExpectedGrypeRange: ">=abc"}, "0.106.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			captureStdout(t, func() { err = checkGrypeVersionRange(tt.config, tt.version) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkGrypeVersionRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && tt.config.StrictGrypeRange && !strings.Contains(err.Error(), tt.version) {
+				t.Errorf("error = %v, want it to mention version %q", err, tt.version)
+			}
+		})
+	}
+}
+
+// TestFilterMatchesByMaxSeverity verifies that a QA team can look only at the
+// low-severity long tail, hiding the severe findings they already triaged.
+//
+// This test covers filterMatchesByMaxSeverity in scanner.go, which backs the
+// max-severity input applied before stats and report generation.
+//
+// It filters a mixed set at "low" and asserts that only the low and
+// negligible matches remain, in their original order, and that the input
+// slice is left untouched.
+func TestFilterMatchesByMaxSeverity(t *testing.T) {
+	matches := []GrypeMatch{
+		makeMatch("CVE-1", "Critical", "pkg1", "1.0", nil, "", ""),
+		makeMatch("CVE-2", "Low", "pkg2", "1.0", nil, "", ""),
+		makeMatch("CVE-3", "High", "pkg3", "1.0", nil, "", ""),
+		makeMatch("CVE-4", "Negligible", "pkg4", "1.0", nil, "", ""),
+		makeMatch("CVE-5", "Medium", "pkg5", "1.0", nil, "", ""),
+	}
+
+	got := filterMatchesByMaxSeverity(matches, "low")
+
+	var ids []string
+	for _, m := range got {
+		ids = append(ids, m.Vulnerability.ID)
+	}
+	if strings.Join(ids, ",") != "CVE-2,CVE-4" {
+		t.Errorf("filterMatchesByMaxSeverity() kept %v, want [CVE-2 CVE-4]", ids)
+	}
+	if len(matches) != 5 || matches[0].Vulnerability.ID != "CVE-1" {
+		t.Error("filterMatchesByMaxSeverity should not modify the input slice")
+	}
+
+	if all := filterMatchesByMaxSeverity(matches, "critical"); len(all) != len(matches) {
+		t.Errorf("max-severity critical kept %d matches, want all %d", len(all), len(matches))
+	}
+}
+
+// TestFilterIgnoredPackages verifies that a team shipping a sandboxed but
+// vulnerable component can keep it out of the badge and gating, either
+// entirely or for one known version.
+//
+// This test covers filterIgnoredPackages in scanner.go, which backs the
+// ignore-packages input applied in processResults before calculateStats.
+//
+// It asserts that a plain name drops every version, that name@version drops
+// only that version, that scoped npm names keep their leading "@", and that
+// the suppressed count and input slice are correct.
+func TestFilterIgnoredPackages(t *testing.T) {
+	matches := []GrypeMatch{
+		makeMatch("CVE-1", "Critical", "sandboxed", "1.0", nil, "", ""),
+		makeMatch("CVE-2", "High", "sandboxed", "2.0", nil, "", ""),
+		makeMatch("CVE-3", "High", "zlib", "1.2.11", nil, "", ""),
+		makeMatch("CVE-4", "Medium", "zlib", "1.3.0", nil, "", ""),
+		makeMatch("CVE-5", "Low", "@scope/pkg", "1.0.0", nil, "", ""),
+		makeMatch("CVE-6", "Low", "openssl", "3.0.0", nil, "", ""),
+	}
+
+	got, suppressed := filterIgnoredPackages(matches, []string{"sandboxed", "zlib@1.2.11", "@scope/pkg@1.0.0"})
+
+	var ids []string
+	for _, m := range got {
+		ids = append(ids, m.Vulnerability.ID)
+	}
+	if strings.Join(ids, ",") != "CVE-4,CVE-6" {
+		t.Errorf("filterIgnoredPackages() kept %v, want [CVE-4 CVE-6]", ids)
+	}
+	if suppressed != 4 {
+		t.Errorf("filterIgnoredPackages() suppressed %d, want 4", suppressed)
+	}
+	if len(matches) != 6 || matches[0].Vulnerability.ID != "CVE-1" {
+		t.Error("filterIgnoredPackages should not modify the input slice")
+	}
+}
+
+// installFakeGrype puts an executable "grype" shell script with the given body
+// first on PATH for the duration of the test, so scan handling can be exercised
+// without a real grype binary.
+func installFakeGrype(t *testing.T, body string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "grype"), []byte(script), 0o755); err != nil { // #nosec G306 -- test helper must be executable
+		t.Fatalf("failed to write fake grype: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestParseScanTimeout verifies that the scan-timeout input accepts the Go
+// duration syntax users know from other actions and rejects nonsense early.
+//
+// This test covers parseScanTimeout in scanner.go, used by runGrypeScan.
+//
+// It asserts that empty and "0" disable the timeout, "10m" parses to ten
+// minutes, and malformed or negative values return an error.
+func TestParseScanTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"empty disables timeout", "", 0, false},
+		{"zero disables timeout", "0", 0, false},
+		{"parses minutes", "10m", 10 * time.Minute, false},
+		{"parses compound duration", "1h30m", 90 * time.Minute, false},
+		{"rejects missing unit", "10", 0, true},
+		{"rejects negative duration", "-5m", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScanTimeout(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScanTimeout(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseScanTimeout(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunGrypeScanTimeout verifies that a hanging grype no longer blocks the
+// workflow until the runner kills it.
+//
+// This test covers the scan-timeout handling of runGrypeScan in scanner.go.
+//
+// It installs a fake grype that sleeps far longer than the configured timeout
+// and asserts that runGrypeScan returns promptly with a timeout error, while
+// a fake grype that exits 1 after writing output (vulnerabilities found) is
+// still treated as success.
+func TestRunGrypeScanTimeout(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "out.json")
+
+	t.Run("kills hanging grype after timeout", func(t *testing.T) {
+		installFakeGrype(t, "sleep 30")
+
+		start := time.Now()
+		var err error
+		captureStdout(t, func() {
+			_, err = runGrypeScan(Config{ScanTimeout: "200ms"}, "dir:.", outputPath)
+		})
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatalf("runGrypeScan() error = %v, want timeout error", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("runGrypeScan() took %v, want prompt return after timeout", elapsed)
+		}
+	})
+
+	t.Run("keeps vulnerabilities-found exit code as success", func(t *testing.T) {
+		installFakeGrype(t, `echo '{"matches":[]}' > "$5"; exit 1`)
+
+		var err error
+		captureStdout(t, func() {
+			_, err = runGrypeScan(Config{ScanTimeout: "30s"}, "dir:.", outputPath)
+		})
+		if err != nil {
+			t.Errorf("runGrypeScan() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("rejects invalid timeout before running grype", func(t *testing.T) {
+		_, err := runGrypeScan(Config{ScanTimeout: "soon"}, "dir:.", outputPath)
+		if err == nil || !strings.Contains(err.Error(), "scan-timeout") {
+			t.Errorf("runGrypeScan() error = %v, want scan-timeout error", err)
+		}
+	})
+}
+
+// TestBuildGrypeArgs verifies that power users can hand grype their own
+// .grype.yaml and that a mistyped path fails loudly.
+//
+// This test covers buildGrypeArgs in scanner.go, which assembles the grype
+// command line for runGrypeScan.
+//
+// It asserts that "-c <path>" is included only when grype-config is set to an
+// existing file, that --add-cpes-if-none and --by-cve follow their inputs,
+// that --platform and --scope apply to image targets only, that an unknown
+// scan-scope is rejected,
+// that directory targets exclude ./.git/** unless include-git-dir is set, and
+// that a missing file or a directory yields an error naming the input.
+func TestBuildGrypeArgs(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".grype.yaml")
+	if err := os.WriteFile(configPath, []byte("ignore: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("omits -c without grype-config", func(t *testing.T) {
+		args, err := buildGrypeArgs("dir:.", "out.json", Config{})
+		if err != nil {
+			t.Fatalf("buildGrypeArgs() error = %v", err)
+		}
+		for _, arg := range args {
+			if arg == "-c" {
+				t.Errorf("args = %v, want no -c flag", args)
+			}
+		}
+	})
+
+	t.Run("passes existing grype-config via -c", func(t *testing.T) {
+		args, err := buildGrypeArgs("dir:.", "out.json", Config{GrypeConfig: configPath, OnlyFixed: true})
+		if err != nil {
+			t.Fatalf("buildGrypeArgs() error = %v", err)
+		}
+		want := []string{"dir:.", "-o", "json", "--file", "out.json", "-c", configPath, "--exclude", gitDirExclude, "--only-fixed"}
+		if strings.Join(args, " ") != strings.Join(want, " ") {
+			t.Errorf("args = %v, want %v", args, want)
+		}
+	})
+
+	t.Run("passes each exclude pattern in order", func(t *testing.T) {
+		args, err := buildGrypeArgs("dir:.", "out.json", Config{Exclude: []string{"./vendor/**", "**/testdata/**"}})
+		if err != nil {
+			t.Fatalf("buildGrypeArgs() error = %v", err)
+		}
+		want := []string{"dir:.", "-o", "json", "--file", "out.json", "--exclude", "./vendor/**", "--exclude", "**/testdata/**", "--exclude", gitDirExclude}
+		if strings.Join(args, " ") != strings.Join(want, " ") {
+			t.Errorf("args = %v, want %v", args, want)
+		}
+	})
+
+	t.Run("excludes .git for directory targets by default", func(t *testing.T) {
+		countGitExcludes := func(args []string) int {
+			count := 0
+			for i := 1; i < len(args); i++ {
+				if args[i-1] == "--exclude" && args[i] == gitDirExclude {
+					count++
+				}
+			}
+			return count
+		}
+		tests := []struct {
+			name   string
+			target string
+			config Config
+			want   int
+		}{
+			{"directory target", "dir:.", Config{}, 1},
+			{"include-git-dir set", "dir:.", Config{IncludeGitDir: true}, 0},
+			{"user already excludes .git", "dir:.", Config{Exclude: []string{gitDirExclude}}, 1},
+			{"image target", "alpine:3.19", Config{}, 0},
+			{"sbom target", "sbom:sbom.json", Config{}, 0},
+		}
+		for _, tt := range tests {
+			args, err := buildGrypeArgs(tt.target, "out.json", tt.config)
+			if err != nil {
+				t.Fatalf("%s: buildGrypeArgs() error = %v", tt.name, err)
+			}
+			if got := countGitExcludes(args); got != tt.want {
+				t.Errorf("%s: args = %v, want %d --exclude %s", tt.name, args, tt.want, gitDirExclude)
+			}
+		}
+	})
+
+	t.Run("passes platform for image targets only", func(t *testing.T) {
+		config := Config{Platform: "linux/amd64"}
+		tests := map[string]bool{
+			"alpine:3.19":            true,
+			"registry:ghcr.io/o/i:1": true,
+			"dir:.":                  false,
+			"file:app.tar":           false,
+			"sbom:sbom.json":         false,
+		}
+		for target, wantPlatform := range tests {
+			var args []string
+			var err error
+			captureStdout(t, func() { args, err = buildGrypeArgs(target, "out.json", config) })
+			if err != nil {
+				t.Fatalf("buildGrypeArgs(%q) error = %v", target, err)
+			}
+			got := strings.Contains(strings.Join(args, " "), "--platform linux/amd64")
+			if got != wantPlatform {
+				t.Errorf("buildGrypeArgs(%q) = %v, want --platform present = %v", target, args, wantPlatform)
+			}
+		}
+	})
+
+	t.Run("passes scan-scope for image targets only", func(t *testing.T) {
+		for _, scope := range []string{"squashed", "all-layers"} {
+			tests := map[string]bool{
+				"alpine:3.19":    true,
+				"dir:.":          false,
+				"sbom:sbom.json": false,
+			}
+			for target, wantScope := range tests {
+				var args []string
+				var err error
+				captureStdout(t, func() { args, err = buildGrypeArgs(target, "out.json", Config{ScanScope: scope}) })
+				if err != nil {
+					t.Fatalf("buildGrypeArgs(%q) error = %v", target, err)
+				}
+				got := strings.Contains(strings.Join(args, " "), "--scope "+scope)
+				if got != wantScope {
+					t.Errorf("buildGrypeArgs(%q) with scope %s = %v, want --scope present = %v", target, scope, args, wantScope)
+				}
+			}
+		}
+		args, err := buildGrypeArgs("alpine:3.19", "out.json", Config{})
+		if err != nil {
+			t.Fatalf("buildGrypeArgs() error = %v", err)
+		}
+		if strings.Contains(strings.Join(args, " "), "--scope") {
+			t.Errorf("args = %v, want no --scope without scan-scope", args)
+		}
+		for scope, wantErr := range map[string]bool{"": false, "squashed": false, "all-layers": false, "layers": true} {
+			if err := validateScanScope(scope); (err != nil) != wantErr {
+				t.Errorf("validateScanScope(%q) error = %v, want error = %v", scope, err, wantErr)
+			}
+		}
+	})
+
+	t.Run("passes by-cve only when by-cve is set", func(t *testing.T) {
+		for byCVE, want := range map[bool]bool{true: true, false: false} {
+			args, err := buildGrypeArgs("dir:.", "out.json", Config{ByCVE: byCVE})
+			if err != nil {
+				t.Fatalf("buildGrypeArgs() error = %v", err)
+			}
+			if got := strings.Contains(strings.Join(args, " "), "--by-cve"); got != want {
+				t.Errorf("ByCVE=%v: args = %v, want --by-cve present = %v", byCVE, args, want)
+			}
+		}
+	})
+
+	t.Run("passes add-cpes-if-none only when add-cpes is set", func(t *testing.T) {
+		for addCPEs, want := range map[bool]bool{true: true, false: false} {
+			args, err := buildGrypeArgs("dir:.", "out.json", Config{AddCPEs: addCPEs})
+			if err != nil {
+				t.Fatalf("buildGrypeArgs() error = %v", err)
+			}
+			if got := strings.Contains(strings.Join(args, " "), "--add-cpes-if-none"); got != want {
+				t.Errorf("AddCPEs=%v: args = %v, want --add-cpes-if-none present = %v", addCPEs, args, want)
+			}
+		}
+	})
+
+	for name, path := range map[string]string{
+		"rejects missing grype-config": filepath.Join(dir, "missing.yaml"),
+		"rejects directory as config":  dir,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildGrypeArgs("dir:.", "out.json", Config{GrypeConfig: path})
+			if err == nil || !strings.Contains(err.Error(), "grype-config") {
+				t.Errorf("buildGrypeArgs() error = %v, want grype-config error", err)
+			}
+		})
+	}
+}
+
+// TestCountByType verifies that workflows can branch on vulnerabilities in a
+// specific ecosystem, such as Go modules, while ignoring OS package noise.
+//
+// This test covers countByType and typeOutputKey in scanner.go and
+// formatTypeBreakdown in output.go, which back the cve-count-<type> outputs
+// and the per-type line of printSummary.
+//
+// It groups mixed-type matches and asserts the per-type counts, that a
+// missing type counts as "unknown", that output keys are sanitized, and
+// that the summary breakdown lists the most affected type first.
+func TestCountByType(t *testing.T) {
+	typed := func(id, pkgType string) GrypeMatch {
+		m := makeMatch(id, "High", "pkg", "1.0", nil, "", "")
+		m.Artifact.Type = pkgType
+		return m
+	}
+	matches := []GrypeMatch{
+		typed("CVE-1", "go-module"),
+		typed("CVE-2", "npm"),
+		typed("CVE-3", "go-module"),
+		typed("CVE-4", "deb"),
+		typed("CVE-5", ""),
+	}
+
+	counts := countByType(matches)
+	want := map[string]int{"go-module": 2, "npm": 1, "deb": 1, "unknown": 1}
+	if len(counts) != len(want) {
+		t.Errorf("countByType() = %v, want %v", counts, want)
+	}
+	for pkgType, n := range want {
+		if counts[pkgType] != n {
+			t.Errorf("countByType()[%q] = %d, want %d", pkgType, counts[pkgType], n)
+		}
+	}
+
+	keys := map[string]string{
+		"go-module":    "cve-count-go-module",
+		"java-archive": "cve-count-java-archive",
+		"Binary":       "cve-count-binary",
+		"php/composer": "cve-count-php-composer",
+	}
+	for pkgType, wantKey := range keys {
+		if got := typeOutputKey(pkgType); got != wantKey {
+			t.Errorf("typeOutputKey(%q) = %q, want %q", pkgType, got, wantKey)
+		}
+	}
+
+	if got := formatTypeBreakdown(counts); got != "go-module 2 | deb 1 | npm 1 | unknown 1" {
+		t.Errorf("formatTypeBreakdown() = %q", got)
+	}
+}
+
+// TestProcessResultsFailOnTypes verifies that teams can fail the build only
+// for vulnerabilities in the ecosystems they own, while the report and
+// outputs still show everything.
+//
+// This test covers filterMatchesByTypes in scanner.go and its use for the
+// fail-build decision in processResults (main.go).
+//
+// It scans a critical deb finding and a low go-module finding with
+// fail-on-types "go-module" and asserts the build passes while the critical
+// count is still reported; adding a high go-module finding makes it fail.
+func TestProcessResultsFailOnTypes(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+
+	typed := func(id, severity, pkgType string) GrypeMatch {
+		m := makeMatch(id, severity, "pkg-"+id, "1.0", nil, "", "")
+		m.Artifact.Type = pkgType
+		return m
+	}
+	config := Config{FailBuild: true, SeverityCutoff: "high", FailOnTypes: []string{"Go-Module", "npm"}}
+
+	output := &GrypeOutput{Matches: []GrypeMatch{typed("CVE-1", "Critical", "deb"), typed("CVE-2", "Low", "go-module")}}
+	var err error
+	captureStdout(t, func() { _, err = processResults(config, output, nil, scanRunInfo{}) })
+	if err != nil {
+		t.Fatalf("processResults() error = %v, want nil (deb findings are out of scope)", err)
+	}
+	content, readErr := os.ReadFile(outFile)
+	if readErr != nil {
+		t.Fatalf("ReadFile() error = %v", readErr)
+	}
+	if !strings.Contains(string(content), "critical=1") {
+		t.Errorf("outputs should still report the deb finding:\n%s", content)
+	}
+
+	output = &GrypeOutput{Matches: []GrypeMatch{typed("CVE-1", "Critical", "deb"), typed("CVE-3", "High", "go-module")}}
+	captureStdout(t, func() { _, err = processResults(config, output, nil, scanRunInfo{}) })
+	if err == nil || !strings.Contains(err.Error(), "in Go-Module, npm packages") {
+		t.Errorf("processResults() error = %v, want failure scoped to listed types", err)
+	}
+}
+
+// TestIsCachedDBFresh verifies that workflows persisting the grype DB with
+// actions/cache skip the download while the cached copy is recent enough.
+//
+// This test covers isCachedDBFresh, cachedDBBuilt, parseDBMaxAge, and grypeEnv
+// in scanner.go, used by run before updateGrypeDB.
+//
+// It writes schema v5 and v6 metadata into a temp cache dir and asserts that
+// a database younger than db-max-age is fresh, an older or missing one is
+// not, an invalid db-max-age is an error, and grype subprocesses receive
+// GRYPE_DB_CACHE_DIR pointing at the configured directory.
+func TestIsCachedDBFresh(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	writeMetadata := func(t *testing.T, dir, schema, name, built string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, schema), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		content := fmt.Sprintf(`{"built":%q}`, built)
+		if err := os.WriteFile(filepath.Join(dir, schema, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	freshDir := t.TempDir()
+	writeMetadata(t, freshDir, "5", "metadata.json", "2026-03-01T00:00:00Z")
+	writeMetadata(t, freshDir, "6", "import.json", "2026-03-10T06:00:00.123Z")
+
+	staleDir := t.TempDir()
+	writeMetadata(t, staleDir, "6", "import.json", "2026-03-08T12:00:00Z")
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    bool
+		wantErr bool
+	}{
+		{"is not fresh without db-cache-dir", Config{}, false, false},
+		{"uses newest schema metadata within default max age", Config{DBCacheDir: freshDir}, true, false},
+		{"is not fresh beyond db-max-age", Config{DBCacheDir: staleDir, DBMaxAge: "24h"}, false, false},
+		{"is fresh within a longer db-max-age", Config{DBCacheDir: staleDir, DBMaxAge: "72h"}, true, false},
+		{"is not fresh for an empty cache", Config{DBCacheDir: t.TempDir()}, false, false},
+		{"rejects invalid db-max-age", Config{DBCacheDir: freshDir, DBMaxAge: "soon"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			var err error
+			captureStdout(t, func() { got, err = isCachedDBFresh(tt.config, now) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isCachedDBFresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("isCachedDBFresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("passes db-cache-dir to grype", func(t *testing.T) {
+		env := grypeEnv(Config{DBCacheDir: freshDir})
+		if last := env[len(env)-1]; last != "GRYPE_DB_CACHE_DIR="+freshDir {
+			t.Errorf("last env entry = %q, want GRYPE_DB_CACHE_DIR override", last)
+		}
+	})
+}
+
+// TestRunGrypeScanRegistryCredentials verifies that private registry images
+// can be scanned without the credentials ever showing up in the logs.
+//
+// This test covers the registry credentials of grypeEnv in scanner.go, as
+// applied to the grype command by runGrypeScan, and the registry validation
+// of validateConfig in config.go.
+//
+// It runs a fake grype that dumps its environment and asserts that
+// GRYPE_REGISTRY_AUTH_AUTHORITY, _USERNAME, and _PASSWORD carry the inputs,
+// that the authority is omitted without registry-server, that nothing is set
+// without credentials, that the action's console output never contains the
+// values, and that a username without a password is rejected.
+func TestRunGrypeScanRegistryCredentials(t *testing.T) {
+	installFakeGrype(t, `env > "$5"`)
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    []string
+		notWant []string
+	}{
+		{"passes credentials with authority", Config{RegistryServer: "ghcr.io", RegistryUsername: "robot", RegistryPassword: "s3cret"},
+			[]string{"GRYPE_REGISTRY_AUTH_AUTHORITY=ghcr.io", "GRYPE_REGISTRY_AUTH_USERNAME=robot", "GRYPE_REGISTRY_AUTH_PASSWORD=s3cret"}, nil},
+		{"omits authority without registry-server", Config{RegistryUsername: "robot", RegistryPassword: "s3cret"},
+			[]string{"GRYPE_REGISTRY_AUTH_USERNAME=robot"}, []string{"GRYPE_REGISTRY_AUTH_AUTHORITY="}},
+		{"sets nothing without credentials", Config{},
+			nil, []string{"GRYPE_REGISTRY_AUTH_"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "out.json")
+			var err error
+			stdout := captureStdout(t, func() { _, err = runGrypeScan(tt.config, "registry:ghcr.io/o/private:1", outputPath) })
+			if err != nil {
+				t.Fatalf("runGrypeScan() error = %v", err)
+			}
+			env, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(env), want+"\n") {
+					t.Errorf("grype environment missing %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(string(env), notWant) {
+					t.Errorf("grype environment unexpectedly contains %q", notWant)
+				}
+			}
+			if strings.Contains(stdout, "robot") || strings.Contains(stdout, "s3cret") {
+				t.Errorf("console output leaks registry credentials: %q", stdout)
+			}
+		})
+	}
+
+	t.Run("rejects username without password", func(t *testing.T) {
+		err := validateConfig(Config{SeverityCutoff: "medium", BadgeHost: defaultBadgeHost, RegistryUsername: "robot"})
+		if err == nil || !strings.Contains(err.Error(), "registry-password") {
+			t.Errorf("validateConfig() error = %v, want registry-password error", err)
+		}
+	})
+}
+
+// TestRunGrypeScanErrorCategories verifies that users get targeted guidance
+// depending on whether grype is missing, crashed, or silently wrote nothing.
+//
+// This test covers GrypeError and the failure classification of runGrypeScan
+// in scanner.go, which run inspects via errors.As to print a hint.
+//
+// It runs with an empty PATH and with fake grype scripts and asserts the
+// NotFound, ExecFailure (with exit code), and NoOutput categories, plus that a
+// non-zero exit with written results still counts as a successful scan. The
+// returned exit code, which feeds the grype-exit-code output, must be grype's
+// own code (1 for findings) and -1 when grype never ran.
+func TestRunGrypeScanErrorCategories(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string // fake grype body; empty means grype is not installed
+		wantCategory GrypeErrorCategory
+		wantExitCode int
+		wantErr      bool
+	}{
+		{"reports missing grype as not found", "", GrypeNotFound, -1, true},
+		{"reports crash without output as exec failure", "exit 2", GrypeExecFailure, 2, true},
+		{"reports success without output as no output", "exit 0", GrypeNoOutput, 0, true},
+		{"accepts non-zero exit with written results", `echo '{"matches":[]}' > "$5"; exit 1`, 0, 1, false},
+		{"accepts clean exit with written results", `echo '{"matches":[]}' > "$5"`, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PATH", t.TempDir())
+			if tt.script != "" {
+				installFakeGrype(t, tt.script)
+			}
+			outputPath := filepath.Join(t.TempDir(), "out.json")
+			if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			var exitCode int
+			var err error
+			captureStdout(t, func() { exitCode, err = runGrypeScan(Config{}, "dir:.", outputPath) })
+			if exitCode != tt.wantExitCode {
+				t.Errorf("runGrypeScan() exit code = %d, want %d", exitCode, tt.wantExitCode)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("runGrypeScan() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var grypeErr *GrypeError
+			if !errors.As(err, &grypeErr) {
+				t.Fatalf("runGrypeScan() error = %v, want *GrypeError", err)
+			}
+			if grypeErr.Category != tt.wantCategory || grypeErr.ExitCode != tt.wantExitCode {
+				t.Errorf("GrypeError = {%v, %d}, want {%v, %d}", grypeErr.Category, grypeErr.ExitCode, tt.wantCategory, tt.wantExitCode)
+			}
+			if grypeErr.Hint() == "" {
+				t.Error("GrypeError.Hint() is empty, want guidance")
+			}
+		})
+	}
+}
+
+// TestRunGrypeScanDBLockRetry verifies that a briefly locked vulnerability
+// database in parallel CI does not fail the scan, while real failures still
+// fail immediately.
+//
+// This test covers the database-lock retry of runGrypeScan in scanner.go
+// with a fake grype that counts its invocations.
+//
+// It asserts that a scan failing once with "database is locked" succeeds on
+// the retry, that a persistent lock gives up after grypeDBLockRetries
+// retries, and that other failures run grype only once.
+func TestRunGrypeScanDBLockRetry(t *testing.T) {
+	orig := grypeDBLockRetryDelay
+	grypeDBLockRetryDelay = 0
+	t.Cleanup(func() { grypeDBLockRetryDelay = orig })
+
+	tests := []struct {
+		name      string
+		script    string
+		wantRuns  int
+		wantError bool
+	}{
+		{"retries once locked then succeeds", `[ "$(wc -l < "$RUNS")" -eq 1 ] && { echo "failed to load vulnerability db: database is locked" >&2; exit 1; }
+echo '{"matches":[]}' > "$5"`, 2, false},
+		{"gives up on persistent lock", `echo "database is locked" >&2; exit 1`, 1 + grypeDBLockRetries, true},
+		{"does not retry other failures", `echo "unsupported source" >&2; exit 2`, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := filepath.Join(t.TempDir(), "runs")
+			t.Setenv("RUNS", runs)
+			installFakeGrype(t, `echo run >> "$RUNS"
+`+tt.script)
+			outputPath := filepath.Join(t.TempDir(), "out.json")
+			if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			captureStdout(t, func() { _, err = runGrypeScan(Config{}, "dir:.", outputPath) })
+			if (err != nil) != tt.wantError {
+				t.Errorf("runGrypeScan() error = %v, want error %v", err, tt.wantError)
+			}
+			data, _ := os.ReadFile(runs)
+			if got := strings.Count(string(data), "run"); got != tt.wantRuns {
+				t.Errorf("grype ran %d times, want %d", got, tt.wantRuns)
+			}
+		})
+	}
+}
+
+// TestTimedScanDuration verifies that performance-conscious users get the
+// real wall-clock time of a scan to track over time.
+//
+// This test covers timed and formatSeconds in scanner.go, which executeScan
+// wraps around runGrypeScan for the scan-duration-seconds output.
+//
+// It times a fake grype that sleeps 200ms and asserts the duration covers the
+// sleep and the scan's error is passed through, then asserts seconds are
+// rendered with one decimal.
+func TestTimedScanDuration(t *testing.T) {
+	installFakeGrype(t, `sleep 0.2; echo '{"matches":[]}' > "$5"`)
+	outputPath := filepath.Join(t.TempDir(), "out.json")
+
+	var duration time.Duration
+	var err error
+	captureStdout(t, func() {
+		duration, err = timed(func() error {
+			_, scanErr := runGrypeScan(Config{}, "dir:.", outputPath)
+			return scanErr
+		})
+	})
+	if err != nil {
+		t.Fatalf("timed() error = %v", err)
+	}
+	if duration < 200*time.Millisecond {
+		t.Errorf("timed() = %s, want at least 200ms", duration)
+	}
+
+	wantErr := errors.New("scan failed")
+	if _, err := timed(func() error { return wantErr }); err != wantErr {
+		t.Errorf("timed() error = %v, want %v", err, wantErr)
+	}
+
+	if got := formatSeconds(12345 * time.Millisecond); got != "12.3" {
+		t.Errorf("formatSeconds() = %q, want 12.3", got)
+	}
+}
+
+// TestGenerateSBOM verifies that users can archive the SBOM of exactly what
+// was scanned next to the vulnerability results.
+//
+// This test covers generateSBOM and buildGrypeArgsWithFormat in scanner.go,
+// called from executeScan when sbom-output is set.
+//
+// It uses a fake grype that records its output format and asserts that the
+// SBOM is requested as cyclonedx-json for the scan target and written to the
+// workspace-relative sbom-output path, that an empty sbom-output skips grype,
+// that paths escaping the workspace are rejected, and that a hanging SBOM
+// pass is aborted after scan-timeout.
+func TestGenerateSBOM(t *testing.T) {
+	installFakeGrype(t, `echo "{\"target\":\"$1\",\"format\":\"$3\"}" > "$5"`)
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+
+	t.Run("writes cyclonedx sbom to the workspace", func(t *testing.T) {
+		var err error
+		captureStdout(t, func() { err = generateSBOM(Config{SBOMOutput: "out/sbom.cdx.json"}, "dir:.") })
+		if err != nil {
+			t.Fatalf("generateSBOM() error = %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(workspace, "out", "sbom.cdx.json"))
+		if err != nil {
+			t.Fatalf("sbom-output not written: %v", err)
+		}
+		if got := strings.TrimSpace(string(data)); got != `{"target":"dir:.","format":"cyclonedx-json"}` {
+			t.Errorf("sbom-output = %s, want cyclonedx-json of dir:.", got)
+		}
+	})
+
+	t.Run("skips grype without sbom-output", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		if err := generateSBOM(Config{}, "dir:."); err != nil {
+			t.Errorf("generateSBOM() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("rejects sbom-output outside the workspace", func(t *testing.T) {
+		var err error
+		captureStdout(t, func() { err = generateSBOM(Config{SBOMOutput: "../sbom.json"}, "dir:.") })
+		if err == nil || !strings.Contains(err.Error(), "sbom-output") {
+			t.Errorf("generateSBOM() error = %v, want sbom-output path error", err)
+		}
+	})
+
+	t.Run("kills hanging grype after scan-timeout", func(t *testing.T) {
+		installFakeGrype(t, "sleep 30")
+
+		start := time.Now()
+		var err error
+		captureStdout(t, func() { err = generateSBOM(Config{SBOMOutput: "sbom.json", ScanTimeout: "200ms"}, "dir:.") })
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatalf("generateSBOM() error = %v, want timeout error", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("generateSBOM() took %v, want prompt return after timeout", elapsed)
+		}
+	})
+}
+
+// TestGenerateTemplateOutput verifies that users can get grype's results in a
+// fully custom shape from their own Go template, while the action keeps using
+// its JSON scan for stats and badges.
+//
+// This test covers generateTemplateOutput and validateTemplateFile in
+// scanner.go, called from executeScan and validateConfig when template-file
+// is set.
+//
+// It uses a fake grype that echoes its arguments and asserts that the
+// template is rendered with "-o template" and "-t <file>" for the scan target
+// and copied verbatim to output-file, and that a hanging pass is aborted
+// after scan-timeout. It also asserts that template-file without
+// output-file, a missing template, or a directory is rejected.
+func TestGenerateTemplateOutput(t *testing.T) {
+	installFakeGrype(t, `echo "rendered: $*" > "$5"`)
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	templatePath := filepath.Join(t.TempDir(), "report.tmpl")
+	if err := os.WriteFile(templatePath, []byte("{{range .Matches}}{{.Vulnerability.ID}}{{end}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("renders the template into output-file", func(t *testing.T) {
+		config := Config{TemplateFile: templatePath, OutputFiles: []string{"out/report.html"}}
+		if err := validateTemplateFile(config); err != nil {
+			t.Fatalf("validateTemplateFile() error = %v", err)
+		}
+		var err error
+		captureStdout(t, func() { err = generateTemplateOutput(config, "dir:.") })
+		if err != nil {
+			t.Fatalf("generateTemplateOutput() error = %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(workspace, "out", "report.html"))
+		if err != nil {
+			t.Fatalf("output-file not written: %v", err)
+		}
+		got := string(data)
+		for _, want := range []string{"rendered: dir:. -o template", "-t " + templatePath} {
+			if !strings.Contains(got, want) {
+				t.Errorf("output-file = %q, want to contain %q", got, want)
+			}
+		}
+	})
+
+	t.Run("kills hanging grype after scan-timeout", func(t *testing.T) {
+		installFakeGrype(t, "sleep 30")
+
+		start := time.Now()
+		var err error
+		config := Config{TemplateFile: templatePath, OutputFiles: []string{"out/report.html"}, ScanTimeout: "200ms"}
+		captureStdout(t, func() { err = generateTemplateOutput(config, "dir:.") })
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatalf("generateTemplateOutput() error = %v, want timeout error", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("generateTemplateOutput() took %v, want prompt return after timeout", elapsed)
+		}
+	})
+
+	t.Run("skips grype without template-file", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		if err := generateTemplateOutput(Config{OutputFiles: []string{"results.json"}}, "dir:."); err != nil {
+			t.Errorf("generateTemplateOutput() error = %v, want nil", err)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		config Config
+		errMsg string
+	}{
+		{"rejects template-file without output-file", Config{TemplateFile: templatePath}, "requires exactly one output-file"},
+		{"rejects template-file with several output-files", Config{TemplateFile: templatePath, OutputFiles: []string{"a.txt", "b.txt"}}, "requires exactly one output-file"},
+		{"rejects missing template", Config{TemplateFile: filepath.Join(workspace, "missing.tmpl"), OutputFiles: []string{"out.txt"}}, "not found"},
+		{"rejects directory as template", Config{TemplateFile: workspace, OutputFiles: []string{"out.txt"}}, "is a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTemplateFile(tt.config); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateTemplateFile() error = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
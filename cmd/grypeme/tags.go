@@ -0,0 +1,282 @@
+// Package main provides scanning of every release tag matching a glob.
+// Each tag is scanned in its own temporary worktree, its results are saved
+// per tag, and the worst release (or, with aggregate-mode sum, all releases
+// together) drives the badge, outputs, and gating.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultScanTagsDir is the directory per-tag results are written to when
+// scan-tags-dir is not set.
+const defaultScanTagsDir = "grype-tags"
+
+// Aggregate modes select how the per-tag results of scan-tags-glob combine
+// into the results that drive the badge, outputs, and fail-build decision.
+const (
+	aggregateModeMax = "max" // the worst single tag (see worseStats)
+	aggregateModeSum = "sum" // the findings of all tags added up
+)
+
+// validateAggregateMode rejects aggregate-mode values other than max and sum;
+// an empty value selects max.
+func validateAggregateMode(mode string) error {
+	switch mode {
+	case "", aggregateModeMax, aggregateModeSum:
+		return nil
+	}
+	return fmt.Errorf("invalid aggregate-mode %q (allowed: %s, %s)", mode, aggregateModeMax, aggregateModeSum)
+}
+
+// maxDefaultParallelism caps the default number of concurrent scans, since
+// each grype run loads the full vulnerability database into memory.
+const maxDefaultParallelism = 4
+
+// parseParallelism parses the parallelism input. An empty value selects the
+// number of CPUs, capped at maxDefaultParallelism.
+func parseParallelism(value string) (int, error) {
+	if value == "" {
+		return min(runtime.NumCPU(), maxDefaultParallelism), nil
+	}
+	parallelism, err := strconv.Atoi(value)
+	if err != nil || parallelism < 1 {
+		return 0, fmt.Errorf("invalid parallelism %q: must be a positive integer", value)
+	}
+	return parallelism, nil
+}
+
+// runParallel calls fn(i) for every i in [0, count) on at most workers
+// goroutines and waits for all calls to finish. fn must only write to state
+// owned by index i, e.g. the i-th element of a result slice.
+func runParallel(workers, count int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, count); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// tagScan is the result of scanning one tag in scanMatchingTags.
+type tagScan struct {
+	output     *GrypeOutput
+	rawJSON    []byte
+	resultPath string
+	err        error
+}
+
+// validateScanTagsGlob rejects malformed scan-tags-glob patterns before any
+// tag is listed or scanned.
+func validateScanTagsGlob(glob string) error {
+	if glob == "" {
+		return nil
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return fmt.Errorf("invalid scan-tags-glob %q: %w", glob, err)
+	}
+	return nil
+}
+
+// matchingTags returns the tags from tagNames that match glob, oldest version
+// first. Pre-release tags are skipped unless includePrereleases is set.
+func matchingTags(tagNames []string, glob string, includePrereleases bool) []string {
+	var tags []string
+	for _, tag := range tagNames {
+		if matched, _ := path.Match(glob, tag); !matched {
+			continue
+		}
+		if isPreReleaseTag(tag) && !includePrereleases {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return compareTagsDesc(tags[i], tags[j]) > 0
+	})
+	return tags
+}
+
+// worseStats reports whether a is a worse scan result than b: more critical
+// findings, then more high, medium, low, negligible, and other findings, in
+// that order.
+func worseStats(a, b VulnerabilityStats) bool {
+	for _, pair := range [][2]int{
+		{a.Critical, b.Critical},
+		{a.High, b.High},
+		{a.Medium, b.Medium},
+		{a.Low, b.Low},
+		{a.Negligible, b.Negligible},
+		{a.Other, b.Other},
+	} {
+		if pair[0] != pair[1] {
+			return pair[0] > pair[1]
+		}
+	}
+	return false
+}
+
+// sumTagOutputs combines per-tag results for aggregate-mode sum: the matches
+// and ignored matches of every output, in order, under the descriptor and
+// source of the last one, which scanMatchingTags passes as the newest tag.
+// Without dedupe-cves its stats are the per-tag stats added up.
+func sumTagOutputs(outputs []*GrypeOutput) *GrypeOutput {
+	summed := *outputs[len(outputs)-1]
+	summed.Matches, summed.IgnoredMatches = nil, nil
+	for _, output := range outputs {
+		summed.Matches = append(summed.Matches, output.Matches...)
+		summed.IgnoredMatches = append(summed.IgnoredMatches, output.IgnoredMatches...)
+	}
+	return &summed
+}
+
+// tagResultPath is the per-tag JSON file in dir; slashes in tag names such as
+// "release/v1" are replaced so every tag maps to a single file.
+func tagResultPath(dir, tag string) string {
+	return filepath.Join(dir, strings.ReplaceAll(tag, "/", "_")+".json")
+}
+
+// scanMatchingTags scans every tag of the local repository that matches
+// config.ScanTagsGlob and returns the result of the worst one.
+//
+// Up to config.Parallelism tags (see parseParallelism) are scanned
+// concurrently, each in its own temporary worktree via scanRef with its own
+// grype output file; scanRef removes the worktree even when the scan fails,
+// so a failing tag never leaves other worktrees behind or affects their
+// results. Every tag's raw grype JSON is written to config.ScanTagsDir (see
+// tagResultPath), and output-file receives the worst tag's results.
+//
+// Results are aggregated oldest version first once all scans have finished,
+// so the outcome does not depend on scheduling: among equally bad tags the
+// newest one is reported, and when several tags fail, the error of the
+// oldest one is returned. With config.AggregateMode sum, the findings of all
+// tags are added up (see sumTagOutputs) and output-file receives the summed
+// results instead of the worst tag's.
+//
+// Returns the aggregated parsed output, its raw JSON, and the worst tag's
+// name, or an error if no tag matches, a tag cannot be scanned, or a result
+// cannot be written. Called from run() instead of the single-target scan
+// when scan-tags-glob is set.
+func scanMatchingTags(config Config) (*GrypeOutput, []byte, string, error) {
+	if err := validateArtifactModes(config); err != nil {
+		return nil, nil, "", err
+	}
+	if err := requireGitRepository(); err != nil {
+		return nil, nil, "", err
+	}
+	parallelism, err := parseParallelism(config.Parallelism)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	tagNames, err := listRepoTags()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	tags := matchingTags(tagNames, config.ScanTagsGlob, config.ScanTagsIncludePrereleases)
+	if len(tags) == 0 {
+		return nil, nil, "", fmt.Errorf("no tags match scan-tags-glob %q", config.ScanTagsGlob)
+	}
+
+	dir := config.ScanTagsDir
+	if dir == "" {
+		dir = defaultScanTagsDir
+	}
+
+	results := make([]tagScan, len(tags))
+	runParallel(parallelism, len(tags), func(i int) {
+		tag := tags[i]
+		logInfof("Scanning tag %s", tag)
+		output, rawJSON, err := scanRef(config, tag)
+		if err != nil {
+			results[i].err = fmt.Errorf("failed to scan tag %s: %w", tag, err)
+			return
+		}
+		resultPath, err := writeWorkspaceFile(tagResultPath(dir, tag), rawJSON)
+		if err != nil {
+			results[i].err = fmt.Errorf("failed to save results of tag %s: %w", tag, err)
+			return
+		}
+		results[i] = tagScan{output: output, rawJSON: rawJSON, resultPath: resultPath}
+	})
+
+	var worstOutput *GrypeOutput
+	var worstJSON []byte
+	var worstTag, worstPath string
+	var worst VulnerabilityStats
+	outputs := make([]*GrypeOutput, len(tags))
+	for i, tag := range tags {
+		result := results[i]
+		if result.err != nil {
+			return nil, nil, "", result.err
+		}
+		outputs[i] = result.output
+
+		stats := calculateStats(result.output, config.UnknownSeverityAs, config.DedupeCVEs)
+		logInfof("  %s: %d vulnerabilities (critical: %d, high: %d)", tag, stats.Total, stats.Critical, stats.High)
+		if worstOutput == nil || !worseStats(worst, stats) {
+			worstOutput, worstJSON, worstTag, worstPath, worst = result.output, result.rawJSON, tag, result.resultPath, stats
+		}
+	}
+
+	logInfof("Worst result of %d tags: %s", len(tags), worstTag)
+	output, rawJSON, srcPath, label := worstOutput, worstJSON, worstPath, worstTag
+	if config.AggregateMode == aggregateModeSum {
+		output = sumTagOutputs(outputs)
+		if rawJSON, err = json.Marshal(output); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to marshal summed results: %w", err)
+		}
+		label = fmt.Sprintf("all %d tags", len(tags))
+		logInfof("Summed results of %d tags: %d vulnerabilities", len(tags), len(output.Matches))
+	}
+
+	if len(config.OutputFiles) > 0 {
+		maxSize, err := parseMaxOutputSize(config.MaxOutputSize)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if output != worstOutput {
+			tmpFile, err := os.CreateTemp("", "grype-tags-*.json")
+			if err != nil {
+				return nil, nil, "", fmt.Errorf("failed to create temp file: %w", err)
+			}
+			srcPath = tmpFile.Name()
+			defer os.Remove(srcPath)
+			_, err = tmpFile.Write(rawJSON)
+			if closeErr := tmpFile.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return nil, nil, "", fmt.Errorf("failed to write summed results: %w", err)
+			}
+		}
+		savedPaths, err := saveOutputFiles(srcPath, config.OutputFiles, output, config.IncludeFindingHash, maxSize)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to save output file: %w", err)
+		}
+		for _, savedPath := range savedPaths {
+			logInfof("Scan results of %s saved to: %s", label, savedPath)
+		}
+	}
+	return output, rawJSON, worstTag, nil
+}
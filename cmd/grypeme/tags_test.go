@@ -0,0 +1,345 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMatchingTags verifies that scan-tags-glob selects the intended releases
+// in release order, regardless of how tags are listed.
+//
+// This test covers matchingTags and validateScanTagsGlob in tags.go.
+//
+// It asserts that only tags matching the glob are returned oldest version
+// first, that pre-releases are skipped unless included explicitly, and that
+// malformed globs are rejected.
+func TestMatchingTags(t *testing.T) {
+	tagNames := []string{"v1.10.0", "v2.0.0", "v1.2.0", "v1.3.0-rc.1", "v1.9.0", "nightly"}
+
+	t.Run("returns matching stable tags oldest first", func(t *testing.T) {
+		got := matchingTags(tagNames, "v1.*", false)
+		want := []string{"v1.2.0", "v1.9.0", "v1.10.0"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("matchingTags() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("includes pre-releases on request", func(t *testing.T) {
+		got := matchingTags(tagNames, "v1.*", true)
+		want := []string{"v1.2.0", "v1.3.0-rc.1", "v1.9.0", "v1.10.0"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("matchingTags() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("returns nothing when no tag matches", func(t *testing.T) {
+		if got := matchingTags(tagNames, "v3.*", false); len(got) != 0 {
+			t.Errorf("matchingTags() = %v, want none", got)
+		}
+	})
+
+	t.Run("rejects malformed glob", func(t *testing.T) {
+		if err := validateScanTagsGlob("v1.[0-"); err == nil || !strings.Contains(err.Error(), "scan-tags-glob") {
+			t.Errorf("validateScanTagsGlob() error = %v, want scan-tags-glob error", err)
+		}
+	})
+}
+
+// TestWorseStats verifies that the reported tag is the one with the most
+// severe findings, not simply the one with the most findings.
+//
+// This test covers worseStats in tags.go, used by scanMatchingTags.
+//
+// It asserts that a single critical outweighs many lower findings, that
+// counts are compared severity by severity, and that equal stats are not
+// worse.
+func TestWorseStats(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b VulnerabilityStats
+		want bool
+	}{
+		{"critical outweighs many highs", VulnerabilityStats{Critical: 1}, VulnerabilityStats{High: 20}, true},
+		{"more highs at equal criticals", VulnerabilityStats{Critical: 1, High: 2}, VulnerabilityStats{Critical: 1, High: 1}, true},
+		{"fewer mediums is not worse", VulnerabilityStats{Medium: 1}, VulnerabilityStats{Medium: 3}, false},
+		{"equal stats are not worse", VulnerabilityStats{Low: 2}, VulnerabilityStats{Low: 2}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := worseStats(tt.a, tt.b); got != tt.want {
+				t.Errorf("worseStats(%+v, %+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSumTagOutputs verifies that with aggregate-mode sum the badge and
+// fail-build decision count the findings of every scanned release, while
+// max keeps reporting only the worst release.
+//
+// This test covers sumTagOutputs, validateAggregateMode, and worseStats in
+// tags.go, as combined by scanMatchingTags.
+//
+// It aggregates two targets with differing counts and asserts that sum adds
+// up every severity and keeps the newest target's descriptor, that max
+// selects the target with the critical finding, and that unknown modes are
+// rejected.
+func TestSumTagOutputs(t *testing.T) {
+	older := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-CRIT", "Critical", "openssl", "3.0.0", nil, "", ""),
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "", ""),
+	}}
+	older.Descriptor.Version = "0.105.0"
+	newer := &GrypeOutput{Matches: []GrypeMatch{
+		makeMatch("CVE-HIGH-1", "High", "curl", "8.0.0", nil, "", ""),
+		makeMatch("CVE-HIGH-2", "High", "curl", "8.0.0", nil, "", ""),
+		makeMatch("CVE-LOW", "Low", "zlib", "1.2.11", nil, "", ""),
+	}}
+	newer.Descriptor.Version = "0.106.0"
+	olderStats, newerStats := calculateStats(older, "", false), calculateStats(newer, "", false)
+
+	summed := sumTagOutputs([]*GrypeOutput{older, newer})
+	got := calculateStats(summed, "", false)
+	want := VulnerabilityStats{Total: 5, Distinct: 4, Critical: 1, High: 2, Low: 2}
+	if got != want {
+		t.Errorf("sum stats = %+v, want %+v", got, want)
+	}
+	if summed.Descriptor.Version != "0.106.0" {
+		t.Errorf("summed descriptor version = %q, want the newest target's 0.106.0", summed.Descriptor.Version)
+	}
+	if len(older.Matches) != 2 || len(newer.Matches) != 3 {
+		t.Error("sumTagOutputs should not modify the per-target outputs")
+	}
+
+	if !worseStats(olderStats, newerStats) {
+		t.Errorf("max: %+v should be worse than %+v", olderStats, newerStats)
+	}
+
+	for _, mode := range []string{"", "max", "sum"} {
+		if err := validateAggregateMode(mode); err != nil {
+			t.Errorf("validateAggregateMode(%q) error = %v, want nil", mode, err)
+		}
+	}
+	if err := validateAggregateMode("avg"); err == nil || !strings.Contains(err.Error(), "aggregate-mode") {
+		t.Errorf("validateAggregateMode(\"avg\") error = %v, want aggregate-mode error", err)
+	}
+}
+
+// TestRunParallel verifies that concurrent scans cover every target exactly
+// once and never run more scans at a time than configured.
+//
+// This test covers runParallel and parseParallelism in tags.go.
+//
+// It runs 20 jobs on 3 workers and asserts each index ran once with at most
+// 3 running at a time, then asserts the parallelism default and that zero,
+// negative, and non-numeric values are rejected.
+func TestRunParallel(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	calls := make([]int, 20)
+	runParallel(3, len(calls), func(i int) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+		calls[i]++
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	for i, n := range calls {
+		if n != 1 {
+			t.Errorf("job %d ran %d times, want 1", i, n)
+		}
+	}
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", peak)
+	}
+
+	t.Run("defaults to CPUs capped at 4", func(t *testing.T) {
+		got, err := parseParallelism("")
+		if err != nil || got != min(runtime.NumCPU(), maxDefaultParallelism) {
+			t.Errorf("parseParallelism(\"\") = %d, %v, want %d", got, err, min(runtime.NumCPU(), maxDefaultParallelism))
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		for _, value := range []string{"0", "-2", "many"} {
+			if _, err := parseParallelism(value); err == nil || !strings.Contains(err.Error(), "parallelism") {
+				t.Errorf("parseParallelism(%q) error = %v, want parallelism error", value, err)
+			}
+		}
+	})
+}
+
+// TestScanMatchingTags verifies that release engineers get the worst result of
+// all matching releases plus one JSON file per release, and that no temporary
+// worktree survives the run.
+//
+// This test covers scanMatchingTags in tags.go against the repository from
+// setupTestRepoWithTags and a fake grype whose findings depend on the
+// checked-out README.md.
+//
+// It expects v1.0.0 (one critical) to be reported over v1.10.0 (one low), the
+// pre-release to be skipped, per-tag files for both releases, and no
+// grype-scan-* directories left behind, also when a later tag fails to scan.
+// With aggregate-mode sum both tags' findings must be reported and saved to
+// output-file. With parallelism 3 every tag must be scanned exactly once and
+// ties must still resolve to the newest tag. The per-tag result lines are printed as
+// progress messages, so quiet: true suppresses them.
+func TestScanMatchingTags(t *testing.T) {
+	repoDir := setupTestRepoWithTags(t)
+	oldWD, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(oldWD) })
+	if err := os.Chdir(repoDir); err != nil {
+		t.Fatalf("chdir failed: %v", err)
+	}
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+
+	assertNoWorktrees := func(t *testing.T, tmpDir string) {
+		t.Helper()
+		leftovers, _ := filepath.Glob(filepath.Join(tmpDir, "grype-scan-*"))
+		if len(leftovers) > 0 {
+			t.Errorf("temporary worktrees left behind: %v", leftovers)
+		}
+	}
+
+	t.Run("reports the worst tag and writes per-tag results", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		t.Setenv("TMPDIR", tmpDir)
+		installFakeGrype(t, `case "$(cat "${1#dir:}/README.md")" in
+stable) echo '{"matches":[{"vulnerability":{"id":"CVE-CRIT","severity":"Critical"},"artifact":{"name":"openssl","version":"3.0.0"}}]}' > "$5" ;;
+*) echo '{"matches":[{"vulnerability":{"id":"CVE-LOW","severity":"Low"},"artifact":{"name":"zlib","version":"1.2.11"}}]}' > "$5" ;;
+esac`)
+
+		var output *GrypeOutput
+		var worstTag string
+		var err error
+		out := captureStdout(t, func() {
+			output, _, worstTag, err = scanMatchingTags(Config{ScanTagsGlob: "v1.*", ScanTagsDir: "tags"})
+		})
+		if err != nil {
+			t.Fatalf("scanMatchingTags() error = %v", err)
+		}
+		if !strings.Contains(out, "  v1.0.0: 1 vulnerabilities (critical: 1, high: 0)\n") {
+			t.Errorf("output = %q, want per-tag result line", out)
+		}
+		if worstTag != "v1.0.0" || len(output.Matches) != 1 || output.Matches[0].Vulnerability.ID != "CVE-CRIT" {
+			t.Errorf("worst tag = %s with %+v, want v1.0.0 with CVE-CRIT", worstTag, output.Matches)
+		}
+		for _, tag := range []string{"v1.0.0", "v1.10.0"} {
+			if _, err := os.Stat(filepath.Join(workspace, "tags", tag+".json")); err != nil {
+				t.Errorf("per-tag result for %s missing: %v", tag, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(workspace, "tags", "v1.0.0-alpha.json")); err == nil {
+			t.Error("pre-release v1.0.0-alpha was scanned without include-prereleases")
+		}
+		assertNoWorktrees(t, tmpDir)
+	})
+
+	t.Run("sums the findings of all tags with aggregate-mode sum", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		t.Setenv("TMPDIR", tmpDir)
+		installFakeGrype(t, `case "$(cat "${1#dir:}/README.md")" in
+stable) echo '{"matches":[{"vulnerability":{"id":"CVE-CRIT","severity":"Critical"},"artifact":{"name":"openssl","version":"3.0.0"}}]}' > "$5" ;;
+*) echo '{"matches":[{"vulnerability":{"id":"CVE-LOW","severity":"Low"},"artifact":{"name":"zlib","version":"1.2.11"}}]}' > "$5" ;;
+esac`)
+
+		var output *GrypeOutput
+		var worstTag string
+		var err error
+		captureStdout(t, func() {
+			output, _, worstTag, err = scanMatchingTags(Config{ScanTagsGlob: "v1.*", ScanTagsDir: "summed", AggregateMode: aggregateModeSum, OutputFiles: []string{"all.json"}})
+		})
+		if err != nil {
+			t.Fatalf("scanMatchingTags() error = %v", err)
+		}
+		if stats := calculateStats(output, "", false); stats.Total != 2 || stats.Critical != 1 || stats.Low != 1 {
+			t.Errorf("summed stats = %+v, want one critical and one low", stats)
+		}
+		if worstTag != "v1.0.0" {
+			t.Errorf("worst tag = %s, want v1.0.0", worstTag)
+		}
+		saved, err := parseGrypeOutput(filepath.Join(workspace, "all.json"), false)
+		if err != nil || len(saved.Matches) != 2 {
+			t.Errorf("output-file = %+v, %v, want the summed results of both tags", saved, err)
+		}
+		assertNoWorktrees(t, tmpDir)
+	})
+
+	t.Run("cleans up worktrees when a tag fails to scan", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		t.Setenv("TMPDIR", tmpDir)
+		installFakeGrype(t, `grep -q higher "${1#dir:}/README.md" && exit 2
+echo '{"matches":[]}' > "$5"`)
+
+		var err error
+		captureStdout(t, func() {
+			_, _, _, err = scanMatchingTags(Config{ScanTagsGlob: "v1.*", ScanTagsDir: "tags"})
+		})
+		if err == nil || !strings.Contains(err.Error(), "v1.10.0") {
+			t.Errorf("scanMatchingTags() error = %v, want failure naming v1.10.0", err)
+		}
+		assertNoWorktrees(t, tmpDir)
+	})
+
+	t.Run("scans every tag concurrently with deterministic results", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		t.Setenv("TMPDIR", tmpDir)
+		scanned := filepath.Join(t.TempDir(), "scanned.txt")
+		installFakeGrype(t, `echo "$(cat "${1#dir:}/README.md")" >> "`+scanned+`"
+echo '{"matches":[{"vulnerability":{"id":"CVE-HIGH","severity":"High"},"artifact":{"name":"curl","version":"8.0.0"}}]}' > "$5"`)
+
+		setQuietLogging(true)
+		t.Cleanup(func() { setQuietLogging(false) })
+
+		var worstTag string
+		var err error
+		out := captureStdout(t, func() {
+			_, _, worstTag, err = scanMatchingTags(Config{ScanTagsGlob: "v1.*", ScanTagsIncludePrereleases: true, ScanTagsDir: "parallel", Parallelism: "3"})
+		})
+		if err != nil {
+			t.Fatalf("scanMatchingTags() error = %v", err)
+		}
+		if strings.Contains(out, "vulnerabilities (critical") {
+			t.Errorf("output = %q, want per-tag result lines suppressed with quiet", out)
+		}
+		data, _ := os.ReadFile(scanned)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		sort.Strings(lines)
+		if want := []string{"alpha", "higher minor", "stable"}; !reflect.DeepEqual(lines, want) {
+			t.Errorf("scanned READMEs = %v, want every tag once (%v)", lines, want)
+		}
+		// Equal results everywhere: the newest tag wins regardless of scheduling
+		if worstTag != "v1.10.0" {
+			t.Errorf("worst tag = %s, want newest tag v1.10.0 on ties", worstTag)
+		}
+		for _, tag := range []string{"v1.0.0-alpha", "v1.0.0", "v1.10.0"} {
+			if _, err := os.Stat(filepath.Join(workspace, "parallel", tag+".json")); err != nil {
+				t.Errorf("per-tag result for %s missing: %v", tag, err)
+			}
+		}
+		assertNoWorktrees(t, tmpDir)
+	})
+
+	t.Run("rejects a glob without matching tags", func(t *testing.T) {
+		_, _, _, err := scanMatchingTags(Config{ScanTagsGlob: "v9.*"})
+		if err == nil || !strings.Contains(err.Error(), "no tags match") {
+			t.Errorf("scanMatchingTags() error = %v, want no tags match", err)
+		}
+	})
+}
@@ -13,27 +13,63 @@ type GrypeMatch struct {
 			Versions []string `json:"versions"` // Versions that fix this vulnerability
 			State    string   `json:"state"`    // Fix state: "fixed", "not-fixed", "wont-fix", or "unknown"
 		} `json:"fix"`
+		EPSS []EPSSScore `json:"epss,omitempty"` // Exploit prediction scores (only present in newer Grype versions)
 	} `json:"vulnerability"`
 	Artifact struct {
 		Name    string `json:"name"`    // Package name (e.g., "openssl", "lodash")
 		Version string `json:"version"` // Installed version of the package
 		Type    string `json:"type"`    // Package type (e.g., "go-module", "npm", "deb")
+		// Licenses lists the package licenses syft found (e.g., "MIT", "Apache-2.0").
+		Licenses []string `json:"licenses,omitempty"`
+		// Locations lists the files the package was found in, relative to the scan root.
+		Locations []struct {
+			Path string `json:"path"`
+		} `json:"locations,omitempty"`
 	} `json:"artifact"`
 }
 
+// EPSSScore is one Exploit Prediction Scoring System entry attached to a vulnerability.
+type EPSSScore struct {
+	EPSS       float64 `json:"epss"`       // Probability of exploitation in the next 30 days (0..1)
+	Percentile float64 `json:"percentile"` // Percentile of this score among all scored CVEs (0..1)
+}
+
+// MaxEPSS returns the highest EPSS entry of the match's vulnerability.
+// The boolean is false when Grype did not report any EPSS data.
+func (m GrypeMatch) MaxEPSS() (EPSSScore, bool) {
+	if len(m.Vulnerability.EPSS) == 0 {
+		return EPSSScore{}, false
+	}
+	best := m.Vulnerability.EPSS[0]
+	for _, score := range m.Vulnerability.EPSS[1:] {
+		if score.EPSS > best.EPSS {
+			best = score
+		}
+	}
+	return best, true
+}
+
 // GrypeOutput represents the complete JSON output from a Grype scan.
 // It contains all vulnerability matches and metadata about the Grype version and database.
 type GrypeOutput struct {
-	Matches    []GrypeMatch `json:"matches"` // List of all vulnerability matches
-	Descriptor struct {
+	Matches []GrypeMatch `json:"matches"` // List of all vulnerability matches
+	// IgnoredMatches lists matches suppressed by grype's own ignore rules
+	// (e.g., from .grype.yaml); they are not part of Matches or any count.
+	IgnoredMatches []GrypeMatch `json:"ignoredMatches,omitempty"`
+	Descriptor     struct {
 		Version string `json:"version"` // Grype version used for the scan
-		DB      struct {
+		// Timestamp is when grype ran the scan.
+		// Format: RFC3339 (e.g., "2026-01-30T12:34:56.123456789Z")
+		Timestamp string `json:"timestamp,omitempty"`
+		DB        struct {
 			// Built contains the database build timestamp for older Grype versions (< 0.106).
 			// Format: RFC3339 (e.g., "2026-01-30T12:34:56Z")
 			Built string `json:"built,omitempty"`
 			// Status contains database metadata for newer Grype versions (>= 0.106).
 			Status struct {
 				Built string `json:"built,omitempty"` // Database build timestamp
+				// SchemaVersion is the database schema version (e.g., 6); 0 when not reported.
+				SchemaVersion int `json:"schemaVersion,omitempty"`
 			} `json:"status,omitempty"`
 		} `json:"db"`
 	} `json:"descriptor"`
@@ -55,38 +91,159 @@ func (o *GrypeOutput) DBBuilt() string {
 // Config holds all configuration options for the GitHub Action.
 // Values are typically loaded from environment variables (INPUT_* prefix).
 type Config struct {
+	// Mode selects what the action does: "scan" (default) or "preflight",
+	// which only checks that grype and its database are usable
+	Mode string
 	// Scan modes - these are mutually exclusive with artifact modes
 	// Scan specifies the repository scan mode: "latest_release", "head", or a specific tag/branch name
 	Scan string
+	// RepoURL is an optional remote repository (https:// or git://) to clone and scan
+	// instead of the local checkout; Scan then selects the remote ref
+	RepoURL string
+	// ScanSubdir scopes repository scans (including scan-tags-glob) to this
+	// directory relative to the repository root, e.g. "services/api"
+	ScanSubdir string
+	// ScanTagsGlob scans every local tag matching this glob (e.g. "v1.*") instead
+	// of a single ref and reports the worst result; pre-releases are skipped
+	// unless ScanTagsIncludePrereleases is set. Per-tag JSON goes to ScanTagsDir.
+	ScanTagsGlob               string
+	ScanTagsIncludePrereleases bool
+	ScanTagsDir                string
+	// Parallelism is the number of tags scanned concurrently (default: CPUs, at most 4)
+	Parallelism string
+	// AggregateMode combines the per-tag results of ScanTagsGlob: "max"
+	// (default) reports the worst tag, "sum" adds up the findings of all tags
+	AggregateMode string
 
 	// Artifact modes - mutually exclusive with each other and with Scan
-	Image       string // Container image reference to scan (e.g., "alpine:latest")
-	ImageSource string // Source for image scans: auto, registry, docker, podman, containerd
-	Path        string // Local directory or file path to scan
-	SBOM        string // Path to an SBOM file to scan (CycloneDX, SPDX, or Syft formats)
+	Image        string // Container image reference to scan (e.g., "alpine:latest")
+	ImageSource  string // Source for image scans: auto, registry, docker, podman, containerd
+	ImageArchive string // Image tarball from "docker save" or an OCI tool, scanned as docker-archive or oci-archive
+	Platform     string // Image platform passed to grype as --platform (e.g., "linux/amd64"); image targets only
+	ScanScope    string // Image layers passed to grype as --scope: squashed or all-layers; image targets only
+	Path         string // Local directory or file path to scan
+	SBOM         string // Path to an SBOM file to scan (CycloneDX, SPDX, or Syft formats)
+	SBOMStdin    bool   // If true, read the SBOM to scan from stdin
+	SBOMContent  string // SBOM document to scan, passed inline instead of as a file
+
+	// Registry credentials for private images (passed to grype as GRYPE_REGISTRY_AUTH_*; never printed)
+	RegistryServer   string // Registry host the credentials apply to; empty applies them to any registry
+	RegistryUsername string // Registry username
+	RegistryPassword string // Registry password or access token
 
 	// Scan behavior options
-	FailBuild      bool   // If true, exit with error when vulnerabilities exceed severity cutoff
-	SeverityCutoff string // Minimum severity to trigger fail-build: critical, high, medium, low, negligible
-	OutputFile     string // Path to save the JSON scan results
-	OnlyFixed      bool   // If true, only report vulnerabilities that have fixes available
-	DBUpdate       bool   // If true, update the Grype vulnerability database before scanning
-	Debug          bool   // If true, print debug information including environment variables
-	Description    string // Optional free-text description included verbatim in the Markdown report
+	FailBuild         bool     // If true, exit with error when vulnerabilities exceed severity cutoff
+	SeverityCutoff    string   // Minimum severity to trigger fail-build: critical, high, medium, low, negligible, or all/any/none
+	FailOnTypes       []string // If non-empty, only matches of these package types (e.g., "go-module") count for fail-build
+	MaxAllowed        string   // fail-build only fails when more than this many findings are at or above the cutoff (default 0)
+	SeverityBudget    string   // Per-severity fail-build budget (e.g. "critical=0,high=2"); replaces SeverityCutoff when set
+	OutputFiles       []string // Paths to save the scan results to, each in the format its extension selects
+	MaxOutputSize     string   // Largest raw JSON copied to OutputFiles (e.g. "50MB"); empty or "0" means unlimited
+	SBOMOutput        string   // Path to save a CycloneDX SBOM of the scanned target
+	TemplateFile      string   // Go template rendered by grype (-o template -t) into the single OutputFiles entry instead of the JSON copy
+	BaselineFile      string   // Path to a previous grype JSON; enables diff mode (only new findings gate fail-build)
+	OnlyFixed         bool     // If true, only report vulnerabilities that have fixes available
+	AddCPEs           bool     // If true, pass --add-cpes-if-none so packages without CPEs still get CPE matches
+	ByCVE             bool     // If true, pass --by-cve so matches are keyed by CVE instead of the original advisory
+	MaxSeverity       string   // If set, only matches at or below this severity are counted and reported
+	UnknownSeverityAs string   // If set, matches with empty or "unknown" severity are counted as this severity instead of Other
+	DedupeCVEs        bool     // If true, counts cover distinct vulnerability IDs instead of every package match
+	IgnorePackages    []string // Package names (or name@version) whose matches are dropped before counting and reporting
+	IgnoreFile        string   // Path to ignore rules (vulnerability, package glob, fix-state) applied like IgnorePackages
+	DBUpdate          bool     // If true, update the Grype vulnerability database before scanning
+	DBCacheDir        string   // Persistent grype DB cache directory (GRYPE_DB_CACHE_DIR), e.g. restored by actions/cache
+	DBMaxAge          string   // Maximum age of the cached DB before db-update downloads a new one (default 24h)
+	InputJSON         string   // Existing grype JSON to process instead of running grype (dry run)
+	GrypeConfig       string   // Path to a grype config file (.grype.yaml) passed via -c
+	Exclude           []string // Glob patterns passed to grype as repeated --exclude arguments
+	IncludeGitDir     bool     // If true, do not exclude ./.git/** from directory scans
+	AllowPartial      bool     // If true, recover the complete matches from truncated grype JSON instead of failing
+	ScanTimeout       string   // Maximum grype scan duration (Go duration, e.g. "10m"); empty or "0" disables the timeout
+	Debug             bool     // If true, print debug information including environment variables
+	Quiet             bool     // If true, suppress informational progress messages; warnings, errors, and the summary still print
+	LogFormat         string   // Log message format: "text" (default) or "json" (one JSON object per line)
+	StrictOutputs     bool     // If true, failing to write GITHUB_OUTPUT fails the action instead of warning
+	Description       string   // Optional free-text description included verbatim in the Markdown report
+	CollapseVersions  bool     // If true, merge report rows of one CVE+package across installed versions
+	ReportMaxRows     string   // Maximum rows in the report's CVE table (default 200, "0" = unlimited)
+	ReportSort        string   // Report row order: "severity" (default) or "package"
+	MatchesJSONLimit  string   // Maximum entries in the matches-json output (default 50, "0" = unlimited)
+	ReportMinSeverity string   // Lowest severity listed in the report's detailed tables; counts stay complete
+	CVELinkTemplate   string   // Report Source link with {id} replaced by the vulnerability ID (e.g. "https://tracker/vuln/{id}")
+	DBAgeBadge        bool     // If true, emit a db-age-badge-url output showing the vulnerability DB age
+	DBStaleAge        string   // DB age beyond which db-stale is true and a warning is printed (e.g. "7d", "72h"; default 7d)
+	FailOnStaleDB     bool     // If true, fail the run when the DB is older than DBStaleAge, before evaluating findings
+	BadgeHost         string   // shields.io-compatible server for badge URLs (default: https://img.shields.io)
+	BadgeStyle        string   // shields.io badge style (flat, flat-square, plastic, for-the-badge, social); empty keeps the default
+	BadgeColor        string   // Fixed badge color overriding the severity color (shields.io name or hex)
+	BadgeLabel        string   // Fixed badge label replacing the generated "✊ grype <version>"
+
+	// Export options
+	CSVFile            string // Path to write a CSV export of the vulnerability matches
+	SummaryFile        string // Path to write a compact JSON summary (counts and metadata only)
+	OSVFile            string // Path to write the matches as OSV vulnerability objects
+	JUnitFile          string // Path to write a JUnit XML report (one testcase per match, failing at/above SeverityCutoff)
+	HTMLFile           string // Path to write a self-contained HTML report (summary and CVE tables, inline CSS)
+	LicenseReport      string // Path to write a Markdown license inventory (package -> licenses) of the matched packages
+	Annotations        bool   // If true, print a workflow annotation per match (error/warning at or above SeverityCutoff, notice below)
+	IncludeFindingHash bool   // If true, per-finding exports carry a stable findingHash for downstream deduplication
+
+	// CVE changelog (optional; scans the most recent releases of the local repository)
+	CVEChangelogFile     string // Path to write the release-to-release CVE changelog (Markdown)
+	CVEChangelogReleases string // Number of most recent releases to include (default 3)
+
+	// Grype version guard (optional)
+	ExpectedGrypeRange string // Accepted Grype version range (e.g., ">=0.100.0 <1.0.0"); empty disables the check
+	StrictGrypeRange   bool   // If true, a Grype version outside ExpectedGrypeRange fails the action instead of warning
+
+	// GraphQL integration (optional)
+	GraphQLURL      string // GraphQL endpoint that receives the scan summary mutation
+	GraphQLMutation string // Mutation template with {{placeholder}} fields substituted from the summary
+	GraphQLToken    string // Optional bearer token for the GraphQL endpoint
+
+	// Webhook integration (optional)
+	WebhookURL            string // Endpoint that receives the scan summary as a JSON POST
+	WebhookAuth           string // Optional bearer token for the webhook
+	WebhookIncludeMatches bool   // If true, the webhook payload also carries every match
+	WebhookRequired       bool   // If true, a failed webhook POST fails the action instead of warning
+
+	// Pull request comment (optional)
+	PRComment   bool   // If true, post or update a scan summary comment on pull_request events
+	GitHubToken string // GitHub token used for the pull request comment API
 
 	// Gist integration (optional)
 	GistToken    string // GitHub token with gist scope for writing badge + report to a gist
 	GistID       string // ID of the gist to update
 	GistFilename string // Base filename for gist files (default: auto-generated from scan mode)
+	GistProxy    string // HTTP(S) proxy URL for the Gist API, overriding HTTP_PROXY/HTTPS_PROXY/NO_PROXY
 }
 
 // VulnerabilityStats contains aggregated vulnerability counts by severity level.
 // Used for generating summaries, badges, and determining fail-build conditions.
 type VulnerabilityStats struct {
-	Total    int // Total number of vulnerabilities found
-	Critical int // Count of critical severity vulnerabilities
-	High     int // Count of high severity vulnerabilities
-	Medium   int // Count of medium severity vulnerabilities
-	Low      int // Count of low severity vulnerabilities
-	Other    int // Count of vulnerabilities with unknown/other severity levels
+	Total      int // Total number of vulnerabilities found (distinct IDs with dedupe-cves)
+	Distinct   int // Number of distinct vulnerability IDs, however many packages each affects
+	Critical   int // Count of critical severity vulnerabilities
+	High       int // Count of high severity vulnerabilities
+	Medium     int // Count of medium severity vulnerabilities
+	Low        int // Count of low severity vulnerabilities
+	Negligible int // Count of negligible severity vulnerabilities
+	Other      int // Count of vulnerabilities with unknown or unrecognized severity levels
+	Fixable    int // Count of vulnerabilities with a fix available (fix state "fixed"), across all severities
+	NotFixed   int // Count of vulnerabilities that need mitigation instead of an upgrade (fix state "not-fixed" or "wont-fix")
+}
+
+// BySeverity returns the per-severity counts keyed by lower-case severity name
+// ("critical", "high", "medium", "low", "negligible", "other"). Machine-readable
+// exports use it so consumers can iterate severities without knowing the
+// struct fields.
+func (s VulnerabilityStats) BySeverity() map[string]int {
+	return map[string]int{
+		"critical":   s.Critical,
+		"high":       s.High,
+		"medium":     s.Medium,
+		"low":        s.Low,
+		"negligible": s.Negligible,
+		"other":      s.Other,
+	}
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -65,6 +68,57 @@ func TestGrypeOutputJSONMarshaling(t *testing.T) {
 	}
 }
 
+// TestGrypeOutputDBSchemaVersion verifies that the database schema version
+// grype reports is captured, so format changes can be diagnosed from outputs.
+//
+// This test covers the Descriptor.DB.Status.SchemaVersion field in types.go
+// and its use by setOutputs and printSummary in output.go.
+//
+// It parses a descriptor shaped like grype's with "schemaVersion": 6 and
+// asserts the field, the db-schema-version output, and the summary line; a
+// descriptor without it yields 0 and no output.
+func TestGrypeOutputDBSchemaVersion(t *testing.T) {
+	fixture := `{"matches":[],"descriptor":{"name":"grype","version":"0.106.0","db":{"status":{"schemaVersion":6,"from":"https://grype.anchore.io/databases/v6/vulnerability-db_v6.0.2.tar.zst","built":"2026-01-30T04:12:34Z","path":"/root/.cache/grype/db/6/vulnerability.db","valid":true}}}}`
+
+	var output GrypeOutput
+	if err := json.Unmarshal([]byte(fixture), &output); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got := output.Descriptor.DB.Status.SchemaVersion; got != 6 {
+		t.Fatalf("SchemaVersion = %d, want 6", got)
+	}
+
+	outFile := filepath.Join(t.TempDir(), "github_output.txt")
+	t.Setenv("GITHUB_OUTPUT", outFile)
+	if err := setOutputs(VulnerabilityStats{}, &output, scanRunInfo{}, "", "image", badgeOptions{}, 0, "", "", nil); err != nil {
+		t.Fatalf("setOutputs() error = %v", err)
+	}
+	content, _ := os.ReadFile(outFile)
+	if !strings.Contains(string(content), "db-schema-version=6") {
+		t.Errorf("outputs missing db-schema-version=6:\n%s", content)
+	}
+	if summary := captureStdout(t, func() { printSummary(VulnerabilityStats{}, &output, scanRunInfo{}) }); !strings.Contains(summary, "db schema: v6") {
+		t.Errorf("printSummary() = %q, want db schema line", summary)
+	}
+
+	var legacy GrypeOutput
+	if err := json.Unmarshal([]byte(`{"matches":[],"descriptor":{"version":"0.87.0","db":{"built":"2025-01-01T00:00:00Z"}}}`), &legacy); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got := legacy.Descriptor.DB.Status.SchemaVersion; got != 0 {
+		t.Errorf("SchemaVersion without status = %d, want 0", got)
+	}
+	if err := os.WriteFile(outFile, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := setOutputs(VulnerabilityStats{}, &legacy, scanRunInfo{}, "", "image", badgeOptions{}, 0, "", "", nil); err != nil {
+		t.Fatalf("setOutputs() error = %v", err)
+	}
+	if content, _ := os.ReadFile(outFile); strings.Contains(string(content), "db-schema-version") {
+		t.Errorf("db-schema-version written without schema version:\n%s", content)
+	}
+}
+
 func TestGrypeMatchStructure(t *testing.T) {
 	jsonData := `{
 		"vulnerability": {
@@ -131,3 +185,25 @@ func TestVulnerabilityStatsZeroValue(t *testing.T) {
 		t.Errorf("Other = %v, want 0", stats.Other)
 	}
 }
+
+// TestVulnerabilityStatsBySeverity verifies that machine-readable exports list
+// every severity bucket with the same numbers shown in the badge and report.
+//
+// This test covers VulnerabilityStats.BySeverity in types.go.
+//
+// It asserts that each severity key maps to the corresponding struct field
+// and that no extra keys are present.
+func TestVulnerabilityStatsBySeverity(t *testing.T) {
+	stats := VulnerabilityStats{Total: 21, Critical: 1, High: 2, Medium: 3, Low: 4, Negligible: 5, Other: 6}
+	want := map[string]int{"critical": 1, "high": 2, "medium": 3, "low": 4, "negligible": 5, "other": 6}
+
+	got := stats.BySeverity()
+	if len(got) != len(want) {
+		t.Fatalf("BySeverity() has %d keys, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("BySeverity()[%q] = %d, want %d", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package main provides posting of scan results to an arbitrary webhook URL.
+// Teams aggregating findings in their own service receive the scan summary
+// (and optionally every match) as a JSON POST.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// webhookTimeout bounds each webhook request so a hanging endpoint cannot stall the action.
+const webhookTimeout = 10 * time.Second
+
+// webhookAttempts is how often a webhook POST is tried before giving up on 5xx responses.
+const webhookAttempts = 3
+
+// webhookRetryDelay is the pause before the first retry; it doubles for each
+// further attempt. A variable so tests can shorten it.
+var webhookRetryDelay = 2 * time.Second
+
+// webhookPayload is the JSON body posted to webhook-url: the summary written
+// by summary-file plus, when webhook-include-matches is set, every match.
+type webhookPayload struct {
+	scanSummary
+	Matches []GrypeMatch `json:"matches,omitempty"`
+}
+
+// postWebhook POSTs payload as JSON to webhookURL.
+//
+// auth, when non-empty, is sent as a bearer token. Each attempt times out
+// after webhookTimeout; transport errors and 5xx responses are retried up to
+// webhookAttempts times with a doubling delay, while other non-2xx responses
+// fail immediately since retrying cannot fix them.
+//
+// Returns nil once the endpoint answers 2xx, or the last error otherwise.
+func postWebhook(webhookURL, auth string, payload []byte) error {
+	client := &http.Client{Timeout: webhookTimeout}
+	delay := webhookRetryDelay
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		if attempt > 1 {
+			logInfof("Retrying webhook in %s (attempt %d/%d): %v", delay, attempt, webhookAttempts, lastErr)
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to create webhook request: %w", withoutURL(err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", "Bearer "+auth)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("webhook request failed: %w", withoutURL(err))
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned %d: %s", resp.StatusCode, truncate(string(body), 200))
+		if resp.StatusCode < 500 {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// validateWebhookURL ensures the endpoint is an absolute http(s) URL.
+func validateWebhookURL(webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook-url: %w", withoutURL(err))
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook-url: must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// withoutURL returns the cause of a *url.Error, whose message repeats the
+// webhook or GraphQL URL along with any credentials in its userinfo or query.
+// Other errors are returned unchanged.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// publishWebhook builds the webhook payload for this scan and posts it.
+//
+// config provides WebhookURL, the optional WebhookAuth token, and
+// WebhookIncludeMatches; output, stats, and scanMode supply the summary (see
+// buildScanSummary).
+//
+// Returns an error for an invalid URL or when the endpoint rejects the
+// request. Called from publishIntegrations, which only warns on error unless
+// webhook-required is set.
+func publishWebhook(config Config, output *GrypeOutput, stats VulnerabilityStats, scanMode string) error {
+	if err := validateWebhookURL(config.WebhookURL); err != nil {
+		return err
+	}
+
+	payload := webhookPayload{scanSummary: buildScanSummary(output, stats, scanMode)}
+	if config.WebhookIncludeMatches {
+		payload.Matches = output.Matches
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	return postWebhook(config.WebhookURL, config.WebhookAuth, data)
+}
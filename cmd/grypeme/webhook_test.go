@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestPostWebhookRetries verifies that a briefly unavailable aggregation
+// service still receives the results, while a rejected request fails fast.
+//
+// This test covers postWebhook in webhook.go.
+//
+// It runs an httptest endpoint and asserts that two 503 responses followed by
+// a 200 succeed after three attempts with the bearer token and JSON content
+// type set, that persistent 5xx responses give up after webhookAttempts with
+// the status in the error, and that a 400 is not retried. It also asserts
+// that a failed request does not repeat credentials from the webhook URL.
+func TestPostWebhookRetries(t *testing.T) {
+	orig := webhookRetryDelay
+	webhookRetryDelay = 0
+	t.Cleanup(func() { webhookRetryDelay = orig })
+
+	tests := []struct {
+		name         string
+		statuses     []int // response per attempt; the last one repeats
+		wantAttempts int32
+		wantErr      string
+	}{
+		{"succeeds after transient 5xx", []int{503, 503, 200}, 3, ""},
+		{"gives up after persistent 5xx", []int{502}, webhookAttempts, "502"},
+		{"does not retry client errors", []int{400}, 1, "400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				if got := r.Header.Get("Authorization"); got != "Bearer hook-token" {
+					t.Errorf("Authorization = %q, want bearer token", got)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			var err error
+			captureStdout(t, func() { err = postWebhook(server.URL, "hook-token", []byte(`{}`)) })
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("postWebhook() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("postWebhook() error = %v, want %s error", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("omits the webhook URL from request errors", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		hookURL := strings.Replace(server.URL, "http://", "http://user:url-secret@", 1) + "/?sig=query-secret"
+		server.Close()
+
+		var err error
+		captureStdout(t, func() { err = postWebhook(hookURL, "", []byte(`{}`)) })
+		if err == nil || !strings.HasPrefix(err.Error(), "webhook request failed") {
+			t.Fatalf("postWebhook() error = %v, want request error", err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("postWebhook() error = %v, want no URL credentials", err)
+		}
+	})
+}
+
+// TestPublishWebhookPayload verifies that the receiving service gets the same
+// summary as summary-file, with the individual matches only on request.
+//
+// This test covers publishWebhook in webhook.go and buildScanSummary in
+// output.go, and publishIntegrations in main.go for webhook-required.
+//
+// It posts a scan to an httptest endpoint and asserts the payload carries the
+// counts, grype version, and scan mode, and matches only with
+// webhook-include-matches. It also asserts an invalid URL is rejected and
+// that a failing webhook only fails publishIntegrations when required.
+func TestPublishWebhookPayload(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	output := &GrypeOutput{Matches: []GrypeMatch{makeMatch("CVE-1", "High", "openssl", "3.0.0", nil, "", "")}}
+	output.Descriptor.Version = "0.106.0"
+	stats := calculateStats(output, "", false)
+
+	for _, include := range []bool{false, true} {
+		config := Config{WebhookURL: server.URL, WebhookIncludeMatches: include}
+		if err := publishWebhook(config, output, stats, "image"); err != nil {
+			t.Fatalf("publishWebhook() error = %v", err)
+		}
+		var payload webhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("payload is not valid JSON: %v", err)
+		}
+		if payload.Total != 1 || payload.High != 1 || payload.GrypeVersion != "0.106.0" || payload.ScanMode != "image" {
+			t.Errorf("payload summary = %+v, want 1 high finding from grype 0.106.0 in image mode", payload.scanSummary)
+		}
+		if got := len(payload.Matches); got != map[bool]int{false: 0, true: 1}[include] {
+			t.Errorf("include matches %v: payload has %d matches", include, got)
+		}
+	}
+
+	if err := publishWebhook(Config{WebhookURL: "ftp://example.com"}, output, stats, "image"); err == nil || !strings.Contains(err.Error(), "webhook-url") {
+		t.Errorf("publishWebhook() error = %v, want webhook-url error", err)
+	}
+
+	for required, wantErr := range map[bool]bool{false: false, true: true} {
+		var err error
+		captureStdout(t, func() {
+			err = publishIntegrations(Config{WebhookURL: "not a url", WebhookRequired: required}, output, stats, "image")
+		})
+		if (err != nil) != wantErr {
+			t.Errorf("webhook-required %v: publishIntegrations() error = %v, want error %v", required, err, wantErr)
+		}
+	}
+}